@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ftl/hamdeck/pkg/control"
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+var ctlFlags = struct {
+	json bool
+}{}
+
+var ctlCmd = &cobra.Command{
+	Use:   "ctl <command> [argument]",
+	Short: "Control a running HamDeck",
+	Long: `Control a running HamDeck through its control socket.
+
+Commands:
+  page <page>        attach the given page
+  press <index>      press and release the key with the given index
+  profile <profile>  switch to the given profile
+  status             show the device, pages, profiles, connections, and scheduled events
+  reload             read the configuration file again`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runCtl,
+}
+
+func init() {
+	ctlCmd.Flags().BoolVar(&ctlFlags.json, "json", false, "print the response as JSON")
+	rootCmd.AddCommand(ctlCmd)
+}
+
+func runCtl(cmd *cobra.Command, args []string) {
+	socket := rootFlags.controlSocket
+	if !cmd.Flags().Changed("control") {
+		socket = control.FindSocket()
+	}
+
+	response, err := control.Send(socket, control.Request{Command: args[0], Args: args[1:]})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if ctlFlags.json {
+		data, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if response.Status != nil {
+		printStatus(*response.Status)
+	}
+}
+
+func printStatus(status hamdeck.Status) {
+	fmt.Printf("Device:   %s (%s)\n", status.Device, status.Serial)
+	fmt.Printf("Page:     %s\n", status.Page)
+	fmt.Printf("Pages:    %s\n", strings.Join(status.Pages, ", "))
+	if len(status.Profiles) > 0 {
+		profile := status.Profile
+		if profile == "" {
+			profile = "-"
+		}
+		fmt.Printf("Profile:  %s\n", profile)
+		fmt.Printf("Profiles: %s\n", strings.Join(status.Profiles, ", "))
+	}
+	if len(status.Connections) > 0 {
+		fmt.Println("Connections:")
+		for _, connection := range status.Connections {
+			state := "disconnected"
+			if connection.Connected {
+				state = "connected"
+			}
+			if !connection.Since.IsZero() {
+				state += " since " + connection.Since.Format(time.DateTime)
+			}
+			if connection.LastError != "" {
+				state += ", last error: " + connection.LastError
+			}
+			fmt.Printf("  %s: %s\n", connection.Name, state)
+		}
+	}
+	if len(status.NextEvents) > 0 {
+		fmt.Println("Next events:")
+		for _, event := range status.NextEvents {
+			fmt.Printf("  %s: %s\n", event.Name, event.Time.Format(time.DateTime))
+		}
+	}
+}
@@ -2,8 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
-	"log/syslog"
+	"log/slog"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -12,9 +11,13 @@ import (
 	"github.com/ftl/hamradio/cfg"
 	"github.com/spf13/cobra"
 
+	"github.com/ftl/hamdeck/pkg/control"
 	"github.com/ftl/hamdeck/pkg/hamdeck"
 	"github.com/ftl/hamdeck/pkg/hamlib"
+	"github.com/ftl/hamdeck/pkg/logging"
+	"github.com/ftl/hamdeck/pkg/metrics"
 	"github.com/ftl/hamdeck/pkg/mqtt"
+	"github.com/ftl/hamdeck/pkg/plugin"
 	"github.com/ftl/hamdeck/pkg/pulse"
 	"github.com/ftl/hamdeck/pkg/streamdeck"
 	"github.com/ftl/hamdeck/pkg/tci"
@@ -26,8 +29,13 @@ var (
 	buildTime string = "unknown"
 )
 
+var logger = logging.For("main")
+
 var rootFlags = struct {
 	syslog        bool
+	logLevel      string
+	logFormat     string
+	simulate      bool
 	serial        string
 	brightness    int
 	configFile    string
@@ -36,6 +44,16 @@ var rootFlags = struct {
 	mqttAddress   string
 	mqttUsername  string
 	mqttPassword  string
+	auditFile     string
+	auditMaxSize  int
+	metrics       string
+	recordFile    string
+	replayFile    string
+	profile       string
+	profilesDir   string
+	splashFile    string
+	controlSocket string
+	controlGroup  string
 }{}
 
 var rootCmd = &cobra.Command{
@@ -47,70 +65,190 @@ var rootCmd = &cobra.Command{
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		fatal("command failed", "error", err)
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&rootFlags.syslog, "syslog", false, "use syslog for logging")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.logLevel, "loglevel", "", "the log levels as comma separated list, e.g. warn,mqtt=debug (overrides the log_level in the configuration, default: info)")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.logFormat, "logformat", string(logging.TextFormat), "the format of the log output: text or json")
+	rootCmd.PersistentFlags().BoolVar(&rootFlags.simulate, "simulate", false, "replace all hamlib, TCI, MQTT, and pulseaudio connections with simulated ones that log every command")
 	rootCmd.PersistentFlags().StringVar(&rootFlags.serial, "serial", "", "the serial number of the Stream Deck device that should be used")
-	rootCmd.PersistentFlags().IntVar(&rootFlags.brightness, "brightness", 100, "the initial brightness of the Stream Deck device")
-	rootCmd.PersistentFlags().StringVar(&rootFlags.configFile, "config", "", "the configuration file that should be used (default: .config/hamradio/hamdeck.json)")
+	rootCmd.PersistentFlags().IntVar(&rootFlags.brightness, "brightness", 100, "the brightness of the Stream Deck device, overrides the last used brightness")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.configFile, "config", "", "the configuration file in JSON, YAML, or TOML format that should be used (default: .config/hamradio/hamdeck.json or .config/hamradio/hamdeck.yaml)")
 	rootCmd.PersistentFlags().StringVar(&rootFlags.hamlibAddress, "hamlib", "", "the address of the rigctld server (if empty, hamlib buttons are not available)")
 	rootCmd.PersistentFlags().StringVar(&rootFlags.tciAddress, "tci", "", "the address of the TCI server (if empty, tci buttons are not available)")
 	rootCmd.PersistentFlags().StringVar(&rootFlags.mqttAddress, "mqtt", "", "the address of the MQTT server (if empty, atu100 buttons are not available)")
 	rootCmd.PersistentFlags().StringVar(&rootFlags.mqttUsername, "mqttusername", "", "the username for MQTT")
 	rootCmd.PersistentFlags().StringVar(&rootFlags.mqttPassword, "mqttpassword", "", "the password for MQTT")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.auditFile, "audit", "", "record all key events, page changes, and commands in this file as JSON lines (if empty, no audit log is written)")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.metrics, "metrics", "", "the address where the Prometheus metrics are provided on /metrics, e.g. localhost:9765 (if empty, no metrics are provided)")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.recordFile, "record", "", "record all key events with their timing in this file, e.g. to reproduce a problem")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.replayFile, "replay", "", "play back the key events recorded in this file instead of using a Stream Deck device")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.profile, "profile", "", "the profile that should be loaded at startup (default: the profile that was active the last time)")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.profilesDir, "profiles", "", "a directory with one configuration file per profile, the profile is named after the file")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.splashFile, "splash", "", "an image that is shown across all keys during startup, already before the configuration is read")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.controlSocket, "control", control.DefaultSocketFilename(), "the Unix domain socket for the control commands of hamdeck ctl (if empty, no control socket is provided)")
+	rootCmd.PersistentFlags().StringVar(&rootFlags.controlGroup, "controlgroup", control.DefaultGroup, "the group that may use the control socket")
+	rootCmd.PersistentFlags().IntVar(&rootFlags.auditMaxSize, "auditmaxsize", hamdeck.DefaultAuditMaxSize/(1024*1024), "the size in MB at which the audit log is rotated")
 }
 
 func run(cmd *cobra.Command, args []string) {
-	log.Printf("Hamdeck Version %s", version)
+	err := setupLogging()
+	if err != nil {
+		fatal("cannot setup logging", "error", err)
+	}
+	logger.Info("Hamdeck", "version", version)
 	shutdown := monitorShutdownSignals()
 
-	if rootFlags.syslog {
-		logger, err := syslog.NewLogger(syslog.LOG_INFO, 0)
-		if err != nil {
-			log.Fatal(err)
-		}
-		log.SetOutput(logger.Writer())
+	if rootFlags.metrics != "" {
+		metricsServer := metrics.Serve(rootFlags.metrics)
+		defer metricsServer.Close()
 	}
 
-	device, err := streamdeck.Open(rootFlags.serial)
+	device, err := openDevice()
 	if err != nil {
-		log.Fatalf("Cannot open Stream Deck: %v", err)
+		fatal("Cannot open Stream Deck", "error", err)
 	}
 	defer func() {
-		log.Print("Closing device")
+		logger.Info("Closing device")
 		err := device.Close()
 		if err != nil {
-			log.Printf("Cannot close Stream Deck: %v", err)
+			logger.Error("Cannot close Stream Deck", "error", err)
 		} else {
-			log.Print("Device closed")
+			logger.Info("Device closed")
 		}
 	}()
 
-	log.Printf("Using Stream Deck %s %dx%d %s", device.ID(), device.Columns(), device.Rows(), device.Serial())
-	log.Printf("Firmware Version %s", device.FirmwareVersion())
-	device.SetBrightness(rootFlags.brightness)
+	logger.Info("Using Stream Deck", "id", device.ID(), "columns", device.Columns(), "rows", device.Rows(), "serial", device.Serial(), "firmware", device.FirmwareVersion())
+
+	store, err := openStore()
+	if err != nil {
+		logger.Error("Cannot restore the runtime state", "error", err)
+	}
+	defer func() {
+		err := store.Flush()
+		if err != nil {
+			logger.Error("Cannot save the runtime state", "error", err)
+		}
+	}()
 
 	deck := hamdeck.New(device)
-	deck.RegisterFactory(hamdeck.NewButtonFactory(deck))
-	deck.RegisterFactory(pulse.NewButtonFactory())
-	deck.RegisterFactory(hamlib.NewButtonFactory(deck, rootFlags.hamlibAddress))
-	deck.RegisterFactory(tci.NewButtonFactory(deck, rootFlags.tciAddress))
-	deck.RegisterFactory(mqtt.NewButtonFactory(deck, rootFlags.mqttAddress, rootFlags.mqttUsername, rootFlags.mqttPassword))
+	deck.SetStore(store)
+
+	if rootFlags.splashFile != "" {
+		splash, err := hamdeck.LoadImageFile(rootFlags.splashFile)
+		if err != nil {
+			logger.Error("Cannot load the splash image", "error", err)
+		} else {
+			deck.ShowSplash(splash)
+		}
+	}
+
+	if rootFlags.auditFile != "" {
+		audit, err := hamdeck.OpenAuditLog(rootFlags.auditFile, int64(rootFlags.auditMaxSize)*1024*1024, hamdeck.DefaultAuditBackups)
+		if err != nil {
+			fatal("Cannot open the audit log", "error", err)
+		}
+		defer audit.Close()
+		deck.SetAuditLog(audit)
+	}
 
-	err = configureHamDeck(deck, rootFlags.configFile)
+	brightness := rootFlags.brightness
+	if storedBrightness, ok := deck.StoredBrightness(); ok && !cmd.Flags().Changed("brightness") {
+		brightness = storedBrightness
+	}
+	err = deck.SetBrightness(brightness)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("Cannot set the brightness", "error", err)
+	}
+
+	hamdeckFactory := hamdeck.NewButtonFactory(deck)
+	defer hamdeckFactory.Close()
+	deck.RegisterFactory(hamdeckFactory)
+	if rootFlags.simulate {
+		logger.Info("Simulation mode: all hamlib, TCI, MQTT, and pulseaudio connections are simulated")
+		deck.RegisterFactory(pulse.NewSimulatedButtonFactory(deck))
+		deck.RegisterFactory(hamlib.NewSimulatedButtonFactory(deck, rootFlags.hamlibAddress))
+		deck.RegisterFactory(tci.NewSimulatedButtonFactory(deck, rootFlags.tciAddress))
+		deck.RegisterFactory(mqtt.NewSimulatedButtonFactory(deck, rootFlags.mqttAddress))
+	} else {
+		deck.RegisterFactory(pulse.NewButtonFactory(deck))
+		deck.RegisterFactory(hamlib.NewButtonFactory(deck, rootFlags.hamlibAddress))
+		deck.RegisterFactory(tci.NewButtonFactory(deck, rootFlags.tciAddress))
+		deck.RegisterFactory(mqtt.NewButtonFactory(deck, rootFlags.mqttAddress, rootFlags.mqttUsername, rootFlags.mqttPassword))
+	}
+	deck.RegisterFactory(plugin.NewButtonFactory(deck))
+
+	if rootFlags.profilesDir != "" {
+		deck.SetProfiles(hamdeck.ProfileDirectory(rootFlags.profilesDir))
+	}
+
+	configFile, err := resolveConfigFile(rootFlags.configFile)
+	if err != nil {
+		fatal("Cannot configure HamDeck", "error", err)
+	}
+	err = configureHamDeck(deck, configFile, rootFlags.profile)
+	if err != nil {
+		fatal("Cannot configure HamDeck", "error", err)
+	}
+
+	reloadable := false
+	if rootFlags.controlSocket != "" {
+		controlServer, err := control.Listen(rootFlags.controlSocket, rootFlags.controlGroup, deck, func() error {
+			return reloadHamDeck(deck, configFile)
+		})
+		if err != nil {
+			logger.Error("Cannot provide the control socket", "error", err)
+		} else {
+			reloadable = true
+			defer controlServer.Close()
+		}
+	}
+	if !reloadable && len(deck.ProfileNames()) == 0 {
+		// connections that are not used now will never be used
+		deck.CloseUnusedFactories()
 	}
 
 	err = deck.Run(shutdown)
 	if err != nil {
-		log.Fatal(err)
+		fatal("HamDeck failed", "error", err)
 	}
 }
 
+// setupLogging configures the output and the levels of all loggers. Everything logged through the log package
+// of the standard library, e.g. by third party libraries, is logged as subsystem "default".
+func setupLogging() error {
+	format, err := logging.ParseFormat(rootFlags.logFormat)
+	if err != nil {
+		return err
+	}
+	if rootFlags.syslog {
+		handler, err := logging.NewSyslogHandler("hamdeck", format)
+		if err != nil {
+			return err
+		}
+		logging.SetHandler(handler)
+	} else {
+		logging.SetOutput(os.Stderr, format)
+	}
+
+	levels, err := logging.ParseLevels(rootFlags.logLevel)
+	if err != nil {
+		return err
+	}
+	logging.SetLevels(levels)
+
+	slog.SetDefault(logging.For("default"))
+	return nil
+}
+
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
 func monitorShutdownSignals() <-chan struct{} {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
@@ -120,7 +258,7 @@ func monitorShutdownSignals() <-chan struct{} {
 		for {
 			<-signals
 			if rude {
-				log.Fatal("graceful shutdown failed")
+				fatal("graceful shutdown failed")
 			}
 			rude = true
 			close(shutdown)
@@ -129,28 +267,100 @@ func monitorShutdownSignals() <-chan struct{} {
 	return shutdown
 }
 
-func configureHamDeck(deck *hamdeck.HamDeck, config string) error {
-	if config == "" {
-		configDirectory, err := cfg.Directory("")
+func openDevice() (hamdeck.Device, error) {
+	var device hamdeck.Device
+	if rootFlags.replayFile != "" {
+		recording, err := hamdeck.LoadRecording(rootFlags.replayFile)
+		if err != nil {
+			return nil, err
+		}
+		device = hamdeck.NewReplayDevice(recording)
+	} else {
+		streamDeck, err := streamdeck.Open(rootFlags.serial)
+		if err != nil {
+			return nil, err
+		}
+		device = streamDeck
+	}
+
+	if rootFlags.recordFile != "" {
+		recorder, err := hamdeck.RecordKeys(device, rootFlags.recordFile)
 		if err != nil {
-			return fmt.Errorf("cannot resolve configuration directory: %w", err)
+			device.Close()
+			return nil, err
 		}
-		config = filepath.Join(configDirectory, hamdeck.ConfigDefaultFilename)
-		log.Printf("Using default configuration file %s", config)
+		device = recorder
+	}
+
+	// the recording contains the keys as they are pressed on the device, the configuration maps them when the
+	// recording is replayed
+	return hamdeck.NewTransformedDevice(device), nil
+}
+
+func openStore() (*hamdeck.Store, error) {
+	configDirectory, err := cfg.Directory("")
+	if err != nil {
+		return hamdeck.NewStore(""), fmt.Errorf("cannot resolve configuration directory: %w", err)
 	}
+	filename := filepath.Join(configDirectory, hamdeck.DefaultStoreFilename)
+	logger.Info("Using state file", "filename", filename)
+	return hamdeck.LoadStore(filename)
+}
 
-	file, err := os.Open(config)
+// resolveConfigFile returns the given configuration file, or the default configuration file if none is given.
+func resolveConfigFile(config string) (string, error) {
+	if config != "" {
+		return config, nil
+	}
+	configDirectory, err := cfg.Directory("")
 	if err != nil {
-		return fmt.Errorf("cannot open configuration file: %w", err)
+		return "", fmt.Errorf("cannot resolve configuration directory: %w", err)
 	}
-	defer file.Close()
+	config = findDefaultConfigFile(configDirectory)
+	logger.Info("Using default configuration file", "filename", config)
+	return config, nil
+}
 
-	err = deck.ReadConfig(file)
+// configureHamDeck reads the configuration and loads the given or the last used profile.
+func configureHamDeck(deck *hamdeck.HamDeck, config string, profile string) error {
+	err := deck.ReadConfigFile(config)
 	if err != nil {
 		return err
 	}
 
-	deck.CloseUnusedFactories()
+	if len(deck.ProfileNames()) == 0 {
+		return nil
+	}
+
+	if profile != "" {
+		return deck.SwitchProfile(profile)
+	}
+	if storedProfile, ok := deck.StoredProfile(); ok {
+		err = deck.SwitchProfile(storedProfile)
+		if err != nil {
+			logger.Warn("Cannot restore the last used profile", "profile", storedProfile, "error", err)
+		}
+	}
 
 	return nil
 }
+
+// reloadHamDeck reads the configuration file again and loads the active profile.
+func reloadHamDeck(deck *hamdeck.HamDeck, config string) error {
+	err := deck.ReloadConfigFile(config)
+	if err != nil {
+		return err
+	}
+	logger.Info("Reloaded the configuration", "filename", config)
+	return nil
+}
+
+func findDefaultConfigFile(configDirectory string) string {
+	for _, filename := range hamdeck.ConfigDefaultFilenames {
+		result := filepath.Join(configDirectory, filename)
+		if _, err := os.Stat(result); err == nil {
+			return result
+		}
+	}
+	return filepath.Join(configDirectory, hamdeck.ConfigDefaultFilename)
+}
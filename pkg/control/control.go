@@ -0,0 +1,356 @@
+// Package control provides a Unix domain socket to control a running HamDeck, and the client that talks to it.
+//
+// The protocol is line based: the client sends one request per line, either as plain words like "page contest"
+// or as JSON like {"command": "page", "args": ["contest"]}. The server answers each request with one line of JSON.
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
+)
+
+var logger = logging.For("control")
+
+const (
+	// DefaultGroup is the group that may use the socket, the same group that may use the Stream Deck device.
+	DefaultGroup = "plugdev"
+	// SystemSocketFilename is the socket of the HamDeck system service.
+	SystemSocketFilename = "/run/hamdeck/hamdeck.sock"
+
+	socketFilename = "hamdeck.sock"
+	socketMode     = 0660
+	dialTimeout    = 2 * time.Second
+)
+
+// The commands that are understood by the server.
+const (
+	PageCommand    = "page"
+	PressCommand   = "press"
+	ProfileCommand = "profile"
+	StatusCommand  = "status"
+	ReloadCommand  = "reload"
+)
+
+// DefaultSocketFilename returns the socket in the runtime directory of the user, or the socket of the system
+// service if there is no runtime directory.
+func DefaultSocketFilename() string {
+	runtimeDirectory := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDirectory == "" {
+		return SystemSocketFilename
+	}
+	return filepath.Join(runtimeDirectory, socketFilename)
+}
+
+// FindSocket returns the first socket that exists: in the runtime directory of the user or of the system service.
+func FindSocket() string {
+	candidates := []string{DefaultSocketFilename(), SystemSocketFilename}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// Request is one command sent to the server.
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// ParseRequest parses one line of the protocol, either plain words or a JSON object.
+func ParseRequest(line string) (Request, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var result Request
+		err := json.Unmarshal([]byte(line), &result)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid request: %w", err)
+		}
+		return result, nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Request{}, fmt.Errorf("empty request")
+	}
+	return Request{Command: fields[0], Args: fields[1:]}, nil
+}
+
+// Response is the answer of the server to one request.
+type Response struct {
+	OK     bool            `json:"ok"`
+	Error  string          `json:"error,omitempty"`
+	Status *hamdeck.Status `json:"status,omitempty"`
+}
+
+func errorResponse(err error) Response {
+	return Response{Error: err.Error()}
+}
+
+/*
+	Server
+*/
+
+// Deck is the part of the HamDeck that is controlled through the socket.
+type Deck interface {
+	Do(func() error) error
+	AttachPage(id string) error
+	PressKey(index int) error
+	SwitchProfile(name string) error
+	Status() hamdeck.Status
+}
+
+// Server accepts the requests on the socket and executes them on the deck.
+type Server struct {
+	listener net.Listener
+	deck     Deck
+	reload   func() error
+
+	lock        *sync.Mutex
+	connections map[net.Conn]bool
+	closed      bool
+	wait        *sync.WaitGroup
+}
+
+// Listen provides the control socket with the given filename. The socket may be used by the owner and by the members
+// of the given group. The reload function reads the configuration again, if it is nil, the reload command is
+// not available.
+func Listen(filename string, group string, deck Deck, reload func() error) (*Server, error) {
+	err := removeStaleSocket(filename)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the control socket: %w", err)
+	}
+	err = os.Chmod(filename, socketMode)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("cannot set the permissions of the control socket: %w", err)
+	}
+	if group != "" {
+		err = chownGroup(filename, group)
+		if err != nil {
+			logger.Warn("cannot give the group access to the control socket", "group", group, "error", err)
+		}
+	}
+
+	result := &Server{
+		listener:    listener,
+		deck:        deck,
+		reload:      reload,
+		lock:        new(sync.Mutex),
+		connections: make(map[net.Conn]bool),
+		wait:        new(sync.WaitGroup),
+	}
+	result.wait.Add(1)
+	go result.serve()
+	logger.Info("serving control socket", "filename", filename)
+	return result, nil
+}
+
+// removeStaleSocket removes the socket of a HamDeck that was not shut down properly.
+func removeStaleSocket(filename string) error {
+	info, err := os.Stat(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open the control socket: %w", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("cannot open the control socket: %s is not a socket", filename)
+	}
+	conn, err := net.DialTimeout("unix", filename, dialTimeout)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("cannot open the control socket: another HamDeck is listening on %s", filename)
+	}
+	return os.Remove(filename)
+}
+
+func chownGroup(filename string, name string) error {
+	group, err := user.LookupGroup(name)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(group.Gid)
+	if err != nil {
+		return err
+	}
+	return os.Chown(filename, -1, gid)
+}
+
+// Close closes the socket and all open connections.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	s.closed = true
+	err := s.listener.Close()
+	for conn := range s.connections {
+		conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wait.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wait.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.lock.Lock()
+			closed := s.closed
+			s.lock.Unlock()
+			if !closed {
+				logger.Error("cannot accept control connection", "error", err)
+			}
+			return
+		}
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			conn.Close()
+			return
+		}
+		s.connections[conn] = true
+		s.wait.Add(1)
+		s.lock.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.wait.Done()
+	defer func() {
+		s.lock.Lock()
+		delete(s.connections, conn)
+		s.lock.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	encoder := json.NewEncoder(conn)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var response Response
+		request, err := ParseRequest(scanner.Text())
+		if err != nil {
+			response = errorResponse(err)
+		} else {
+			logger.Debug("control request", "command", request.Command, "args", request.Args)
+			response = s.Execute(request)
+		}
+		err = encoder.Encode(response)
+		if err != nil {
+			logger.Error("cannot send control response", "error", err)
+			return
+		}
+	}
+}
+
+// Execute executes the given request on the deck.
+func (s *Server) Execute(request Request) Response {
+	var err error
+	switch request.Command {
+	case PageCommand:
+		err = s.executeWithArg(request, func(id string) error {
+			return s.deck.AttachPage(id)
+		})
+	case PressCommand:
+		err = s.executeWithArg(request, func(arg string) error {
+			index, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid key index %s", arg)
+			}
+			return s.deck.PressKey(index)
+		})
+	case ProfileCommand:
+		err = s.executeWithArg(request, func(name string) error {
+			return s.deck.SwitchProfile(name)
+		})
+	case StatusCommand:
+		status := s.deck.Status()
+		return Response{OK: true, Status: &status}
+	case ReloadCommand:
+		if s.reload == nil {
+			err = fmt.Errorf("the configuration cannot be reloaded")
+		} else {
+			err = s.deck.Do(s.reload)
+		}
+	default:
+		err = fmt.Errorf("unknown command %q, use page, press, profile, status, or reload", request.Command)
+	}
+	if err != nil {
+		return errorResponse(err)
+	}
+	return Response{OK: true}
+}
+
+// executeWithArg executes the given function with the single argument of the request in the goroutine that runs
+// the deck.
+func (s *Server) executeWithArg(request Request, f func(string) error) error {
+	if len(request.Args) != 1 {
+		return fmt.Errorf("%s needs exactly one argument", request.Command)
+	}
+	return s.deck.Do(func() error {
+		return f(request.Args[0])
+	})
+}
+
+/*
+	Client
+*/
+
+// Send sends the given request to the server on the given socket and returns the response. If the request failed,
+// the error of the response is returned.
+func Send(filename string, request Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", filename, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to HamDeck: %w", err)
+	}
+	defer conn.Close()
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, '\n')
+	_, err = conn.Write(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot send the request: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the response: %w", err)
+	}
+	var result Response
+	err = json.Unmarshal(line, &result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if !result.OK {
+		return &result, errors.New(result.Error)
+	}
+	return &result, nil
+}
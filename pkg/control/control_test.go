@@ -0,0 +1,175 @@
+package control
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+func TestParseRequest(t *testing.T) {
+	tt := []struct {
+		line     string
+		expected Request
+		invalid  bool
+	}{
+		{line: "status", expected: Request{Command: "status", Args: []string{}}},
+		{line: "  page  contest \n", expected: Request{Command: "page", Args: []string{"contest"}}},
+		{line: `{"command": "press", "args": ["3"]}`, expected: Request{Command: "press", Args: []string{"3"}}},
+		{line: "", invalid: true},
+		{line: `{"command": `, invalid: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.line, func(t *testing.T) {
+			actual, err := ParseRequest(tc.line)
+			if tc.invalid {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+type testDeck struct {
+	calls   int
+	page    string
+	pressed []int
+	profile string
+}
+
+func (d *testDeck) Do(f func() error) error {
+	d.calls++
+	return f()
+}
+
+func (d *testDeck) AttachPage(id string) error {
+	if id == "unknown" {
+		return fmt.Errorf("no page defined with name %s", id)
+	}
+	d.page = id
+	return nil
+}
+
+func (d *testDeck) PressKey(index int) error {
+	d.pressed = append(d.pressed, index)
+	return nil
+}
+
+func (d *testDeck) SwitchProfile(name string) error {
+	d.profile = name
+	return nil
+}
+
+func (d *testDeck) Status() hamdeck.Status {
+	return hamdeck.Status{Page: d.page, Profile: d.profile}
+}
+
+func TestServer_Execute(t *testing.T) {
+	deck := &testDeck{}
+	reloaded := false
+	server := &Server{deck: deck, reload: func() error {
+		reloaded = true
+		return nil
+	}}
+
+	assert.True(t, server.Execute(Request{Command: PageCommand, Args: []string{"contest"}}).OK)
+	assert.Equal(t, "contest", deck.page)
+	assert.True(t, server.Execute(Request{Command: PressCommand, Args: []string{"3"}}).OK)
+	assert.Equal(t, []int{3}, deck.pressed)
+	assert.True(t, server.Execute(Request{Command: ProfileCommand, Args: []string{"ragchew"}}).OK)
+	assert.Equal(t, "ragchew", deck.profile)
+	assert.True(t, server.Execute(Request{Command: ReloadCommand}).OK)
+	assert.True(t, reloaded)
+	assert.Equal(t, 4, deck.calls, "all changes must run in the goroutine of the deck")
+
+	response := server.Execute(Request{Command: StatusCommand})
+	assert.True(t, response.OK)
+	require.NotNil(t, response.Status)
+	assert.Equal(t, "contest", response.Status.Page)
+	assert.Equal(t, "ragchew", response.Status.Profile)
+}
+
+func TestServer_ExecuteInvalidRequests(t *testing.T) {
+	server := &Server{deck: &testDeck{}}
+
+	tt := []struct {
+		name    string
+		request Request
+	}{
+		{"unknown command", Request{Command: "shutdown"}},
+		{"missing argument", Request{Command: PageCommand}},
+		{"too many arguments", Request{Command: PageCommand, Args: []string{"a", "b"}}},
+		{"invalid index", Request{Command: PressCommand, Args: []string{"one"}}},
+		{"deck error", Request{Command: PageCommand, Args: []string{"unknown"}}},
+		{"no reload", Request{Command: ReloadCommand}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			response := server.Execute(tc.request)
+			assert.False(t, response.OK)
+			assert.NotEmpty(t, response.Error)
+		})
+	}
+}
+
+func TestListenAndSend(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hamdeck.sock")
+	deck := &testDeck{}
+	server, err := Listen(filename, "", deck, nil)
+	require.NoError(t, err)
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(socketMode), info.Mode().Perm())
+
+	_, err = Listen(filename, "", deck, nil)
+	assert.Error(t, err, "only one HamDeck may listen on the socket")
+
+	response, err := Send(filename, Request{Command: PageCommand, Args: []string{"contest"}})
+	require.NoError(t, err)
+	assert.True(t, response.OK)
+	assert.Equal(t, "contest", deck.page)
+
+	response, err = Send(filename, Request{Command: StatusCommand})
+	require.NoError(t, err)
+	require.NotNil(t, response.Status)
+	assert.Equal(t, "contest", response.Status.Page)
+
+	_, err = Send(filename, Request{Command: PageCommand, Args: []string{"unknown"}})
+	assert.EqualError(t, err, "no page defined with name unknown")
+
+	require.NoError(t, server.Close())
+	_, err = Send(filename, Request{Command: StatusCommand})
+	assert.Error(t, err)
+}
+
+func TestListen_RemovesStaleSocket(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hamdeck.sock")
+	server, err := Listen(filename, "", &testDeck{}, nil)
+	require.NoError(t, err)
+	server.listener.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	require.NoError(t, server.Close())
+	_, err = os.Stat(filename)
+	require.NoError(t, err, "the socket file is left behind")
+
+	server, err = Listen(filename, "", &testDeck{}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, server.Close())
+}
+
+func TestListen_KeepsOtherFiles(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hamdeck.sock")
+	require.NoError(t, os.WriteFile(filename, []byte("not a socket"), 0644))
+
+	_, err := Listen(filename, "", &testDeck{}, nil)
+	assert.Error(t, err)
+	_, err = os.Stat(filename)
+	assert.NoError(t, err)
+}
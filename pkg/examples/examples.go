@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"log"
 
 	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
 )
 
+var logger = logging.For("examples")
+
 /*
 	The HelloTextButton shows the text "Hello" while released and "World" while pressed.
 */
@@ -40,13 +42,13 @@ func (b *HelloTextButton) redrawImages(gc hamdeck.GraphicContext) {
 }
 
 func (b *HelloTextButton) Pressed() {
-	log.Print("Hello World pressed")
+	logger.Info("Hello World pressed")
 	b.image = b.worldImage
 	b.Invalidate(false)
 }
 
 func (b *HelloTextButton) Released() {
-	log.Print("Hello World released")
+	logger.Info("Hello World released")
 	b.image = b.helloImage
 	b.Invalidate(false)
 }
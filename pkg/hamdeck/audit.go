@@ -0,0 +1,283 @@
+package hamdeck
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAuditMaxSize = 10 * 1024 * 1024
+	DefaultAuditBackups = 3
+)
+
+// The events recorded in the audit log.
+const (
+	AuditKeyPressed  = "key_pressed"
+	AuditKeyReleased = "key_released"
+	AuditPageChanged = "page_changed"
+	AuditCommand     = "command"
+)
+
+const auditResultOK = "ok"
+
+// AuditEntry is one line in the audit log.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	Page       string    `json:"page"`
+	Index      *int      `json:"index,omitempty"`
+	Button     string    `json:"button,omitempty"`
+	Connection string    `json:"connection,omitempty"`
+	Schedule   string    `json:"schedule,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Result     string    `json:"result,omitempty"`
+}
+
+// AuditLog writes the audit entries as JSON lines into a file. When the file exceeds the maximum size,
+// it is rotated: hamdeck.audit.jsonl becomes hamdeck.audit.jsonl.1, and so on. A nil AuditLog discards all entries.
+type AuditLog struct {
+	lock     *sync.Mutex
+	filename string
+	maxSize  int64
+	backups  int
+	file     *os.File
+	size     int64
+	now      func() time.Time
+}
+
+// OpenAuditLog opens the given file to append audit entries. A maxSize <= 0 disables the rotation.
+func OpenAuditLog(filename string, maxSize int64, backups int) (*AuditLog, error) {
+	result := &AuditLog{
+		lock:     new(sync.Mutex),
+		filename: filename,
+		maxSize:  maxSize,
+		backups:  backups,
+		now:      time.Now,
+	}
+	err := result.open()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (l *AuditLog) open() error {
+	file, err := os.OpenFile(l.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open the audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("cannot open the audit log: %w", err)
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+func (l *AuditLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Record writes the given entry into the audit log. The time is set if it is missing.
+func (l *AuditLog) Record(entry AuditEntry) {
+	if l == nil {
+		return
+	}
+	if entry.Time.IsZero() {
+		entry.Time = l.now()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Error("cannot marshal audit entry", "error", err)
+		return
+	}
+	data = append(data, '\n')
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.file == nil {
+		return
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(data)) > l.maxSize {
+		err := l.rotate()
+		if err != nil {
+			logger.Error("cannot rotate the audit log", "error", err)
+			if l.file == nil {
+				return
+			}
+		}
+	}
+	n, err := l.file.Write(data)
+	l.size += int64(n)
+	if err != nil {
+		logger.Error("cannot write the audit log", "error", err)
+	}
+}
+
+func (l *AuditLog) rotate() error {
+	err := l.file.Close()
+	l.file = nil
+	if err != nil {
+		return err
+	}
+
+	if l.backups > 0 {
+		os.Remove(l.backupFilename(l.backups))
+		for i := l.backups - 1; i > 0; i-- {
+			os.Rename(l.backupFilename(i), l.backupFilename(i+1))
+		}
+		err = os.Rename(l.filename, l.backupFilename(1))
+	} else {
+		err = os.Remove(l.filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	return l.open()
+}
+
+func (l *AuditLog) backupFilename(i int) string {
+	return fmt.Sprintf("%s.%d", l.filename, i)
+}
+
+// SetAuditLog sets the audit log that records key events, page changes, and the commands sent by the buttons.
+func (d *HamDeck) SetAuditLog(audit *AuditLog) {
+	d.audit = audit
+}
+
+func (d *HamDeck) auditKey(key Key) {
+	if d.audit == nil {
+		return
+	}
+	event := AuditKeyReleased
+	if key.Pressed {
+		event = AuditKeyPressed
+	}
+	d.audit.Record(d.auditEntry(event, key.Index))
+}
+
+func (d *HamDeck) auditPage(id string) {
+	d.audit.Record(AuditEntry{
+		Event: AuditPageChanged,
+		Page:  id,
+	})
+}
+
+func (d *HamDeck) auditEntry(event string, index int) AuditEntry {
+	pageID, info := d.buttonInfo(index)
+	return AuditEntry{
+		Event:      event,
+		Page:       pageID,
+		Index:      &index,
+		Button:     info.buttonType,
+		Connection: info.connection,
+	}
+}
+
+// commandAudit returns a function that records a command of the button with the given index. The page and the key
+// are taken now, so the entry is correct even if the command finishes after the page changed.
+func (d *HamDeck) commandAudit(index int) CommandAudit {
+	entry := d.auditEntry(AuditCommand, index)
+	return func(command string, err error) {
+		if d.audit == nil {
+			return
+		}
+		entry := entry
+		entry.Command = command
+		entry.Result = auditResult(err)
+		d.audit.Record(entry)
+	}
+}
+
+// auditConnectionCommand records a command that the deck sent to the given connection without a key.
+func (d *HamDeck) auditConnectionCommand(buttonType string, connection string, command string, err error) {
+	if d.audit == nil {
+		return
+	}
+	d.audit.Record(AuditEntry{
+		Event:      AuditCommand,
+		Button:     buttonType,
+		Connection: connection,
+		Command:    command,
+		Result:     auditResult(err),
+	})
+}
+
+func auditResult(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return auditResultOK
+}
+
+// CommandAudit records a command that a button sent to its connection, together with its result.
+type CommandAudit func(command string, err error)
+
+// AuditCommand records a command that the button sent to its connection, together with its result.
+func (b *BaseButton) AuditCommand(command string, err error) {
+	b.StartCommand()(command, err)
+}
+
+// StartCommand captures the key and page of the button when a command starts. Use the returned CommandAudit
+// to record commands that finish asynchronously, they are recorded even if the button was detached in the meantime.
+func (b *BaseButton) StartCommand() CommandAudit {
+	if b.ctx == nil {
+		return func(command string, _ error) {
+			logger.Debug("command of a detached button is not audited", "command", command)
+		}
+	}
+	auditor, ok := b.ctx.(commandAuditor)
+	if !ok {
+		return func(string, error) {}
+	}
+	return auditor.startCommand()
+}
+
+type commandAuditor interface {
+	startCommand() CommandAudit
+}
+
+func (c *buttonContext) startCommand() CommandAudit {
+	return c.deck.commandAudit(c.index)
+}
+
+// scheduleContext is the context of a schedule's action. The action is not on a key, it has nothing to redraw.
+type scheduleContext struct {
+	deck     *HamDeck
+	schedule string
+	info     buttonInfo
+}
+
+func (c *scheduleContext) Invalidate(bool) {}
+
+func (c *scheduleContext) startCommand() CommandAudit {
+	return func(command string, err error) {
+		if c.deck.audit == nil {
+			return
+		}
+		c.deck.audit.Record(AuditEntry{
+			Event:      AuditCommand,
+			Button:     c.info.buttonType,
+			Connection: c.info.connection,
+			Schedule:   c.schedule,
+			Command:    command,
+			Result:     auditResult(err),
+		})
+	}
+}
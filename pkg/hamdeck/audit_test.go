@@ -0,0 +1,177 @@
+package hamdeck
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const auditTestConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": {
+			"buttons": [
+				{ "type": "test.Button", "index": 3, "connection": "rig" }
+			]
+		}
+	}
+}`
+
+func readAuditEntries(t *testing.T, filename string) []AuditEntry {
+	t.Helper()
+	file, err := os.Open(filename)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var result []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		result = append(result, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return result
+}
+
+func TestAuditLog_RecordsKeysPagesAndCommands(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(filename, DefaultAuditMaxSize, DefaultAuditBackups)
+	require.NoError(t, err)
+
+	deck, _ := setupTestDeck(t, auditTestConfig, func(deck *HamDeck) {
+		deck.SetAuditLog(audit)
+	})
+
+	deck.handleKey(Key{Index: 3, Pressed: true})
+	(&buttonContext{index: 3, deck: deck}).startCommand()("set_freq 7000000", errors.New("timeout"))
+	deck.handleKey(Key{Index: 3, Pressed: false})
+	require.NoError(t, audit.Close())
+
+	entries := readAuditEntries(t, filename)
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, AuditPageChanged, entries[0].Event)
+	assert.Equal(t, "main", entries[0].Page)
+	assert.Nil(t, entries[0].Index)
+
+	assert.Equal(t, AuditKeyPressed, entries[1].Event)
+	assert.Equal(t, "main", entries[1].Page)
+	require.NotNil(t, entries[1].Index)
+	assert.Equal(t, 3, *entries[1].Index)
+	assert.Equal(t, testButtonType, entries[1].Button)
+	assert.Equal(t, "rig", entries[1].Connection)
+	assert.False(t, entries[1].Time.IsZero())
+
+	assert.Equal(t, AuditCommand, entries[2].Event)
+	assert.Equal(t, "set_freq 7000000", entries[2].Command)
+	assert.Equal(t, "timeout", entries[2].Result)
+	assert.Equal(t, "rig", entries[2].Connection)
+
+	assert.Equal(t, AuditKeyReleased, entries[3].Event)
+}
+
+func TestAuditLog_RecordsCommandsWithoutKeys(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(filename, DefaultAuditMaxSize, DefaultAuditBackups)
+	require.NoError(t, err)
+
+	deck, _ := setupTestDeck(t, `{
+		"buttons": [
+			{ "type": "hamdeck.Cycle", "index": 2, "states": [
+				{ "label": "A", "action": { "type": "test.Button", "connection": "rig" } }
+			] }
+		],
+		"schedules": [
+			{ "name": "Net", "cron": "55 18 * * 3", "action": { "type": "test.Button", "connection": "rig" } }
+		]
+	}`, func(deck *HamDeck) {
+		deck.SetAuditLog(audit)
+	})
+	cycleAction := deck.buttons[2].(*CycleButton).states[0].Action.(*testButton)
+	scheduleAction := deck.schedules.schedules[0].Action.(*testButton)
+
+	cycleAction.ctx.(commandAuditor).startCommand()("set_mode CW", nil)
+	scheduleAction.ctx.(commandAuditor).startCommand()("set_freq 3630000", nil)
+	deck.auditConnectionCommand(InterlockButtonType, "rig", "stop_tx", nil)
+	require.NoError(t, audit.Close())
+
+	entries := readAuditEntries(t, filename)
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, "set_mode CW", entries[1].Command)
+	require.NotNil(t, entries[1].Index)
+	assert.Equal(t, 2, *entries[1].Index)
+	assert.Equal(t, CycleButtonType, entries[1].Button)
+
+	assert.Equal(t, "set_freq 3630000", entries[2].Command)
+	assert.Equal(t, "Net", entries[2].Schedule)
+	assert.Equal(t, testButtonType, entries[2].Button)
+	assert.Equal(t, "rig", entries[2].Connection)
+
+	assert.Equal(t, "stop_tx", entries[3].Command)
+	assert.Equal(t, InterlockButtonType, entries[3].Button)
+	assert.Equal(t, "rig", entries[3].Connection)
+	assert.Equal(t, auditResultOK, entries[3].Result)
+}
+
+func TestAuditLog_RecordsCommandsThatFinishAfterAPageChange(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(filename, DefaultAuditMaxSize, DefaultAuditBackups)
+	require.NoError(t, err)
+
+	deck, _ := setupTestDeck(t, `{
+		"start_page": "main",
+		"pages": {
+			"main": { "buttons": [ { "type": "test.Button", "index": 3, "connection": "rig" } ] },
+			"other": { "buttons": [ { "type": "test.Button", "index": 3, "connection": "rotator" } ] }
+		}
+	}`, func(deck *HamDeck) {
+		deck.SetAuditLog(audit)
+	})
+	button := deck.buttons[3].(*testButton)
+
+	finish := button.ctx.(commandAuditor).startCommand()
+	require.NoError(t, deck.AttachPage("other"))
+	finish("sleep 10", nil)
+	require.NoError(t, audit.Close())
+
+	entries := readAuditEntries(t, filename)
+	require.Len(t, entries, 3)
+	assert.Equal(t, AuditCommand, entries[2].Event)
+	assert.Equal(t, "main", entries[2].Page)
+	require.NotNil(t, entries[2].Index)
+	assert.Equal(t, 3, *entries[2].Index)
+	assert.Equal(t, "rig", entries[2].Connection)
+}
+
+func TestAuditLog_RotatesBySize(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(filename, 200, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		audit.Record(AuditEntry{Event: AuditPageChanged, Page: "main"})
+	}
+	require.NoError(t, audit.Close())
+
+	for _, name := range []string{filename, filename + ".1", filename + ".2"} {
+		info, err := os.Stat(name)
+		require.NoError(t, err, name)
+		assert.LessOrEqual(t, info.Size(), int64(200), name)
+	}
+	_, err = os.Stat(filename + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAuditLog_Nil(t *testing.T) {
+	var audit *AuditLog
+	audit.Record(AuditEntry{Event: AuditPageChanged})
+	assert.NoError(t, audit.Close())
+}
@@ -0,0 +1,453 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ConfigUTC          = "utc"
+	ConfigFormat       = "format"
+	ConfigDate         = "date"
+	ConfigDateFormat   = "date_format"
+	ConfigMode         = "mode"
+	ConfigDuration     = "duration"
+	ConfigRestartOnPTT = "restart_on_ptt"
+)
+
+const (
+	DefaultClockFormat = "15:04"
+	DefaultDateFormat  = "2006-01-02"
+	DefaultTimerPeriod = 10 * time.Minute
+)
+
+// ticker calls the given function every second while the button is attached.
+type ticker struct {
+	lock *sync.Mutex
+	stop chan struct{}
+}
+
+func newTicker() *ticker {
+	return &ticker{
+		lock: new(sync.Mutex),
+	}
+}
+
+func (t *ticker) Start(tick func()) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stop != nil {
+		return
+	}
+	t.stop = make(chan struct{})
+	go func(stop <-chan struct{}) {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				tick()
+			case <-stop:
+				return
+			}
+		}
+	}(t.stop)
+}
+
+func (t *ticker) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stop == nil {
+		return
+	}
+	close(t.stop)
+	t.stop = nil
+}
+
+/*
+	ClockButton
+*/
+
+func NewClockButton(utc bool, format string, dateFormat string) *ClockButton {
+	if format == "" {
+		format = DefaultClockFormat
+	}
+	return &ClockButton{
+		lock:       new(sync.Mutex),
+		ticker:     newTicker(),
+		now:        time.Now,
+		utc:        utc,
+		format:     format,
+		dateFormat: dateFormat,
+	}
+}
+
+type ClockButton struct {
+	BaseButton
+	lock       *sync.Mutex
+	ticker     *ticker
+	now        func() time.Time
+	image      image.Image
+	utc        bool
+	format     string
+	dateFormat string
+	text       string
+	date       string
+}
+
+func (b *ClockButton) currentTime() time.Time {
+	if b.utc {
+		return b.now().UTC()
+	}
+	return b.now().Local()
+}
+
+func (b *ClockButton) tick() {
+	now := b.currentTime()
+	text := now.Format(b.format)
+	var date string
+	if b.dateFormat != "" {
+		date = now.Format(b.dateFormat)
+	}
+
+	b.lock.Lock()
+	changed := (text != b.text || date != b.date)
+	b.text = text
+	b.date = date
+	b.lock.Unlock()
+
+	if changed {
+		b.Invalidate(true)
+	}
+}
+
+func (b *ClockButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.image == nil || redrawImages {
+		gc.SetForeground(White)
+		gc.SetBackground(Black)
+		if b.date == "" {
+			b.image = gc.DrawSingleLineTextButton(b.text)
+		} else {
+			b.image = gc.DrawDoubleLineToggleTextButton(b.text, b.date, 1)
+		}
+	}
+	return b.image
+}
+
+func (b *ClockButton) Pressed() {
+	// nop
+}
+
+func (b *ClockButton) Released() {
+	// nop
+}
+
+func (b *ClockButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.tick()
+	b.ticker.Start(b.tick)
+}
+
+func (b *ClockButton) Detached() {
+	b.ticker.Stop()
+	b.BaseButton.Detached()
+}
+
+/*
+	TimerButton
+*/
+
+type TimerMode string
+
+const (
+	CountdownTimer TimerMode = "countdown"
+	Stopwatch      TimerMode = "stopwatch"
+)
+
+func NewTimerButton(label string, mode TimerMode, period time.Duration) *TimerButton {
+	if mode == "" {
+		mode = CountdownTimer
+	}
+	if period <= 0 {
+		period = DefaultTimerPeriod
+	}
+	result := &TimerButton{
+		lock:   new(sync.Mutex),
+		ticker: newTicker(),
+		now:    time.Now,
+		label:  label,
+		mode:   mode,
+		period: period,
+	}
+	result.longpress = NewLongpressHandler(result.OnLongpress)
+	return result
+}
+
+type TimerButton struct {
+	BaseButton
+	lock        *sync.Mutex
+	ticker      *ticker
+	now         func() time.Time
+	longpress   *LongpressHandler
+	longpressed bool
+	image       image.Image
+	text        string
+	flashOn     bool
+	label       string
+	mode        TimerMode
+	period      time.Duration
+
+	running   bool
+	startedAt time.Time
+	elapsed   time.Duration
+
+	pttConnection string
+
+	store ButtonStore
+}
+
+type storedTimer struct {
+	Running   bool          `json:"running"`
+	StartedAt time.Time     `json:"started_at"`
+	Elapsed   time.Duration `json:"elapsed"`
+}
+
+// Restore continues with the timer state that was stored the last time. A running timer keeps on running
+// while hamdeck is not running.
+func (b *TimerButton) Restore(store ButtonStore) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.store = store
+
+	var stored storedTimer
+	if !store.Get(&stored) {
+		return
+	}
+	b.running = stored.Running
+	b.startedAt = stored.StartedAt
+	b.elapsed = stored.Elapsed
+}
+
+func (b *TimerButton) save() {
+	b.store.Set(storedTimer{
+		Running:   b.running,
+		StartedAt: b.startedAt,
+		Elapsed:   b.elapsed,
+	})
+}
+
+// RestartOnPTT lets the timer restart whenever the PTT of the given connection becomes active.
+func (b *TimerButton) RestartOnPTT(connection string) {
+	b.pttConnection = connection
+}
+
+func (b *TimerButton) StateChanged(connection string, name string, value string) {
+	if b.pttConnection == "" || connection != b.pttConnection || name != StatePTT {
+		return
+	}
+	if value != FormatBoolState(true) {
+		return
+	}
+	b.Restart()
+}
+
+// Elapsed returns the time that elapsed while the timer was running.
+func (b *TimerButton) Elapsed() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.currentElapsed()
+}
+
+func (b *TimerButton) currentElapsed() time.Duration {
+	if !b.running {
+		return b.elapsed
+	}
+	return b.elapsed + b.now().Sub(b.startedAt)
+}
+
+func (b *TimerButton) Running() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.running
+}
+
+// Expired reports if the countdown has run out.
+func (b *TimerButton) Expired() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.expired()
+}
+
+func (b *TimerButton) expired() bool {
+	return b.mode == CountdownTimer && b.currentElapsed() >= b.period
+}
+
+func (b *TimerButton) Start() {
+	b.lock.Lock()
+	if !b.running {
+		b.running = true
+		b.startedAt = b.now()
+	}
+	b.save()
+	b.lock.Unlock()
+	b.Invalidate(true)
+}
+
+func (b *TimerButton) Stop() {
+	b.lock.Lock()
+	if b.running {
+		b.elapsed = b.currentElapsed()
+		b.running = false
+	}
+	b.save()
+	b.lock.Unlock()
+	b.Invalidate(true)
+}
+
+func (b *TimerButton) Reset() {
+	b.lock.Lock()
+	b.elapsed = 0
+	b.startedAt = b.now()
+	b.flashOn = false
+	b.save()
+	b.lock.Unlock()
+	b.Invalidate(true)
+}
+
+func (b *TimerButton) Restart() {
+	b.lock.Lock()
+	b.running = true
+	b.elapsed = 0
+	b.startedAt = b.now()
+	b.flashOn = false
+	b.save()
+	b.lock.Unlock()
+	b.Invalidate(true)
+}
+
+func (b *TimerButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var timeText string
+	if b.mode == CountdownTimer {
+		remaining := b.period - b.currentElapsed()
+		if remaining < 0 {
+			remaining = 0
+		}
+		timeText = formatTimerDuration(remaining.Round(time.Second))
+	} else {
+		timeText = formatTimerDuration(b.currentElapsed().Truncate(time.Second))
+	}
+	text := fmt.Sprintf("%s|%t|%t", timeText, b.running, b.flashOn)
+	if b.image != nil && !redrawImages && text == b.text {
+		return b.image
+	}
+	b.text = text
+
+	switch {
+	case b.expired() && b.flashOn:
+		gc.SetForeground(Black)
+		gc.SetBackground(Red)
+	case b.expired():
+		gc.SetForeground(Red)
+		gc.SetBackground(Black)
+	case b.running:
+		gc.SetForeground(White)
+		gc.SetBackground(Black)
+	default:
+		gc.SetForeground(DisabledGray)
+		gc.SetBackground(Black)
+	}
+	if b.label == "" {
+		b.image = gc.DrawSingleLineTextButton(timeText)
+	} else {
+		b.image = gc.DrawDoubleLineToggleTextButton(b.label, timeText, 2)
+	}
+	return b.image
+}
+
+func formatTimerDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+func (b *TimerButton) Flash(on bool) {
+	b.lock.Lock()
+	expired := b.expired()
+	if expired {
+		b.flashOn = on
+	}
+	b.lock.Unlock()
+	if expired {
+		b.Invalidate(false)
+	}
+}
+
+// Pressed starts or stops the timer, an expired countdown is restarted. A long press resets the timer.
+func (b *TimerButton) Pressed() {
+	b.lock.Lock()
+	b.longpressed = false
+	b.lock.Unlock()
+	b.longpress.Pressed()
+}
+
+func (b *TimerButton) Released() {
+	b.longpress.Released()
+
+	b.lock.Lock()
+	longpressed := b.longpressed
+	running := b.running
+	expired := b.expired()
+	b.lock.Unlock()
+
+	switch {
+	case longpressed:
+		return
+	case expired:
+		b.Restart()
+	case running:
+		b.Stop()
+	default:
+		b.Start()
+	}
+}
+
+func (b *TimerButton) OnLongpress() {
+	b.lock.Lock()
+	b.longpressed = true
+	b.lock.Unlock()
+	b.Reset()
+}
+
+func (b *TimerButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.ticker.Start(func() {
+		b.Invalidate(false)
+	})
+}
+
+func (b *TimerButton) Detached() {
+	b.ticker.Stop()
+	b.BaseButton.Detached()
+}
+
+func ParseTimerMode(s string) (TimerMode, error) {
+	mode := TimerMode(strings.ToLower(strings.TrimSpace(s)))
+	switch mode {
+	case CountdownTimer, Stopwatch:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown timer mode %s", s)
+	}
+}
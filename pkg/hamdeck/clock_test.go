@@ -0,0 +1,77 @@
+package hamdeck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestClockButton(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, time.March, 9, 17, 42, 13, 0, time.FixedZone("CET", 3600))}
+	button := NewClockButton(true, "", DefaultDateFormat)
+	button.now = clock.Now
+
+	button.tick()
+	assert.Equal(t, "16:42", button.text)
+	assert.Equal(t, "2024-03-09", button.date)
+}
+
+func TestTimerButton_Countdown(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	button := NewTimerButton("ID", CountdownTimer, 10*time.Second)
+	button.now = clock.Now
+
+	button.Start()
+	clock.Add(4 * time.Second)
+	assert.Equal(t, 4*time.Second, button.Elapsed())
+
+	button.Stop()
+	clock.Add(4 * time.Second)
+	assert.Equal(t, 4*time.Second, button.Elapsed())
+	assert.False(t, button.Expired())
+
+	button.Start()
+	clock.Add(6 * time.Second)
+	assert.True(t, button.Expired())
+
+	button.Reset()
+	assert.False(t, button.Expired())
+	assert.True(t, button.Running())
+}
+
+func TestTimerButton_RestartOnPTT(t *testing.T) {
+	config := `{
+		"buttons": [
+			{ "type": "hamdeck.Timer", "index": 1, "mode": "stopwatch", "restart_on_ptt": "rig" }
+		]
+	}`
+	runWithConfigString(t, config, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		button, ok := deck.buttons[1].(*TimerButton)
+		require.True(t, ok)
+		assert.Equal(t, Stopwatch, button.mode)
+		assert.False(t, button.Running())
+
+		deck.PublishState("rig", StatePTT, "true")
+		assert.True(t, button.Running())
+	})
+}
+
+func TestFormatTimerDuration(t *testing.T) {
+	assert.Equal(t, "00:00", formatTimerDuration(0))
+	assert.Equal(t, "09:05", formatTimerDuration(9*time.Minute+5*time.Second))
+	assert.Equal(t, "1:02:03", formatTimerDuration(time.Hour+2*time.Minute+3*time.Second))
+}
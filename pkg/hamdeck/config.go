@@ -2,12 +2,14 @@ package hamdeck
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
+	"image/color"
 	"io"
-	"log"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/ftl/hamdeck/pkg/logging"
 )
 
 const (
@@ -20,30 +22,115 @@ const (
 	ConfigType            = "type"
 	ConfigIndex           = "index"
 	ConfigConnection      = "connection"
+	ConfigLogLevel        = "log_level"
 )
 
+// ReadConfig reads the configuration in JSON, YAML, or TOML format. The format is detected from the content.
 func (d *HamDeck) ReadConfig(r io.Reader) error {
+	return d.ReadConfigFormat(r, "")
+}
+
+// ReadConfigFormat reads the configuration in the given format. If the format is empty, it is detected from the content.
+func (d *HamDeck) ReadConfigFormat(r io.Reader, format ConfigFileFormat) error {
 	var buffer bytes.Buffer
 	_, err := buffer.ReadFrom(r)
 	if err != nil {
 		return fmt.Errorf("cannot read the configuration: %w", err)
 	}
 
-	var rawData any
-	err = json.Unmarshal(buffer.Bytes(), &rawData)
+	configuration, err := UnmarshalConfig(buffer.Bytes(), format)
+	if err != nil {
+		return err
+	}
+	configuration, err = resolveConfigIncludes(findEffectiveConfiguration(configuration), ".", nil)
+	if err != nil {
+		return err
+	}
+	configuration, err = substituteConfigVariables(configuration)
+	if err != nil {
+		return err
+	}
+
+	return d.applyConfig(configuration)
+}
+
+// ReadConfigFile reads the configuration from the given file. Included files are resolved relative to this file.
+func (d *HamDeck) ReadConfigFile(filename string) error {
+	configuration, err := LoadConfig(filename)
 	if err != nil {
-		return fmt.Errorf("cannot unmarshal the configuration: %w", err)
+		return err
 	}
+	return d.applyConfig(configuration)
+}
 
-	configuration, ok := rawData.(map[string]any)
-	if !ok {
-		return fmt.Errorf("configuration is of wrong type: %T", rawData)
+// ReloadConfigFile reads the configuration from the given file again. If a profile is active, only this profile
+// of the new configuration is loaded.
+func (d *HamDeck) ReloadConfigFile(filename string) error {
+	profile := d.ActiveProfile()
+	if profile == "" {
+		return d.ReadConfigFile(filename)
+	}
+
+	configuration, err := LoadConfig(filename)
+	if err != nil {
+		return err
+	}
+	profiles, err := loadConfigProfiles(findEffectiveConfiguration(configuration))
+	if err != nil {
+		return err
 	}
+	d.configProfiles = profiles
+
+	return d.SwitchProfile(profile)
+}
+
+// applyConfig loads the given configuration and shows the page that was shown the last time.
+func (d *HamDeck) applyConfig(configuration map[string]any) error {
 	effectiveConfiguration := findEffectiveConfiguration(configuration)
+	profiles, err := loadConfigProfiles(effectiveConfiguration)
+	if err != nil {
+		return err
+	}
+	d.configProfiles = profiles
+
+	err = d.loadConfiguration(effectiveConfiguration)
+	if err != nil {
+		return err
+	}
 
+	err = d.AttachPage(d.restoredPageID())
+	if err != nil {
+		return err
+	}
+
+	d.rules.EvaluateAll()
+	return nil
+}
+
+func (d *HamDeck) loadConfiguration(configuration map[string]any) error {
+	effectiveConfiguration := findEffectiveConfiguration(configuration)
+
+	err := loadLogLevels(effectiveConfiguration[ConfigLogLevel])
+	if err != nil {
+		return err
+	}
+
+	d.pageLock.Lock()
+	d.configGeneration++
+	d.pageLock.Unlock()
+
+	d.releasePages()
 	d.buttonsPerFactory = make([]int, len(d.factories))
 	d.connections = make(map[connectionKey]ConnectionConfig)
+	d.pageLock.Lock()
 	d.pages = make(map[string]Page)
+	d.pageLock.Unlock()
+	d.rules.Clear()
+	d.schedules.Clear()
+	d.groups.Clear()
+	d.listeners.Clear()
+	d.interlock.Clear()
+	d.clearChangeNotifiers()
 
 	connections, ok := (effectiveConfiguration[ConfigConnections]).(map[string]any)
 	if ok {
@@ -53,13 +140,48 @@ func (d *HamDeck) ReadConfig(r io.Reader) error {
 		return err
 	}
 
+	groups, ok := effectiveConfiguration[ConfigGroups].(map[string]any)
+	if ok {
+		err = d.loadGroups(groups)
+	}
+	if err != nil {
+		return err
+	}
+
+	interlock, ok := effectiveConfiguration[ConfigInterlock].(map[string]any)
+	if ok {
+		err = d.loadInterlock(interlock)
+	}
+	if err != nil {
+		return err
+	}
+
+	devices, _ := effectiveConfiguration[ConfigDevices].(map[string]any)
+	err = d.loadDevices(devices)
+	if err != nil {
+		return err
+	}
+
+	err = d.loadSplash(effectiveConfiguration)
+	if err != nil {
+		return err
+	}
+
+	var layout Layout
+	if rawLayout, ok := effectiveConfiguration[ConfigLayout]; ok {
+		layout, err = ParseLayout(rawLayout)
+	}
+	if err != nil {
+		return err
+	}
+
 	d.startPageID, ok = effectiveConfiguration[ConfigStartPageID].(string)
 	if !ok {
 		d.startPageID = legacyPageID
 	}
 	pages, ok := effectiveConfiguration[ConfigPages].(map[string]any)
 	if ok {
-		err = d.loadPages(pages)
+		err = d.loadPages(pages, layout)
 	}
 	if err != nil {
 		return err
@@ -67,7 +189,7 @@ func (d *HamDeck) ReadConfig(r io.Reader) error {
 
 	buttons, ok := effectiveConfiguration[ConfigButtons].([]any)
 	if ok {
-		err = d.loadLegacyPage(buttons)
+		err = d.loadLegacyPage(buttons, layout)
 	} else if len(d.pages) == 0 {
 		d.loadEmptyLegacyPage()
 	}
@@ -75,7 +197,19 @@ func (d *HamDeck) ReadConfig(r io.Reader) error {
 		return err
 	}
 
-	return d.AttachPage(d.startPageID)
+	rules, ok := effectiveConfiguration[ConfigRules].([]any)
+	if ok {
+		err = d.loadRules(rules)
+	}
+	if err != nil {
+		return err
+	}
+
+	schedules, ok := effectiveConfiguration[ConfigSchedules].([]any)
+	if ok {
+		err = d.loadSchedules(schedules)
+	}
+	return err
 }
 
 func findEffectiveConfiguration(configuration map[string]any) map[string]any {
@@ -91,16 +225,34 @@ func findEffectiveConfiguration(configuration map[string]any) map[string]any {
 	return subconfiguration
 }
 
+// loadLogLevels sets the log levels from the configuration, e.g. "warn,mqtt=debug".
+func loadLogLevels(configuration any) error {
+	if configuration == nil {
+		logging.SetConfigLevels(nil)
+		return nil
+	}
+	rawLevels, ok := ToString(configuration)
+	if !ok {
+		return fmt.Errorf("%s must be a string", ConfigLogLevel)
+	}
+	levels, err := logging.ParseLevels(rawLevels)
+	if err != nil {
+		return err
+	}
+	logging.SetConfigLevels(levels)
+	return nil
+}
+
 func (d *HamDeck) loadConnections(configuration map[string]any) error {
 	for name, config := range configuration {
 		connection, ok := config.(map[string]any)
 		if !ok {
-			log.Printf("%s is not a valid connection configuration", name)
+			logger.Error("invalid connection configuration", "connection", name)
 			continue
 		}
 		connectionType, ok := ToString(connection[ConfigType])
 		if !ok {
-			log.Printf("connection %s needs a type", name)
+			logger.Error("connection needs a type", "connection", name)
 			continue
 		}
 		d.connections[connectionKey{name, connectionType}] = ConnectionConfig(connection)
@@ -108,47 +260,63 @@ func (d *HamDeck) loadConnections(configuration map[string]any) error {
 	return nil
 }
 
-func (d *HamDeck) loadPages(configuration map[string]any) error {
+func (d *HamDeck) loadPages(configuration map[string]any, layout Layout) error {
 	for id, rawPage := range configuration {
 		pageConfiguration, ok := rawPage.(map[string]any)
 		if !ok {
 			return fmt.Errorf("%s is not a valid page", id)
 		}
 
-		page, err := d.loadPage(id, pageConfiguration)
+		pages, err := d.loadPage(id, pageConfiguration, layout)
 		if err != nil {
 			return err
 		}
 
-		d.pages[id] = page
+		for pageID, page := range pages {
+			d.pages[pageID] = page
+		}
 	}
 	return nil
 }
 
-func (d *HamDeck) loadPage(id string, configuration map[string]any) (Page, error) {
+func (d *HamDeck) loadPage(id string, configuration map[string]any, layout Layout) (map[string]Page, error) {
+	if _, ok := configuration[ConfigGenerate]; ok {
+		return d.loadGeneratedPage(id, configuration, d.pageChangedFunc(id, configuration))
+	}
+	if pageType, _ := ToString(configuration[ConfigType]); pageType == ImagePageType {
+		return d.loadImagePage(id, configuration)
+	}
 	buttonsConfiguration, ok := configuration[ConfigButtons].([]any)
 	if !ok {
-		return Page{}, fmt.Errorf("page %s has no buttons defined", id)
+		return nil, fmt.Errorf("page %s has no buttons defined", id)
 	}
-
-	buttons, err := d.loadButtons(buttonsConfiguration)
-	if err != nil {
-		return Page{}, err
+	pageType, _ := ToString(configuration[ConfigType])
+	switch pageType {
+	case "":
+	case ListPageType:
+		return d.loadListPage(id, configuration, buttonsConfiguration)
+	default:
+		return nil, fmt.Errorf("page %s has the unknown type %s", id, pageType)
+	}
+	if rawLayout, ok := configuration[ConfigLayout]; ok {
+		var err error
+		layout, err = ParseLayout(rawLayout)
+		if err != nil {
+			return nil, fmt.Errorf("page %s: %w", id, err)
+		}
 	}
 
-	return Page{
-		buttons: buttons,
-	}, nil
+	return d.loadButtons(id, buttonsConfiguration, layout)
 }
 
-func (d *HamDeck) loadLegacyPage(configuration []any) error {
-	buttons, err := d.loadButtons(configuration)
+func (d *HamDeck) loadLegacyPage(configuration []any, layout Layout) error {
+	pages, err := d.loadButtons(legacyPageID, configuration, layout)
 	if err != nil {
 		return err
 	}
 
-	d.pages[legacyPageID] = Page{
-		buttons: buttons,
+	for pageID, page := range pages {
+		d.pages[pageID] = page
 	}
 	return nil
 }
@@ -159,40 +327,132 @@ func (d *HamDeck) loadEmptyLegacyPage() {
 	}
 }
 
-func (d *HamDeck) loadButtons(configuration []any) ([]Button, error) {
-	result := make([]Button, len(d.buttons))
+func (d *HamDeck) loadRules(configuration []any) error {
+	for i, rawRule := range configuration {
+		ruleConfiguration, ok := rawRule.(map[string]any)
+		if !ok {
+			return fmt.Errorf("rules[%d] is not a rule object", i)
+		}
+
+		rule, err := ParseRule(ruleConfiguration)
+		if err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+		if _, ok := d.pages[rule.PageID]; !ok {
+			return fmt.Errorf("rules[%d]: no page defined with name %s", i, rule.PageID)
+		}
+		for _, condition := range rule.Conditions {
+			err := d.RequestState(condition.Connection, condition.State)
+			if err != nil {
+				logger.Error("invalid rule", "index", i, "error", err)
+			}
+		}
+
+		d.rules.Add(rule)
+	}
+	return nil
+}
+
+// loadButtons creates the buttons of the page with the given ID. The buttons are placed on the given layout;
+// if it does not fit on the device, the buttons are reflowed onto the device and continuation pages.
+func (d *HamDeck) loadButtons(pageID string, configuration []any, layout Layout) (map[string]Page, error) {
+	deviceLayout := d.deviceLayout()
+	if layout.Keys() == 0 {
+		layout = deviceLayout
+	}
+
+	placedButtons := make([]placedButton, 0, len(configuration))
 	for i, rawButtonConfig := range configuration {
 		buttonConfig, ok := rawButtonConfig.(map[string]any)
 		if !ok {
-			log.Printf("buttons[%d] is not a button object", i)
+			logger.Error("button is not a button object", "index", i)
 			continue
 		}
 
-		buttonIndex, ok := ToInt(buttonConfig[ConfigIndex])
-		if !ok {
-			log.Printf("buttons[%d] has no valid index", i)
+		buttonIndex, err := layout.ButtonIndex(buttonConfig)
+		if err != nil {
+			logger.Error("button has no valid position", "index", i, "error", err)
 			continue
 		}
-		if buttonIndex < 0 || buttonIndex >= len(d.buttons) {
-			log.Printf("%d is not a valid button index in [0, %d])", buttonIndex, len(d.buttons))
-		}
+		placedButtons = append(placedButtons, placedButton{index: buttonIndex, config: buttonConfig, source: i})
+	}
 
-		var button Button
-		for j, factory := range d.factories {
-			button = factory.CreateButton(buttonConfig)
-			if button != nil {
-				d.buttonsPerFactory[j] += 1
-				break
-			}
+	arrangement := arrangeButtons(placedButtons, layout, deviceLayout)
+	result := make(map[string]Page, len(arrangement))
+	for n, pageButtons := range arrangement {
+		id := ContinuationPageID(pageID, n)
+		page := d.createPage(id, pageButtons)
+		if len(arrangement) > 1 {
+			page.setPageButton(len(d.buttons)-1, NewPageButton(d, ContinuationPageID(pageID, (n+1)%len(arrangement)), MoreButtonLabel))
 		}
+		result[id] = page
+	}
+	return result, nil
+}
+
+// createPage creates the buttons of the page with the given ID on their keys.
+func (d *HamDeck) createPage(id string, placedButtons []placedButton) Page {
+	result := Page{
+		buttons: make([]Button, len(d.buttons)),
+		infos:   make([]buttonInfo, len(d.buttons)),
+	}
+	for _, placed := range placedButtons {
+		button := d.CreateButton(placed.config)
 		if button == nil {
-			log.Printf("no factory found for buttons[%d]", i)
+			logger.Error("no factory found for button", "index", placed.source)
 			continue
 		}
 
-		result[buttonIndex] = button
+		d.restoreButton(id, placed.index, button)
+		result.buttons[placed.index] = d.decorateButton(button, placed.config)
+		result.infos[placed.index] = newButtonInfo(placed.config)
 	}
-	return result, nil
+	return result
+}
+
+// CreateButton creates a new button using the first factory that is able to handle the given configuration.
+func (d *HamDeck) CreateButton(config map[string]any) Button {
+	for i, factory := range d.factories {
+		button := factory.CreateButton(config)
+		if button != nil {
+			d.buttonsPerFactory[i] += 1
+			return button
+		}
+	}
+	return nil
+}
+
+// createAction creates a button that is triggered by another button or by a schedule.
+// The action is decorated like the buttons on the keys, so it cannot bypass the TX interlock.
+func (d *HamDeck) createAction(config map[string]any) Button {
+	button := d.CreateButton(config)
+	if button == nil {
+		return nil
+	}
+	return d.decorateButton(button, config)
+}
+
+// decorateButton wraps the given button according to the generic options in the button's configuration.
+func (d *HamDeck) decorateButton(button Button, config map[string]any) Button {
+	transmitButton, ok := button.(TransmitButton)
+	if ok {
+		button = &InterlockButton{button: transmitButton, interlock: d.interlock}
+	}
+
+	groupName, ok := ToString(config[ConfigGroup])
+	if ok {
+		groupValue, _ := ToString(config[ConfigGroupValue])
+		button = d.groups.Get(groupName).Join(button, groupValue)
+	}
+
+	confirm, _ := ToBool(config[ConfigConfirm])
+	hold, _ := ToInt(config[ConfigHold])
+	if confirm || hold > 0 {
+		timeout, _ := ToInt(config[ConfigConfirmTimeout])
+		button = NewConfirmButton(d, button, confirm, time.Duration(timeout)*time.Millisecond, time.Duration(hold)*time.Millisecond)
+	}
+
+	return button
 }
 
 func (d *HamDeck) CloseUnusedFactories() {
@@ -296,3 +556,38 @@ func ToStringArray(raw any) ([]string, bool) {
 	}
 	return result, true
 }
+
+var namedColors = map[string]color.Color{
+	"black":     Black,
+	"white":     White,
+	"gray":      DisabledGray,
+	"red":       Red,
+	"green":     Green,
+	"blue":      Blue,
+	"yellow":    Yellow,
+	"magenta":   Magenta,
+	"cyan":      Cyan,
+	"darkgreen": DarkGreen,
+	"orange":    Orange,
+}
+
+// ToColor converts a color name or a hex color code (#rrggbb) into a color.
+func ToColor(raw any) (color.Color, bool) {
+	s, ok := ToString(raw)
+	if !ok {
+		return nil, false
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+	if namedColor, ok := namedColors[s]; ok {
+		return namedColor, true
+	}
+
+	if len(s) != 7 || s[0] != '#' {
+		return nil, false
+	}
+	rgb, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	return color.RGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255}, true
+}
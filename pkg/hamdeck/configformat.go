@@ -0,0 +1,148 @@
+package hamdeck
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileFormat is the file format of a configuration.
+type ConfigFileFormat string
+
+const (
+	JSONConfig ConfigFileFormat = "json"
+	YAMLConfig ConfigFileFormat = "yaml"
+	TOMLConfig ConfigFileFormat = "toml"
+)
+
+// ConfigDefaultFilenames are the names of the configuration files that are looked up in the configuration directory,
+// in this order.
+var ConfigDefaultFilenames = []string{ConfigDefaultFilename, "hamdeck.yaml"}
+
+// ConfigFormatByFilename returns the configuration format that belongs to the extension of the given filename.
+func ConfigFormatByFilename(filename string) (ConfigFileFormat, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return JSONConfig, true
+	case ".yaml", ".yml":
+		return YAMLConfig, true
+	case ".toml":
+		return TOMLConfig, true
+	default:
+		return "", false
+	}
+}
+
+var (
+	tomlTableExpression    = regexp.MustCompile(`^\[\[?[\w."' -]+\]\]?$`)
+	tomlKeyValueExpression = regexp.MustCompile(`^[\w."'-]+\s*=`)
+)
+
+// SniffConfigFormat detects the format of the given configuration data. JSON starts with a curly brace, TOML
+// with a table header or a key/value pair. Everything else is considered to be YAML.
+func SniffConfigFormat(data []byte) ConfigFileFormat {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "{"):
+			return JSONConfig
+		case tomlTableExpression.MatchString(line), tomlKeyValueExpression.MatchString(line):
+			return TOMLConfig
+		default:
+			return YAMLConfig
+		}
+	}
+	return JSONConfig
+}
+
+// UnmarshalConfig unmarshals the given configuration data in the given format. If the format is empty, it is detected
+// from the content. All formats result in the same tree as JSON: objects are map[string]any, arrays are []any,
+// and all numbers are float64.
+func UnmarshalConfig(data []byte, format ConfigFileFormat) (map[string]any, error) {
+	if format == "" {
+		format = SniffConfigFormat(data)
+	}
+
+	var rawData any
+	var err error
+	switch format {
+	case JSONConfig:
+		err = json.Unmarshal(data, &rawData)
+	case YAMLConfig:
+		err = yaml.Unmarshal(data, &rawData)
+		if err == nil {
+			rawData, err = normalizeConfig(rawData)
+		}
+	case TOMLConfig:
+		var tomlData map[string]any
+		err = toml.Unmarshal(data, &tomlData)
+		if err == nil {
+			rawData, err = normalizeConfig(tomlData)
+		}
+	default:
+		return nil, fmt.Errorf("unknown configuration format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal the %s configuration: %w", format, err)
+	}
+
+	configuration, ok := rawData.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("configuration is of wrong type: %T", rawData)
+	}
+	return configuration, nil
+}
+
+// normalizeConfig converts the given tree into the same tree that JSON produces.
+func normalizeConfig(rawData any) (any, error) {
+	data, err := json.Marshal(stringKeys(rawData))
+	if err != nil {
+		return nil, err
+	}
+	var result any
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// stringKeys converts all maps with non-string keys, as YAML allows them, into maps with string keys.
+func stringKeys(rawData any) any {
+	switch data := rawData.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(data))
+		for key, value := range data {
+			result[key] = stringKeys(value)
+		}
+		return result
+	case map[any]any:
+		result := make(map[string]any, len(data))
+		for key, value := range data {
+			result[fmt.Sprint(key)] = stringKeys(value)
+		}
+		return result
+	case []any:
+		result := make([]any, len(data))
+		for i, value := range data {
+			result[i] = stringKeys(value)
+		}
+		return result
+	case []map[string]any:
+		result := make([]any, len(data))
+		for i, value := range data {
+			result[i] = stringKeys(value)
+		}
+		return result
+	default:
+		return data
+	}
+}
@@ -0,0 +1,124 @@
+package hamdeck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jsonFormatTestConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": {
+			"buttons": [
+				{ "type": "test.Button", "index": 3, "label": "40m", "band": "40m" },
+				{ "type": "test.Button", "index": 4, "label": "20m", "band": "20m" }
+			]
+		}
+	}
+}`
+
+const yamlFormatTestConfig = `# the main page
+start_page: main
+pages:
+  main:
+    buttons:
+      - &band
+        type: test.Button
+        index: 3
+        label: 40m
+        band: 40m
+      - <<: *band
+        index: 4
+        label: 20m
+        band: 20m
+`
+
+const tomlFormatTestConfig = `# the main page
+start_page = "main"
+
+[[pages.main.buttons]]
+type = "test.Button"
+index = 3
+label = "40m"
+band = "40m"
+
+[[pages.main.buttons]]
+type = "test.Button"
+index = 4
+label = "20m"
+band = "20m"
+`
+
+func TestSniffConfigFormat(t *testing.T) {
+	tt := []struct {
+		name     string
+		data     string
+		expected ConfigFileFormat
+	}{
+		{"json", jsonFormatTestConfig, JSONConfig},
+		{"yaml", yamlFormatTestConfig, YAMLConfig},
+		{"toml", tomlFormatTestConfig, TOMLConfig},
+		{"toml table", "\n[pages.main]\n", TOMLConfig},
+		{"yaml list", "- a\n- b\n", YAMLConfig},
+		{"empty", "", JSONConfig},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SniffConfigFormat([]byte(tc.data)))
+		})
+	}
+}
+
+func TestConfigFormatByFilename(t *testing.T) {
+	for filename, expected := range map[string]ConfigFileFormat{
+		"hamdeck.json": JSONConfig,
+		"hamdeck.yaml": YAMLConfig,
+		"hamdeck.YML":  YAMLConfig,
+		"hamdeck.toml": TOMLConfig,
+	} {
+		actual, ok := ConfigFormatByFilename(filename)
+		assert.True(t, ok, filename)
+		assert.Equal(t, expected, actual, filename)
+	}
+	_, ok := ConfigFormatByFilename("hamdeck.conf")
+	assert.False(t, ok)
+}
+
+func TestUnmarshalConfig_SameTreeForAllFormats(t *testing.T) {
+	expected, err := UnmarshalConfig([]byte(jsonFormatTestConfig), JSONConfig)
+	require.NoError(t, err)
+
+	for format, data := range map[ConfigFileFormat]string{
+		YAMLConfig: yamlFormatTestConfig,
+		TOMLConfig: tomlFormatTestConfig,
+	} {
+		actual, err := UnmarshalConfig([]byte(data), "")
+		require.NoError(t, err, format)
+		assert.Equal(t, expected, actual, format)
+	}
+}
+
+func TestUnmarshalConfig_Invalid(t *testing.T) {
+	_, err := UnmarshalConfig([]byte("pages: [unclosed"), YAMLConfig)
+	assert.Error(t, err)
+
+	_, err = UnmarshalConfig([]byte("- a\n- b\n"), YAMLConfig)
+	assert.Error(t, err)
+
+	_, err = UnmarshalConfig([]byte("{}"), "ini")
+	assert.Error(t, err)
+}
+
+func TestReadConfig_YAML(t *testing.T) {
+	deck := New(newDefaultTestDevice())
+	deck.RegisterFactory(new(testButtonFactory))
+
+	require.NoError(t, deck.ReadConfig(strings.NewReader(yamlFormatTestConfig)))
+
+	button := deck.buttons[4].(*testButton)
+	assert.Equal(t, "20m", button.config["band"])
+	assert.Equal(t, float64(4), button.config[ConfigIndex])
+}
@@ -0,0 +1,198 @@
+package hamdeck
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	ConfigInclude   = "include"
+	ConfigVariables = "variables"
+)
+
+// LoadConfig reads the configuration from the given file. The format is selected by the file extension or detected
+// from the content. The included files are merged into the configuration and all variables are substituted.
+func LoadConfig(filename string) (map[string]any, error) {
+	configuration, err := loadConfigFile(filename, nil)
+	if err != nil {
+		return nil, err
+	}
+	return substituteConfigVariables(configuration)
+}
+
+func loadConfigFile(filename string, including []string) (map[string]any, error) {
+	absoluteFilename, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve the configuration file %s: %w", filename, err)
+	}
+	for _, includingFilename := range including {
+		if includingFilename == absoluteFilename {
+			return nil, fmt.Errorf("the configuration file %s includes itself", filename)
+		}
+	}
+
+	data, err := os.ReadFile(absoluteFilename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the configuration file: %w", err)
+	}
+	format, _ := ConfigFormatByFilename(absoluteFilename)
+	configuration, err := UnmarshalConfig(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return resolveConfigIncludes(findEffectiveConfiguration(configuration), filepath.Dir(absoluteFilename), append(including, absoluteFilename))
+}
+
+// resolveConfigIncludes merges the files included by the given configuration. The include directive is either
+// a single filename or a list of filenames; each filename may be a glob pattern and is resolved relative to the
+// given directory. The definitions of the including configuration take precedence over the included ones.
+func resolveConfigIncludes(configuration map[string]any, dir string, including []string) (map[string]any, error) {
+	rawInclude, ok := configuration[ConfigInclude]
+	if !ok {
+		return configuration, nil
+	}
+	patterns, ok := ToStringArray(rawInclude)
+	if !ok {
+		pattern, isString := rawInclude.(string)
+		if !isString {
+			return nil, fmt.Errorf("%s must be a filename or a list of filenames", ConfigInclude)
+		}
+		patterns = []string{pattern}
+	}
+
+	result := make(map[string]any)
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		filenames, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %s: %w", ConfigInclude, pattern, err)
+		}
+		if len(filenames) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			filenames = []string{pattern}
+		}
+		for _, filename := range filenames {
+			included, err := loadConfigFile(filename, including)
+			if err != nil {
+				return nil, err
+			}
+			result = mergeConfig(result, included)
+		}
+	}
+
+	ownConfiguration := make(map[string]any, len(configuration))
+	for key, value := range configuration {
+		if key != ConfigInclude {
+			ownConfiguration[key] = value
+		}
+	}
+	return mergeConfig(result, ownConfiguration), nil
+}
+
+// mergeConfig merges the overlay into the base configuration. Objects are merged recursively, all other values
+// of the overlay replace the values of the base configuration.
+func mergeConfig(base map[string]any, overlay map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(overlay))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range overlay {
+		baseObject, baseIsObject := result[key].(map[string]any)
+		overlayObject, overlayIsObject := value.(map[string]any)
+		if baseIsObject && overlayIsObject {
+			result[key] = mergeConfig(baseObject, overlayObject)
+		} else {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+var configVariableExpression = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// substituteConfigVariables replaces all ${VAR} references in the string values of the configuration. A variable is
+// looked up in the environment first, then in the variables section of the configuration. The values in the
+// variables section may reference environment variables.
+func substituteConfigVariables(configuration map[string]any) (map[string]any, error) {
+	rawVariables, ok := configuration[ConfigVariables]
+	if !ok {
+		rawVariables = map[string]any{}
+	}
+	variables, ok := rawVariables.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object", ConfigVariables)
+	}
+
+	lookupEnv := func(name string) (string, bool) {
+		return os.LookupEnv(name)
+	}
+	lookup := func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		rawValue, ok := variables[name]
+		if !ok {
+			return "", false
+		}
+		value, ok := rawValue.(string)
+		if !ok {
+			value, _ = ToString(rawValue)
+			return value, true
+		}
+		value, err := substituteString(value, lookupEnv)
+		return value, err == nil
+	}
+
+	result, err := substituteValue(configuration, lookup)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]any), nil
+}
+
+func substituteValue(raw any, lookup func(string) (string, bool)) (any, error) {
+	switch value := raw.(type) {
+	case string:
+		return substituteString(value, lookup)
+	case map[string]any:
+		result := make(map[string]any, len(value))
+		for key, item := range value {
+			substituted, err := substituteValue(item, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = substituted
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(value))
+		for i, item := range value {
+			substituted, err := substituteValue(item, lookup)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = substituted
+		}
+		return result, nil
+	default:
+		return raw, nil
+	}
+}
+
+func substituteString(s string, lookup func(string) (string, bool)) (string, error) {
+	var err error
+	result := configVariableExpression.ReplaceAllStringFunc(s, func(reference string) string {
+		name := configVariableExpression.FindStringSubmatch(reference)[1]
+		value, ok := lookup(name)
+		if !ok && err == nil {
+			err = fmt.Errorf("the variable %s is not defined", name)
+		}
+		return value
+	})
+	return result, err
+}
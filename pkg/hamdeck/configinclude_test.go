@@ -0,0 +1,154 @@
+package hamdeck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for filename, content := range files {
+		filename = filepath.Join(dir, filename)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestLoadConfig_Include(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"hamdeck.yaml": `
+include:
+  - common/connections.json
+  - pages/*.toml
+start_page: main
+pages:
+  main:
+    buttons:
+      - { type: test.Button, index: 0 }
+`,
+		"common/connections.json": `{
+	"connections": {
+		"rig": { "type": "hamlib", "address": "localhost:4532" },
+		"atu": { "type": "mqtt", "address": "localhost:1883" }
+	},
+	"pages": {
+		"main": { "buttons": [ { "type": "test.Button", "index": 1 } ] }
+	}
+}`,
+		"pages/bands.toml": `
+[[pages.bands.buttons]]
+type = "test.Button"
+index = 2
+`,
+		"pages/modes.toml": `
+include = "../common/rig.yaml"
+
+[[pages.modes.buttons]]
+type = "test.Button"
+index = 3
+`,
+		"common/rig.yaml": `
+connections:
+  rig: { address: "rig.local:4532" }
+`,
+	})
+
+	configuration, err := LoadConfig(filepath.Join(dir, "hamdeck.yaml"))
+	require.NoError(t, err)
+
+	assert.NotContains(t, configuration, ConfigInclude)
+	assert.Equal(t, map[string]any{
+		"rig": map[string]any{"type": "hamlib", "address": "rig.local:4532"},
+		"atu": map[string]any{"type": "mqtt", "address": "localhost:1883"},
+	}, configuration[ConfigConnections])
+
+	pages := configuration[ConfigPages].(map[string]any)
+	assert.Len(t, pages, 3)
+	assert.Equal(t, []any{map[string]any{"type": "test.Button", "index": float64(0)}}, pages["main"].(map[string]any)[ConfigButtons])
+	assert.Contains(t, pages, "bands")
+	assert.Contains(t, pages, "modes")
+}
+
+func TestLoadConfig_IncludeCycle(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yaml": "include: b.yaml\n",
+		"b.yaml": "include: a.yaml\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "a.yaml"))
+	assert.ErrorContains(t, err, "includes itself")
+}
+
+func TestLoadConfig_MissingInclude(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"hamdeck.yaml": "include: [missing.yaml, optional/*.yaml]\n",
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "hamdeck.yaml"))
+	assert.ErrorContains(t, err, "missing.yaml")
+}
+
+func TestLoadConfig_Variables(t *testing.T) {
+	t.Setenv("HAMDECK_TEST_MQTT_PASSWORD", "secret")
+	t.Setenv("HAMDECK_TEST_RIG_HOST", "shack.local")
+	dir := writeConfigFiles(t, map[string]string{
+		"hamdeck.yaml": `
+variables:
+  rig_address: ${HAMDECK_TEST_RIG_HOST}:4532
+  mqtt_address: localhost:1883
+connections:
+  rig: { type: hamlib, address: "${rig_address}" }
+  atu: { type: mqtt, address: "${mqtt_address}", password: "${HAMDECK_TEST_MQTT_PASSWORD}" }
+`,
+	})
+
+	configuration, err := LoadConfig(filepath.Join(dir, "hamdeck.yaml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"rig": map[string]any{"type": "hamlib", "address": "shack.local:4532"},
+		"atu": map[string]any{"type": "mqtt", "address": "localhost:1883", "password": "secret"},
+	}, configuration[ConfigConnections])
+}
+
+func TestLoadConfig_EnvironmentOverridesVariables(t *testing.T) {
+	t.Setenv("HAMDECK_TEST_RIG", "env.local:4532")
+	dir := writeConfigFiles(t, map[string]string{
+		"hamdeck.json": `{"variables": {"HAMDECK_TEST_RIG": "localhost:4532"}, "connections": {"rig": {"address": "${HAMDECK_TEST_RIG}"}}}`,
+	})
+
+	configuration, err := LoadConfig(filepath.Join(dir, "hamdeck.json"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "env.local:4532", configuration[ConfigConnections].(map[string]any)["rig"].(map[string]any)["address"])
+}
+
+func TestLoadConfig_UndefinedVariable(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"hamdeck.json": `{"connections": {"rig": {"address": "${HAMDECK_TEST_UNDEFINED}"}}}`,
+	})
+
+	_, err := LoadConfig(filepath.Join(dir, "hamdeck.json"))
+	assert.ErrorContains(t, err, "HAMDECK_TEST_UNDEFINED")
+}
+
+func TestReadConfigFile(t *testing.T) {
+	t.Setenv("HAMDECK_TEST_LABEL", "40m")
+	dir := writeConfigFiles(t, map[string]string{
+		"hamdeck.yaml": "include: buttons.yaml\n",
+		"buttons.yaml": "buttons:\n  - { type: test.Button, index: 5, label: \"${HAMDECK_TEST_LABEL}\" }\n",
+	})
+	deck := New(newDefaultTestDevice())
+	deck.RegisterFactory(new(testButtonFactory))
+
+	require.NoError(t, deck.ReadConfigFile(filepath.Join(dir, "hamdeck.yaml")))
+
+	button := deck.buttons[5].(*testButton)
+	assert.Equal(t, "40m", button.config["label"])
+}
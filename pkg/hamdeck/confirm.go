@@ -0,0 +1,212 @@
+package hamdeck
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+const (
+	ConfigConfirm        = "confirm"
+	ConfigConfirmTimeout = "confirm_timeout"
+	ConfigHold           = "hold"
+)
+
+// DefaultConfirmTimeout is the time window for the confirming press of a ConfirmButton.
+const DefaultConfirmTimeout = 3 * time.Second
+
+/*
+	ConfirmButton
+*/
+
+// ConfirmButton guards the wrapped button against accidental presses. If confirm is set, the first press only arms
+// the button and a second press within the timeout performs the action. If hold is set, the activating press must
+// be held for the given duration.
+func NewConfirmButton(deck *HamDeck, button Button, confirm bool, timeout time.Duration, hold time.Duration) *ConfirmButton {
+	if timeout <= 0 {
+		timeout = DefaultConfirmTimeout
+	}
+	return &ConfirmButton{
+		deck:    deck,
+		button:  button,
+		lock:    new(sync.Mutex),
+		confirm: confirm,
+		timeout: timeout,
+		hold:    hold,
+	}
+}
+
+type ConfirmButton struct {
+	BaseButton
+	deck    *HamDeck
+	button  Button
+	lock    *sync.Mutex
+	confirm bool
+	timeout time.Duration
+	hold    time.Duration
+
+	armed      bool
+	armedTimer *time.Timer
+	holding    bool
+	holdTimer  *time.Timer
+	holdCount  int
+	active     bool
+	flashOn    bool
+
+	confirmImage  image.Image
+	flashingImage image.Image
+	holdImage     image.Image
+}
+
+func (b *ConfirmButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	if b.confirmImage == nil || b.flashingImage == nil || b.holdImage == nil || redrawImages {
+		b.redrawImages(gc)
+	}
+
+	b.lock.Lock()
+	armed := b.armed
+	holding := b.holding
+	flashOn := b.flashOn
+	b.lock.Unlock()
+
+	switch {
+	case holding:
+		return b.holdImage
+	case armed && flashOn:
+		return b.flashingImage
+	case armed:
+		return b.confirmImage
+	default:
+		return b.button.Image(gc, redrawImages)
+	}
+}
+
+func (b *ConfirmButton) redrawImages(gc GraphicContext) {
+	gc.Reset()
+	gc.SetForeground(White)
+	gc.SetBackground(Red)
+	b.confirmImage = gc.DrawSingleLineTextButton("Confirm?")
+	b.holdImage = gc.DrawSingleLineTextButton("Hold...")
+	gc.SwapColors()
+	b.flashingImage = gc.DrawSingleLineTextButton("Confirm?")
+}
+
+func (b *ConfirmButton) Pressed() {
+	b.lock.Lock()
+	if b.confirm && !b.armed {
+		b.armed = true
+		b.armedTimer = time.AfterFunc(b.timeout, b.disarm)
+		b.lock.Unlock()
+		b.Invalidate(false)
+		return
+	}
+
+	if b.armedTimer != nil {
+		b.armedTimer.Stop()
+		b.armedTimer = nil
+	}
+	b.armed = false
+	if b.hold > 0 {
+		b.holding = true
+		b.holdCount++
+		holdCount := b.holdCount
+		b.holdTimer = time.AfterFunc(b.hold, func() {
+			b.deck.post(func() {
+				b.holdDone(holdCount)
+			})
+		})
+		b.lock.Unlock()
+		b.Invalidate(false)
+		return
+	}
+	b.lock.Unlock()
+
+	b.activate()
+}
+
+func (b *ConfirmButton) Released() {
+	b.lock.Lock()
+	if b.holdTimer != nil {
+		b.holdTimer.Stop()
+		b.holdTimer = nil
+	}
+	wasHolding := b.holding
+	b.holding = false
+	active := b.active
+	b.active = false
+	b.lock.Unlock()
+
+	if active {
+		b.button.Released()
+	}
+	if wasHolding {
+		b.Invalidate(false)
+	}
+}
+
+// holdDone activates the wrapped button if the key is still held since the given press. It runs in the goroutine
+// that runs the deck, like Pressed and Released.
+func (b *ConfirmButton) holdDone(holdCount int) {
+	b.lock.Lock()
+	if !b.holding || holdCount != b.holdCount {
+		b.lock.Unlock()
+		return
+	}
+	b.holdTimer = nil
+	b.holding = false
+	b.lock.Unlock()
+
+	b.activate()
+}
+
+func (b *ConfirmButton) activate() {
+	b.lock.Lock()
+	b.active = true
+	b.lock.Unlock()
+
+	b.Invalidate(false)
+	b.button.Pressed()
+}
+
+func (b *ConfirmButton) disarm() {
+	b.lock.Lock()
+	wasArmed := b.armed
+	b.armed = false
+	b.armedTimer = nil
+	b.lock.Unlock()
+
+	if wasArmed {
+		b.Invalidate(false)
+	}
+}
+
+func (b *ConfirmButton) Flash(on bool) {
+	b.lock.Lock()
+	b.flashOn = on
+	armed := b.armed
+	b.lock.Unlock()
+
+	if armed {
+		b.Invalidate(false)
+		return
+	}
+	flashingButton, ok := b.button.(FlashingButton)
+	if ok {
+		flashingButton.Flash(on)
+	}
+}
+
+func (b *ConfirmButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.button.Attached(ctx)
+}
+
+func (b *ConfirmButton) Detached() {
+	b.disarm()
+	b.button.Detached()
+	b.BaseButton.Detached()
+}
+
+func (b *ConfirmButton) Close() {
+	CloseButton(b.button)
+}
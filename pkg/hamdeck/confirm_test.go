@@ -0,0 +1,94 @@
+package hamdeck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const confirmTestConfig = `{
+	"buttons": [
+		{ "type": "test.Button", "index": 1, "confirm": true, "confirm_timeout": 100 },
+		{ "type": "test.Button", "index": 2, "hold": 50 }
+	]
+}`
+
+func TestConfirm_SecondPressWithinTimeoutActivates(t *testing.T) {
+	runWithConfigString(t, confirmTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		button, ok := deck.buttons[1].(*ConfirmButton)
+		require.True(t, ok)
+		inner := button.button.(*testButton)
+
+		device.Press(1)
+		device.Release(1)
+		device.WaitForLastKey()
+		assert.False(t, inner.pressed)
+
+		device.Press(1)
+		device.Release(1)
+		device.WaitForLastKey()
+		assert.True(t, inner.pressed)
+		assert.True(t, inner.released)
+	})
+}
+
+func TestConfirm_TimeoutDisarms(t *testing.T) {
+	runWithConfigString(t, confirmTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		button, ok := deck.buttons[1].(*ConfirmButton)
+		require.True(t, ok)
+		inner := button.button.(*testButton)
+
+		device.Press(1)
+		device.Release(1)
+		device.WaitForLastKey()
+		time.Sleep(150 * time.Millisecond)
+
+		device.Press(1)
+		device.Release(1)
+		device.WaitForLastKey()
+		assert.False(t, inner.pressed)
+	})
+}
+
+func TestConfirm_Hold(t *testing.T) {
+	runWithConfigString(t, confirmTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		button, ok := deck.buttons[2].(*ConfirmButton)
+		require.True(t, ok)
+		inner := button.button.(*testButton)
+
+		device.Press(2)
+		device.Release(2)
+		device.WaitForLastKey()
+		assert.False(t, inner.pressed)
+
+		device.Press(2)
+		device.WaitForLastKey()
+		time.Sleep(100 * time.Millisecond)
+		device.Release(2)
+		device.WaitForLastKey()
+		assert.True(t, inner.pressed)
+		assert.True(t, inner.released)
+	})
+}
+
+func TestConfirm_ReleasedBeforeHoldIsDone(t *testing.T) {
+	inner := &testButton{}
+	button := NewConfirmButton(New(newDefaultTestDevice()), inner, false, 0, time.Hour)
+
+	button.Pressed()
+	staleHoldCount := button.holdCount
+	button.Released()
+	button.holdDone(staleHoldCount)
+	assert.False(t, inner.pressed)
+
+	button.Pressed()
+	button.holdDone(staleHoldCount)
+	assert.False(t, inner.pressed, "the hold timer of the previous press must not activate the button")
+
+	button.holdDone(button.holdCount)
+	assert.True(t, inner.pressed)
+	button.Released()
+	assert.True(t, inner.released)
+}
@@ -73,3 +73,39 @@ func (p *testConnectionProvider) CreateConnection(_ string, config ConnectionCon
 		config: config,
 	}, nil
 }
+
+func TestConnectionManager_ReconnectsWhenTheConfigurationChanged(t *testing.T) {
+	provider := &testConnectionProvider{
+		name: "blah",
+		config: ConnectionConfig{
+			"type":        "test",
+			"some_config": "some_value",
+		},
+	}
+	manager := NewConnectionManager[*testConnection]("test", provider, provider.CreateConnection)
+	var closed []*testConnection
+	manager.SetCloser(func(connection *testConnection) {
+		closed = append(closed, connection)
+	})
+
+	first, err := manager.Get("blah")
+	assert.NoError(t, err)
+	provider.config = ConnectionConfig{
+		"type":        "test",
+		"some_config": "some_value",
+	}
+	unchanged, err := manager.Get("blah")
+	assert.NoError(t, err)
+	assert.Same(t, first, unchanged)
+	assert.Empty(t, closed)
+
+	provider.config = ConnectionConfig{
+		"type":        "test",
+		"some_config": "some_other_value",
+	}
+	changed, err := manager.Get("blah")
+	assert.NoError(t, err)
+	assert.NotSame(t, first, changed)
+	assert.Equal(t, "some_other_value", changed.config["some_config"])
+	assert.Equal(t, []*testConnection{first}, closed)
+}
@@ -0,0 +1,127 @@
+package hamdeck
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CommandTimeout is the time Do waits for the deck to take a command.
+const CommandTimeout = 5 * time.Second
+
+type command struct {
+	f      func() error
+	result chan error
+}
+
+// Do runs the given function in the goroutine that runs the deck and returns its error. All functions that were
+// queued before are run first. It is safe to call Do from any goroutine except the one that runs the deck.
+func (d *HamDeck) Do(f func() error) error {
+	command := command{
+		f:      f,
+		result: make(chan error, 1),
+	}
+	select {
+	case d.commands <- command:
+	case <-time.After(CommandTimeout):
+		return fmt.Errorf("the deck does not respond")
+	}
+	return <-command.result
+}
+
+// post queues the given function to run in the goroutine that runs the deck. post does not wait for the function,
+// so it is safe to call post from any goroutine, including the one that runs the deck.
+func (d *HamDeck) post(f func()) {
+	d.queueLock.Lock()
+	d.queue = append(d.queue, f)
+	d.queueLock.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runQueued runs the functions that were queued with post, in the order they were queued.
+func (d *HamDeck) runQueued() {
+	d.queueLock.Lock()
+	queue := d.queue
+	d.queue = nil
+	d.queueLock.Unlock()
+
+	for _, f := range queue {
+		f()
+	}
+}
+
+// PressKey presses and releases the key with the given index. PressKey must be called from the goroutine that
+// runs the deck, use Do from other goroutines.
+func (d *HamDeck) PressKey(index int) error {
+	if index < 0 || index >= len(d.buttons) {
+		return fmt.Errorf("no key with index %d, the device has %d keys", index, len(d.buttons))
+	}
+	d.handleKey(Key{Index: index, Pressed: true})
+	d.handleKey(Key{Index: index, Pressed: false})
+	return nil
+}
+
+// PageIDs returns the IDs of all pages in alphabetical order.
+func (d *HamDeck) PageIDs() []string {
+	d.pageLock.Lock()
+	result := make([]string, 0, len(d.pages))
+	for id := range d.pages {
+		result = append(result, id)
+	}
+	d.pageLock.Unlock()
+
+	sort.Strings(result)
+	return result
+}
+
+// Status describes the current state of the deck.
+type Status struct {
+	Device      string             `json:"device"`
+	Serial      string             `json:"serial"`
+	Page        string             `json:"page"`
+	Pages       []string           `json:"pages"`
+	Profile     string             `json:"profile,omitempty"`
+	Profiles    []string           `json:"profiles,omitempty"`
+	Connections []ConnectionStatus `json:"connections"`
+	NextEvents  []EventStatus      `json:"next_events,omitempty"`
+}
+
+type ConnectionStatus struct {
+	Name      string    `json:"name"`
+	Connected bool      `json:"connected"`
+	Since     time.Time `json:"since,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+type EventStatus struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+func (d *HamDeck) Status() Status {
+	result := Status{
+		Device:      d.device.ID(),
+		Serial:      d.device.Serial(),
+		Page:        d.CurrentPage(),
+		Pages:       d.PageIDs(),
+		Profile:     d.ActiveProfile(),
+		Profiles:    d.ProfileNames(),
+		Connections: make([]ConnectionStatus, 0),
+	}
+	for _, health := range d.health.All() {
+		result.Connections = append(result.Connections, ConnectionStatus{
+			Name:      health.Name,
+			Connected: health.Connected,
+			Since:     health.Since,
+			LastError: health.LastError,
+		})
+	}
+	for _, event := range d.NextEvents() {
+		result.NextEvents = append(result.NextEvents, EventStatus{Name: event.Name, Time: event.Time})
+	}
+	return result
+}
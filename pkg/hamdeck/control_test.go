@@ -0,0 +1,45 @@
+package hamdeck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDo_RunsInTheRunLoop(t *testing.T) {
+	deck := setupProfileTest(t, NewStore(""))
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		deck.Run(stop)
+	}()
+
+	err := deck.Do(func() error {
+		return deck.PressKey(1)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "contest", deck.ActiveProfile())
+
+	err = deck.Do(func() error {
+		return deck.PressKey(len(deck.buttons))
+	})
+	assert.Error(t, err)
+
+	close(stop)
+	<-done
+}
+
+func TestStatus(t *testing.T) {
+	deck, _ := setupTestDeck(t, `{
+		"start_page": "main",
+		"pages": {"main": {"buttons": []}, "contest": {"buttons": []}}
+	}`, nil)
+
+	status := deck.Status()
+
+	assert.Equal(t, "main", status.Page)
+	assert.Equal(t, []string{"contest", "main"}, status.Pages)
+	assert.Empty(t, status.Profile)
+	assert.Empty(t, status.Connections)
+}
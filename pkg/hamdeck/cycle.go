@@ -0,0 +1,202 @@
+package hamdeck
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"sync"
+)
+
+const (
+	ConfigStates     = "states"
+	ConfigValue      = "value"
+	ConfigForeground = "foreground"
+	ConfigBackground = "background"
+	ConfigAction     = "action"
+)
+
+/*
+	CycleButton
+*/
+
+// CycleState is one of the states of a CycleButton. Its action is any button, which is pressed
+// and released when the CycleButton switches to this state.
+type CycleState struct {
+	Label      string
+	Value      string
+	Foreground color.Color
+	Background color.Color
+	Action     Button
+}
+
+func NewCycleButton(label string, states []CycleState) *CycleButton {
+	result := &CycleButton{
+		lock:   new(sync.Mutex),
+		label:  label,
+		states: states,
+	}
+	result.longpress = NewLongpressHandler(result.OnLongpress)
+
+	return result
+}
+
+type CycleButton struct {
+	BaseButton
+	lock        *sync.Mutex
+	images      []image.Image
+	label       string
+	states      []CycleState
+	current     int
+	longpressed bool
+	longpress   *LongpressHandler
+
+	connection string
+	state      string
+
+	store ButtonStore
+}
+
+// Restore selects the state that was selected the last time. A button that is synced to a state value
+// follows the state value instead.
+func (b *CycleButton) Restore(store ButtonStore) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.store = store
+	if b.connection != "" {
+		return
+	}
+
+	var value string
+	if !store.Get(&value) {
+		return
+	}
+	for i, state := range b.states {
+		if state.Value == value {
+			b.current = i
+			break
+		}
+	}
+}
+
+// SyncTo lets the given state value of the given connection decide about the current state of the button.
+func (b *CycleButton) SyncTo(connection string, state string) {
+	b.connection = connection
+	b.state = state
+}
+
+func (b *CycleButton) StateChanged(connection string, name string, value string) {
+	if connection != b.connection || name != b.state {
+		return
+	}
+
+	b.lock.Lock()
+	lastState := b.current
+	for i, state := range b.states {
+		if strings.EqualFold(state.Value, value) {
+			b.current = i
+			break
+		}
+	}
+	changed := (b.current != lastState)
+	b.lock.Unlock()
+
+	if changed {
+		b.Invalidate(false)
+	}
+}
+
+func (b *CycleButton) Current() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.current
+}
+
+func (b *CycleButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	if b.images == nil || redrawImages {
+		b.redrawImages(gc)
+	}
+	return b.images[b.Current()]
+}
+
+func (b *CycleButton) redrawImages(gc GraphicContext) {
+	b.images = make([]image.Image, len(b.states))
+	for i, state := range b.states {
+		gc.SetForeground(state.Foreground)
+		gc.SetBackground(state.Background)
+		if b.label == "" {
+			b.images[i] = gc.DrawSingleLineTextButton(state.Label)
+		} else {
+			b.images[i] = gc.DrawDoubleLineToggleTextButton(b.label, state.Label, 2)
+		}
+	}
+}
+
+func (b *CycleButton) Pressed() {
+	b.lock.Lock()
+	b.longpressed = false
+	b.lock.Unlock()
+	b.longpress.Pressed()
+}
+
+func (b *CycleButton) Released() {
+	b.longpress.Released()
+
+	b.lock.Lock()
+	longpressed := b.longpressed
+	b.lock.Unlock()
+	if !longpressed {
+		b.switchBy(1)
+	}
+}
+
+func (b *CycleButton) OnLongpress() {
+	b.lock.Lock()
+	b.longpressed = true
+	b.lock.Unlock()
+	b.switchBy(-1)
+}
+
+func (b *CycleButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	for _, state := range b.states {
+		if state.Action != nil {
+			state.Action.Attached(ctx)
+		}
+	}
+}
+
+func (b *CycleButton) Detached() {
+	for _, state := range b.states {
+		if state.Action != nil {
+			state.Action.Detached()
+		}
+	}
+	b.BaseButton.Detached()
+}
+
+// Close closes the actions of all states.
+func (b *CycleButton) Close() {
+	for _, state := range b.states {
+		if state.Action != nil {
+			CloseButton(state.Action)
+		}
+	}
+}
+
+func (b *CycleButton) switchBy(delta int) {
+	b.lock.Lock()
+	b.current = (b.current + delta + len(b.states)) % len(b.states)
+	state := b.states[b.current]
+	store := b.store
+	b.lock.Unlock()
+
+	store.Set(state.Value)
+
+	b.Invalidate(false)
+
+	if state.Action == nil {
+		return
+	}
+	state.Action.Pressed()
+	state.Action.Released()
+}
@@ -0,0 +1,79 @@
+package hamdeck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cycleTestConfig = `{
+	"buttons": [
+		{
+			"type": "hamdeck.Cycle",
+			"index": 1,
+			"label": "Mode",
+			"connection": "rig",
+			"state": "mode",
+			"states": [
+				{ "label": "CW", "action": { "type": "test.Button" } },
+				{ "label": "SSB", "value": "USB", "foreground": "black", "background": "yellow", "action": { "type": "test.Button" } },
+				{ "label": "FT8", "value": "PKTUSB" }
+			]
+		}
+	]
+}`
+
+func TestCycle_PressSwitchesToNextState(t *testing.T) {
+	runWithConfigString(t, cycleTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		button, ok := deck.buttons[1].(*CycleButton)
+		require.True(t, ok)
+		require.Len(t, button.states, 3)
+		assert.Equal(t, 0, button.Current())
+		assert.Equal(t, "SSB", button.states[1].Action.(*testButton).config[ConfigLabel])
+
+		device.Press(1)
+		device.Release(1)
+		device.WaitForLastKey()
+		assert.Equal(t, 1, button.Current())
+		assert.True(t, button.states[1].Action.(*testButton).pressed)
+		assert.True(t, button.states[1].Action.(*testButton).released)
+		assert.False(t, button.states[0].Action.(*testButton).pressed)
+
+		device.Press(1)
+		device.Release(1)
+		device.Press(1)
+		device.Release(1)
+		device.WaitForLastKey()
+		assert.Equal(t, 0, button.Current())
+		assert.True(t, button.states[0].Action.(*testButton).pressed)
+	})
+}
+
+func TestCycle_StateSelectsState(t *testing.T) {
+	runWithConfigString(t, cycleTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		button, ok := deck.buttons[1].(*CycleButton)
+		require.True(t, ok)
+
+		deck.PublishState("rig", StateMode, "pktusb")
+		assert.Equal(t, 2, button.Current())
+
+		deck.PublishState("rig", StateMode, "USB")
+		assert.Equal(t, 1, button.Current())
+		assert.False(t, button.states[1].Action.(*testButton).pressed)
+
+		deck.PublishState("rig", StateMode, "AM")
+		assert.Equal(t, 1, button.Current())
+	})
+}
+
+func TestCycle_StateDoesNotChangeTheActionConfiguration(t *testing.T) {
+	deck, _ := setupTestDeck(t, `{}`, nil)
+	actionConfig := map[string]any{ConfigType: "test.Button"}
+
+	state, ok := NewButtonFactory(deck).createCycleState(map[string]any{ConfigLabel: "CW", ConfigAction: actionConfig})
+
+	require.True(t, ok)
+	assert.Equal(t, "CW", state.Action.(*testButton).config[ConfigLabel])
+	assert.NotContains(t, actionConfig, ConfigLabel)
+}
@@ -0,0 +1,341 @@
+package hamdeck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	ConfigCommand        = "command"
+	ConfigArgs           = "args"
+	ConfigEnv            = "env"
+	ConfigPassState      = "pass_state"
+	ConfigOutputLabel    = "output_label"
+	ConfigTimeout        = "timeout"
+	ConfigStatusCommand  = "status_command"
+	ConfigStatusArgs     = "status_args"
+	ConfigStatusInterval = "status_interval"
+)
+
+const (
+	DefaultStatusTimeout  = 30 * time.Second
+	DefaultStatusInterval = 5 * time.Second
+)
+
+// StateEnvPrefix is the prefix of the environment variables that contain the station state.
+const StateEnvPrefix = "HAMDECK_"
+
+// Command describes an external command that is executed by a button.
+type Command struct {
+	Name      string
+	Args      []string
+	Env       map[string]string
+	PassState bool
+	// Timeout kills the command when it runs longer, 0 lets the command run until it exits.
+	Timeout time.Duration
+}
+
+// String returns the command line of the command.
+func (c Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// StateEnv converts the given state value into an environment variable, e.g. HAMDECK_RIG_FREQUENCY=7050000.
+func StateEnv(connection string, name string, value string) string {
+	return fmt.Sprintf("%s%s_%s=%s", StateEnvPrefix, envName(connection), envName(name), value)
+}
+
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// processes keeps track of the running commands to kill them on shutdown.
+type processes struct {
+	lock    *sync.Mutex
+	running map[*exec.Cmd]struct{}
+}
+
+func newProcesses() *processes {
+	return &processes{
+		lock:    new(sync.Mutex),
+		running: make(map[*exec.Cmd]struct{}),
+	}
+}
+
+func (p *processes) add(cmd *exec.Cmd) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.running[cmd] = struct{}{}
+}
+
+func (p *processes) remove(cmd *exec.Cmd) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.running, cmd)
+}
+
+func (p *processes) KillAll() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for cmd := range p.running {
+		err := killProcessGroup(cmd)
+		if err != nil {
+			logger.Error("cannot kill process", "command", cmd.Path, "error", err)
+		}
+	}
+}
+
+// run executes the given command in its own process group and returns the first line of its output.
+func (p *processes) run(command Command, state *State) (string, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if command.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), command.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command.Name, command.Args...)
+	cmd.Env = os.Environ()
+	if command.PassState && state != nil {
+		state.ForEach(func(connection, name, value string) {
+			cmd.Env = append(cmd.Env, StateEnv(connection, name, value))
+		})
+	}
+	for name, value := range command.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", name, value))
+	}
+	output := new(bytes.Buffer)
+	cmd.Stdout = output
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		return "", err
+	}
+	p.add(cmd)
+	err = cmd.Wait()
+	p.remove(cmd)
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s timed out after %v", command.Name, command.Timeout)
+	}
+
+	return firstLine(output.Bytes()), err
+}
+
+func firstLine(output []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	if scanner.Scan() {
+		return strings.TrimSpace(scanner.Text())
+	}
+	return ""
+}
+
+/*
+	ExecButton
+*/
+
+type ExecResult int
+
+const (
+	ExecIdle ExecResult = iota
+	ExecRunning
+	ExecSuccess
+	ExecFailure
+)
+
+func NewExecButton(processes *processes, state *State, label string, command Command, outputLabel bool) *ExecButton {
+	if label == "" {
+		label = command.Name
+	}
+	return &ExecButton{
+		processes:   processes,
+		state:       state,
+		lock:        new(sync.Mutex),
+		label:       label,
+		command:     command,
+		outputLabel: outputLabel,
+	}
+}
+
+type ExecButton struct {
+	BaseButton
+	processes   *processes
+	state       *State
+	lock        *sync.Mutex
+	image       image.Image
+	label       string
+	command     Command
+	outputLabel bool
+
+	result ExecResult
+	output string
+
+	statusCommand  *Command
+	statusInterval time.Duration
+	statusColor    color.Color
+	stopStatus     chan struct{}
+}
+
+// PollStatus runs the given command periodically while the button is attached. The exit code of the status
+// command defines the color of the key: green on success, red on failure. If the first line of the output is a
+// color name or #rrggbb, this color is used instead.
+func (b *ExecButton) PollStatus(command Command, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultStatusInterval
+	}
+	b.statusCommand = &command
+	b.statusInterval = interval
+}
+
+func (b *ExecButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.image == nil || redrawImages {
+		b.redrawImage(gc)
+	}
+	return b.image
+}
+
+func (b *ExecButton) redrawImage(gc GraphicContext) {
+	label := b.label
+	if b.outputLabel && b.output != "" {
+		label = b.output
+	}
+
+	gc.SetForeground(White)
+	switch {
+	case b.result == ExecRunning:
+		gc.SetBackground(Orange)
+	case b.result == ExecFailure:
+		gc.SetBackground(Red)
+	case b.result == ExecSuccess && b.statusColor == nil:
+		gc.SetBackground(DarkGreen)
+	case b.statusColor != nil:
+		gc.SetBackground(b.statusColor)
+	default:
+		gc.SetBackground(Black)
+	}
+	b.image = gc.DrawSingleLineTextButton(label)
+}
+
+func (b *ExecButton) Pressed() {
+	if b.command.Name == "" {
+		if b.statusCommand != nil {
+			go b.updateStatus()
+		}
+		return
+	}
+
+	b.lock.Lock()
+	if b.result == ExecRunning {
+		b.lock.Unlock()
+		logger.Warn("command is still running", "command", b.command.Name)
+		return
+	}
+	b.result = ExecRunning
+	b.lock.Unlock()
+	b.Invalidate(true)
+
+	audit := b.StartCommand()
+	go func() {
+		output, err := b.processes.run(b.command, b.state)
+		audit(b.command.String(), err)
+
+		b.lock.Lock()
+		if err != nil {
+			logger.Error("command failed", "command", b.command.Name, "error", err)
+			b.result = ExecFailure
+		} else {
+			b.result = ExecSuccess
+		}
+		if output != "" {
+			b.output = output
+		}
+		b.lock.Unlock()
+		b.Invalidate(true)
+	}()
+}
+
+func (b *ExecButton) Released() {
+	// ignore
+}
+
+func (b *ExecButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	if b.statusCommand == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.stopStatus != nil {
+		return
+	}
+	b.stopStatus = make(chan struct{})
+	go b.pollStatus(b.stopStatus)
+}
+
+func (b *ExecButton) Detached() {
+	b.lock.Lock()
+	if b.stopStatus != nil {
+		close(b.stopStatus)
+		b.stopStatus = nil
+	}
+	b.lock.Unlock()
+	b.BaseButton.Detached()
+}
+
+func (b *ExecButton) pollStatus(stop <-chan struct{}) {
+	ticker := time.NewTicker(b.statusInterval)
+	defer ticker.Stop()
+	for {
+		b.updateStatus()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (b *ExecButton) updateStatus() {
+	output, err := b.processes.run(*b.statusCommand, b.state)
+	statusColor, ok := ToColor(output)
+	switch {
+	case ok:
+	case err != nil:
+		statusColor = Red
+	default:
+		statusColor = DarkGreen
+	}
+
+	b.lock.Lock()
+	b.statusColor = statusColor
+	if b.result != ExecRunning {
+		b.result = ExecIdle
+	}
+	if b.outputLabel && output != "" && !ok {
+		b.output = output
+	}
+	b.lock.Unlock()
+	b.Invalidate(true)
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package hamdeck
+
+import "os/exec"
+
+func setProcessGroup(*exec.Cmd) {
+	// not supported
+}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
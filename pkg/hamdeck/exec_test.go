@@ -0,0 +1,70 @@
+package hamdeck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcesses_Run(t *testing.T) {
+	state := NewState()
+	state.Publish("rig", StateFrequency, "7050000")
+	state.Publish("atu", "atu100/swr", "1.20")
+	processes := newProcesses()
+
+	output, err := processes.run(Command{
+		Name:      "sh",
+		Args:      []string{"-c", `echo "$HAMDECK_RIG_FREQUENCY $HAMDECK_ATU_ATU100_SWR $CALL"; echo second line`},
+		Env:       map[string]string{"CALL": "DL0ABC"},
+		PassState: true,
+	}, state)
+	require.NoError(t, err)
+	assert.Equal(t, "7050000 1.20 DL0ABC", output)
+}
+
+func TestProcesses_RunFailure(t *testing.T) {
+	processes := newProcesses()
+
+	_, err := processes.run(Command{Name: "sh", Args: []string{"-c", "exit 1"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestProcesses_RunTimeout(t *testing.T) {
+	processes := newProcesses()
+
+	start := time.Now()
+	_, err := processes.run(Command{Name: "sh", Args: []string{"-c", "sleep 10"}, Timeout: 100 * time.Millisecond}, nil)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestProcesses_RunWithoutTimeout(t *testing.T) {
+	processes := newProcesses()
+	defer processes.KillAll()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := processes.run(Command{Name: "sh", Args: []string{"-c", "sleep 10"}}, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.Fail(t, "a long-running command was stopped", "%v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestExecButton_OutputLabel(t *testing.T) {
+	button := NewExecButton(newProcesses(), NewState(), "Relay", Command{Name: "sh", Args: []string{"-c", "echo ON"}}, true)
+
+	button.Pressed()
+	button.Released()
+	assert.Eventually(t, func() bool {
+		button.lock.Lock()
+		defer button.lock.Unlock()
+		return button.result == ExecSuccess && button.output == "ON"
+	}, 5*time.Second, 10*time.Millisecond)
+}
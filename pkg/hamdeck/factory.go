@@ -1,6 +1,8 @@
 package hamdeck
 
-import "log"
+import (
+	"time"
+)
 
 const (
 	ConfigPage  = "page"
@@ -8,31 +10,59 @@ const (
 )
 
 const (
-	PageButtonType = "hamdeck.Page"
+	PageButtonType             = "hamdeck.Page"
+	CycleButtonType            = "hamdeck.Cycle"
+	InterlockButtonType        = "hamdeck.Interlock"
+	ExecButtonType             = "hamdeck.Exec"
+	ClockButtonType            = "hamdeck.Clock"
+	TimerButtonType            = "hamdeck.Timer"
+	ConnectionStatusButtonType = "hamdeck.ConnectionStatus"
 )
 
 type Factory struct {
+	deck         *HamDeck
 	pageSwitcher PageSwitcher
+	processes    *processes
 }
 
 type PageSwitcher interface {
 	AttachPage(string) error
 }
 
-func NewButtonFactory(pageSwitcher PageSwitcher) *Factory {
+func NewButtonFactory(deck *HamDeck) *Factory {
 	return &Factory{
-		pageSwitcher: pageSwitcher,
+		deck:         deck,
+		pageSwitcher: deck,
+		processes:    newProcesses(),
 	}
 }
 
 func (f *Factory) Close() {
-	// nop
+	f.processes.KillAll()
 }
 
 func (f *Factory) CreateButton(config map[string]any) Button {
 	switch config[ConfigType] {
 	case PageButtonType:
 		return f.createPageButton(config)
+	case CycleButtonType:
+		return f.createCycleButton(config)
+	case InterlockButtonType:
+		return f.createInterlockButton(config)
+	case ExecButtonType:
+		return f.createExecButton(config)
+	case ClockButtonType:
+		return f.createClockButton(config)
+	case TimerButtonType:
+		return f.createTimerButton(config)
+	case ConnectionStatusButtonType:
+		return f.createConnectionStatusButton(config)
+	case ProfileButtonType:
+		return f.createProfileButton(config)
+	case ProfileStatusButtonType:
+		return f.createProfileStatusButton(config)
+	case NextEventButtonType:
+		return f.createNextEventButton(config)
 	default:
 		return nil
 	}
@@ -42,10 +72,233 @@ func (f *Factory) createPageButton(config map[string]any) Button {
 	id, haveID := ToString(config[ConfigPage])
 	label, haveLabel := ToString(config[ConfigLabel])
 	if !haveID {
-		log.Print("A hamdeck.Page button must have a page field.")
+		logger.Error("A hamdeck.Page button must have a page field")
 	}
 	if !haveLabel {
-		log.Print("A hamdeck.Page button must have a label field.")
+		logger.Error("A hamdeck.Page button must have a label field")
 	}
 	return NewPageButton(f.pageSwitcher, id, label)
 }
+
+func (f *Factory) createCycleButton(config map[string]any) Button {
+	label, _ := ToString(config[ConfigLabel])
+	rawStates, haveStates := config[ConfigStates].([]any)
+	if !haveStates || len(rawStates) == 0 {
+		logger.Error("A hamdeck.Cycle button must have a states field")
+		return nil
+	}
+
+	states := make([]CycleState, 0, len(rawStates))
+	for i, rawState := range rawStates {
+		stateConfig, ok := rawState.(map[string]any)
+		if !ok {
+			logger.Error("state of a hamdeck.Cycle button is not a state object", "index", i)
+			return nil
+		}
+		state, ok := f.createCycleState(stateConfig)
+		if !ok {
+			logger.Error("state of a hamdeck.Cycle button must have a label field", "index", i)
+			return nil
+		}
+		states = append(states, state)
+	}
+
+	result := NewCycleButton(label, states)
+
+	connection, haveConnection := ToString(config[ConfigConnection])
+	stateName, haveStateName := ToString(config[ConfigState])
+	if haveConnection && haveStateName {
+		result.SyncTo(connection, stateName)
+		f.deck.ListenToState(result)
+		err := f.deck.RequestState(connection, stateName)
+		if err != nil {
+			logger.Error("Cannot sync hamdeck.Cycle button", "error", err)
+		}
+		value, ok := f.deck.GetState(connection, stateName)
+		if ok {
+			result.StateChanged(connection, stateName, value)
+		}
+	}
+
+	return result
+}
+
+func (f *Factory) createCycleState(config map[string]any) (CycleState, bool) {
+	label, haveLabel := ToString(config[ConfigLabel])
+	if !haveLabel {
+		return CycleState{}, false
+	}
+	value, haveValue := ToString(config[ConfigValue])
+	if !haveValue {
+		value = label
+	}
+	foreground, haveForeground := ToColor(config[ConfigForeground])
+	if !haveForeground {
+		foreground = White
+	}
+	background, haveBackground := ToColor(config[ConfigBackground])
+	if !haveBackground {
+		background = Black
+	}
+
+	var action Button
+	rawActionConfig, haveAction := config[ConfigAction].(map[string]any)
+	if haveAction {
+		actionConfig := make(map[string]any, len(rawActionConfig)+1)
+		for key, value := range rawActionConfig {
+			actionConfig[key] = value
+		}
+		if _, haveActionLabel := actionConfig[ConfigLabel]; !haveActionLabel {
+			actionConfig[ConfigLabel] = label
+		}
+		action = f.deck.createAction(actionConfig)
+		if action == nil {
+			logger.Error("Cannot create the action for state of a hamdeck.Cycle button", "state", label)
+		}
+	}
+
+	return CycleState{
+		Label:      label,
+		Value:      value,
+		Foreground: foreground,
+		Background: background,
+		Action:     action,
+	}, true
+}
+
+func (f *Factory) createInterlockButton(config map[string]any) Button {
+	label, _ := ToString(config[ConfigLabel])
+	return NewInterlockStatusButton(f.deck.interlock, label)
+}
+
+func (f *Factory) createExecButton(config map[string]any) Button {
+	label, _ := ToString(config[ConfigLabel])
+	command, haveCommand := ToString(config[ConfigCommand])
+	statusCommand, haveStatusCommand := ToString(config[ConfigStatusCommand])
+	if !(haveCommand || haveStatusCommand) {
+		logger.Error("A hamdeck.Exec button must have a command or a status_command field")
+		return nil
+	}
+	if label == "" && !haveCommand {
+		label = statusCommand
+	}
+	args, _ := ToStringArray(config[ConfigArgs])
+	statusArgs, _ := ToStringArray(config[ConfigStatusArgs])
+	passState, _ := ToBool(config[ConfigPassState])
+	outputLabel, _ := ToBool(config[ConfigOutputLabel])
+	timeout, _ := ToInt(config[ConfigTimeout])
+	statusInterval, _ := ToInt(config[ConfigStatusInterval])
+
+	env := make(map[string]string)
+	rawEnv, _ := config[ConfigEnv].(map[string]any)
+	for name, rawValue := range rawEnv {
+		value, ok := ToString(rawValue)
+		if !ok {
+			logger.Error("The value of an environment variable of a hamdeck.Exec button must be a string", "name", name)
+			continue
+		}
+		env[name] = value
+	}
+
+	result := NewExecButton(f.processes, f.deck.state, label, Command{
+		Name:      command,
+		Args:      args,
+		Env:       env,
+		PassState: passState,
+		Timeout:   time.Duration(timeout) * time.Millisecond,
+	}, outputLabel)
+	if haveStatusCommand {
+		statusTimeout := time.Duration(timeout) * time.Millisecond
+		if statusTimeout <= 0 {
+			statusTimeout = DefaultStatusTimeout
+		}
+		result.PollStatus(Command{
+			Name:      statusCommand,
+			Args:      statusArgs,
+			Env:       env,
+			PassState: passState,
+			Timeout:   statusTimeout,
+		}, time.Duration(statusInterval)*time.Millisecond)
+	}
+	return result
+}
+
+func (f *Factory) createClockButton(config map[string]any) Button {
+	utc, haveUTC := ToBool(config[ConfigUTC])
+	if !haveUTC {
+		utc = true
+	}
+	format, _ := ToString(config[ConfigFormat])
+	date, _ := ToBool(config[ConfigDate])
+	dateFormat, haveDateFormat := ToString(config[ConfigDateFormat])
+	if date && !haveDateFormat {
+		dateFormat = DefaultDateFormat
+	}
+	if !date {
+		dateFormat = ""
+	}
+
+	return NewClockButton(utc, format, dateFormat)
+}
+
+func (f *Factory) createTimerButton(config map[string]any) Button {
+	label, _ := ToString(config[ConfigLabel])
+	rawMode, _ := ToString(config[ConfigMode])
+	duration, _ := ToInt(config[ConfigDuration])
+
+	var mode TimerMode
+	if rawMode != "" {
+		var err error
+		mode, err = ParseTimerMode(rawMode)
+		if err != nil {
+			logger.Error("Cannot create hamdeck.Timer button", "error", err)
+			return nil
+		}
+	}
+
+	result := NewTimerButton(label, mode, time.Duration(duration)*time.Second)
+
+	pttConnection, ok := ToString(config[ConfigRestartOnPTT])
+	if ok {
+		result.RestartOnPTT(pttConnection)
+		f.deck.ListenToState(result)
+		err := f.deck.RequestState(pttConnection, StatePTT)
+		if err != nil {
+			logger.Error("Cannot restart hamdeck.Timer button on PTT", "error", err)
+		}
+	}
+
+	return result
+}
+
+func (f *Factory) createConnectionStatusButton(config map[string]any) Button {
+	connection, _ := ToString(config[ConfigConnection])
+	label, _ := ToString(config[ConfigLabel])
+	if connection != "" {
+		err := f.deck.RequestState(connection, StateConnected)
+		if err != nil {
+			logger.Error("Cannot show the status of connection", "connection", connection, "error", err)
+		}
+	}
+	return NewConnectionStatusButton(f.deck, connection, label)
+}
+
+func (f *Factory) createProfileButton(config map[string]any) Button {
+	profile, haveProfile := ToString(config[ConfigProfile])
+	label, _ := ToString(config[ConfigLabel])
+	if !haveProfile {
+		logger.Error("A hamdeck.Profile button must have a profile field")
+		return nil
+	}
+	return NewProfileButton(f.deck, profile, label)
+}
+
+func (f *Factory) createProfileStatusButton(config map[string]any) Button {
+	label, _ := ToString(config[ConfigLabel])
+	return NewProfileStatusButton(f.deck, label)
+}
+
+func (f *Factory) createNextEventButton(config map[string]any) Button {
+	schedule, _ := ToString(config[ConfigSchedule])
+	return NewNextEventButton(f.deck, schedule)
+}
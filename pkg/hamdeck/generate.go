@@ -0,0 +1,135 @@
+package hamdeck
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ConfigGenerate = "generate"
+	ConfigKind     = "kind"
+)
+
+// PageGenerator is implemented by button factories that generate the buttons of a page from their data, e.g. from
+// the bandplan or from the devices of an audio server.
+type PageGenerator interface {
+	// GenerateButtons returns the configurations of the buttons for the given generator configuration, or false if
+	// the generator is not supported by the factory. If the data changes later, the factory calls the changed
+	// function to regenerate the page; changed may be nil.
+	GenerateButtons(config map[string]any, changed func()) ([]map[string]any, bool)
+}
+
+// ChangeNotifier is implemented by page generators that keep the changed functions of the generated pages. The deck
+// calls ClearChanged before it loads another configuration, so the generator only notifies the pages of the current
+// configuration.
+type ChangeNotifier interface {
+	ClearChanged()
+}
+
+// clearChangeNotifiers drops the changed functions of the previous configuration.
+func (d *HamDeck) clearChangeNotifiers() {
+	for _, factory := range d.factories {
+		if notifier, ok := factory.(ChangeNotifier); ok {
+			notifier.ClearChanged()
+		}
+	}
+}
+
+// loadGeneratedPage creates a list page from the configured buttons, followed by the generated buttons.
+func (d *HamDeck) loadGeneratedPage(id string, configuration map[string]any, changed func()) (map[string]Page, error) {
+	generateConfiguration, ok := configuration[ConfigGenerate].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("page %s: %s must be an object", id, ConfigGenerate)
+	}
+	generatedButtons, err := d.generateButtons(generateConfiguration, changed)
+	if err != nil {
+		return nil, fmt.Errorf("page %s: %w", id, err)
+	}
+
+	buttonsConfiguration, _ := configuration[ConfigButtons].([]any)
+	buttons := make([]any, 0, len(buttonsConfiguration)+len(generatedButtons))
+	buttons = append(buttons, buttonsConfiguration...)
+	for _, button := range generatedButtons {
+		buttons = append(buttons, button)
+	}
+	return d.loadListPage(id, configuration, buttons)
+}
+
+func (d *HamDeck) generateButtons(configuration map[string]any, changed func()) ([]map[string]any, error) {
+	for _, factory := range d.factories {
+		generator, ok := factory.(PageGenerator)
+		if !ok {
+			continue
+		}
+		buttons, ok := generator.GenerateButtons(configuration, changed)
+		if ok {
+			return buttons, nil
+		}
+	}
+	kind, _ := ToString(configuration[ConfigKind])
+	connection, _ := ToString(configuration[ConfigConnection])
+	if connection != "" {
+		return nil, fmt.Errorf("no generator found for %s on connection %s", kind, connection)
+	}
+	return nil, fmt.Errorf("no generator found for %s", kind)
+}
+
+// pageChangedFunc returns the function that regenerates the given page as long as the configuration is not reloaded.
+// The function may be called from any goroutine, the page is regenerated in the goroutine that runs the deck.
+func (d *HamDeck) pageChangedFunc(id string, configuration map[string]any) func() {
+	d.pageLock.Lock()
+	generation := d.configGeneration
+	d.pageLock.Unlock()
+
+	return func() {
+		d.post(func() {
+			d.regeneratePage(id, configuration, generation)
+		})
+	}
+}
+
+// regeneratePage replaces the generated page and its continuation pages. If one of them is currently visible,
+// it is attached again. regeneratePage must be called from the goroutine that runs the deck.
+func (d *HamDeck) regeneratePage(id string, configuration map[string]any, generation int) {
+	d.pageLock.Lock()
+	stale := (generation != d.configGeneration)
+	d.pageLock.Unlock()
+	if stale {
+		return
+	}
+
+	pages, err := d.loadGeneratedPage(id, configuration, nil)
+	if err != nil {
+		logger.Error("cannot regenerate page", "page", id, "error", err)
+		return
+	}
+
+	replaced := make(map[string]Page)
+	d.pageLock.Lock()
+	for n := 0; ; n++ {
+		pageID := ContinuationPageID(id, n)
+		page, ok := d.pages[pageID]
+		if !ok {
+			break
+		}
+		replaced[pageID] = page
+		delete(d.pages, pageID)
+	}
+	for pageID, page := range pages {
+		d.pages[pageID] = page
+	}
+	currentPageID := d.currentPageID
+	d.pageLock.Unlock()
+	defer closePages(replaced)
+
+	if currentPageID != id && !strings.HasPrefix(currentPageID, id+".") {
+		return
+	}
+	if _, ok := pages[currentPageID]; !ok {
+		currentPageID = id
+	}
+	err = d.AttachPage(currentPageID)
+	if err != nil {
+		logger.Error("cannot attach regenerated page", "page", currentPageID, "error", err)
+	}
+}
@@ -0,0 +1,134 @@
+package hamdeck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testGeneratorFactory struct {
+	testButtonFactory
+	count   int
+	changed []func()
+}
+
+func (f *testGeneratorFactory) GenerateButtons(config map[string]any, changed func()) ([]map[string]any, bool) {
+	if config[ConfigKind] != "test" {
+		return nil, false
+	}
+	if changed != nil {
+		f.changed = append(f.changed, changed)
+	}
+	result := make([]map[string]any, f.count)
+	for i := range result {
+		result[i] = map[string]any{ConfigType: testButtonType, "label": fmt.Sprintf("g%d", i)}
+	}
+	return result, true
+}
+
+func (f *testGeneratorFactory) ClearChanged() {
+	f.changed = nil
+}
+
+func (f *testGeneratorFactory) notifyChanged() {
+	for _, changed := range f.changed {
+		changed()
+	}
+}
+
+const generateTestConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": { "buttons": [] },
+		"generated": {
+			"generate": { "kind": "test" },
+			"buttons": [ { "type": "test.Button", "label": "static" } ]
+		}
+	}
+}`
+
+func TestGeneratedPage(t *testing.T) {
+	deck := New(newTestDevice(80, 2, 3))
+	factory := &testGeneratorFactory{count: 3}
+	deck.RegisterFactory(factory)
+
+	require.NoError(t, deck.ReadConfig(strings.NewReader(generateTestConfig)))
+
+	assert.Equal(t, []string{"static", "g0", "g1", "g2", "", "Back:main"}, pageLabels(deck, "generated"))
+}
+
+func TestGeneratedPage_Regenerate(t *testing.T) {
+	deck := New(newTestDevice(80, 2, 3))
+	factory := &testGeneratorFactory{count: 6}
+	deck.RegisterFactory(factory)
+	require.NoError(t, deck.ReadConfig(strings.NewReader(generateTestConfig)))
+	assert.Equal(t, []string{"generated", "generated.2", "generated.3", "main"}, pageIDs(deck))
+	require.NoError(t, deck.AttachPage("generated.3"))
+	startDeck(t, deck)
+
+	factory.count = 2
+	factory.notifyChanged()
+	waitForQueue(t, deck)
+
+	assert.Equal(t, []string{"generated", "main"}, pageIDs(deck))
+	assert.Equal(t, "generated", deck.CurrentPage())
+	assert.Equal(t, []string{"static", "g0", "g1"}, pageLabels(deck, "generated")[:3])
+	assert.Same(t, deck.pages["generated"].buttons[1], deck.buttons[1])
+}
+
+func TestGeneratedPage_IgnoresChangesAfterReload(t *testing.T) {
+	deck := New(newTestDevice(80, 2, 3))
+	factory := &testGeneratorFactory{count: 1}
+	deck.RegisterFactory(factory)
+	require.NoError(t, deck.ReadConfig(strings.NewReader(generateTestConfig)))
+	staleChanged := factory.changed[0]
+	require.NoError(t, deck.ReadConfig(strings.NewReader(`{"pages": {"main": {"buttons": []}}}`)))
+	assert.Empty(t, factory.changed, "the changed functions of the previous configuration are dropped")
+	startDeck(t, deck)
+
+	staleChanged()
+	waitForQueue(t, deck)
+
+	assert.Equal(t, []string{"main"}, pageIDs(deck))
+}
+
+func TestGeneratedPage_RegenerateWhileKeysArePressed(t *testing.T) {
+	device := newTestDevice(80, 2, 3)
+	deck := New(device)
+	factory := &testGeneratorFactory{count: 4}
+	deck.RegisterFactory(factory)
+	deck.RegisterFactory(NewButtonFactory(deck))
+	require.NoError(t, deck.ReadConfig(strings.NewReader(generateTestConfig)))
+	require.NoError(t, deck.AttachPage("generated"))
+	changed := factory.changed[0]
+	startDeck(t, deck)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			changed()
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		device.Press(i % 5)
+		device.Release(i % 5)
+	}
+	<-done
+	waitForQueue(t, deck)
+
+	assert.Contains(t, pageIDs(deck), "generated")
+	assert.Equal(t, "generated", deck.CurrentPage())
+}
+
+func TestGeneratedPage_NoGenerator(t *testing.T) {
+	deck := New(newDefaultTestDevice())
+	deck.RegisterFactory(new(testButtonFactory))
+
+	err := deck.ReadConfig(strings.NewReader(`{"pages": {"bands": {"generate": {"kind": "bands", "connection": "rig"}}}}`))
+
+	assert.ErrorContains(t, err, "no generator found for bands on connection rig")
+}
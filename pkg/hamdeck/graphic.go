@@ -9,7 +9,6 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 	"io"
-	"log"
 	"math"
 	"os"
 
@@ -163,17 +162,23 @@ func (gc *GC) LoadIconAsset(name string) image.Image {
 	assetName := fmt.Sprintf("img/%s", name)
 	asset, err := bindata.Assets.Open(assetName)
 	if err != nil {
-		log.Fatalf("cannot open asset %s: %v", assetName, err)
+		fatalAssetError("cannot open asset", assetName, err)
 	}
 	defer asset.Close()
 
 	icon, err := gc.LoadIconFromReader(asset)
 	if err != nil {
-		log.Fatalf("cannot load asset %s: %v", assetName, err)
+		fatalAssetError("cannot load asset", assetName, err)
 	}
 	return icon
 }
 
+// fatalAssetError logs the error and exits. The assets are embedded into the binary, they cannot be missing or broken.
+func fatalAssetError(msg string, assetName string, err error) {
+	logger.Error(msg, "asset", assetName, "error", err)
+	os.Exit(1)
+}
+
 func (gc *GC) LoadFontFaceFromReader(r io.Reader, points float64) (font.Face, error) {
 	fontBytes, err := io.ReadAll(r)
 	if err != nil {
@@ -193,13 +198,13 @@ func (gc *GC) LoadFontAsset(name string, points float64) font.Face {
 	assetName := fmt.Sprintf("fonts/%s", name)
 	asset, err := bindata.Assets.Open(assetName)
 	if err != nil {
-		log.Fatalf("cannot open asset %s: %v", assetName, err)
+		fatalAssetError("cannot open asset", assetName, err)
 	}
 	defer asset.Close()
 
 	face, err := gc.LoadFontFaceFromReader(asset, points)
 	if err != nil {
-		log.Fatalf("cannot load asset %s: %v", assetName, err)
+		fatalAssetError("cannot load asset", assetName, err)
 	}
 	return face
 }
@@ -253,3 +258,22 @@ func (gc *GC) DrawIconLabelButton(icon image.Image, label string) image.Image {
 
 	return result
 }
+
+func (gc *GC) DrawFrame(button image.Image) image.Image {
+	result, ctx := gc.newImage()
+
+	if button != nil {
+		ctx.DrawImage(button, 0, 0)
+	} else {
+		ctx.SetColor(gc.background)
+		ctx.Clear()
+	}
+
+	lineWidth := float64(gc.pixels) / 16
+	ctx.SetColor(gc.foreground)
+	ctx.SetLineWidth(lineWidth)
+	ctx.DrawRectangle(lineWidth/2, lineWidth/2, float64(gc.pixels)-lineWidth, float64(gc.pixels)-lineWidth)
+	ctx.Stroke()
+
+	return result
+}
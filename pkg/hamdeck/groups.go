@@ -0,0 +1,228 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+)
+
+const (
+	ConfigGroups     = "groups"
+	ConfigGroup      = "group"
+	ConfigGroupValue = "group_value"
+)
+
+/*
+	ButtonGroup
+*/
+
+// A ButtonGroup gives its members radio button semantics: only one member is active at any time.
+// The active member is either selected by pressing it, or by the value of an optional state source.
+type ButtonGroup struct {
+	name    string
+	lock    *sync.Mutex
+	members []*GroupButton
+	active  *GroupButton
+
+	hasSource  bool
+	connection string
+	state      string
+}
+
+func NewButtonGroup(name string) *ButtonGroup {
+	return &ButtonGroup{
+		name: name,
+		lock: new(sync.Mutex),
+	}
+}
+
+func (g *ButtonGroup) SetSource(connection string, state string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.hasSource = true
+	g.connection = connection
+	g.state = state
+}
+
+func (g *ButtonGroup) Join(button Button, value string) *GroupButton {
+	result := &GroupButton{
+		button: button,
+		group:  g,
+		value:  value,
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.members = append(g.members, result)
+
+	return result
+}
+
+func (g *ButtonGroup) Activate(member *GroupButton) {
+	g.lock.Lock()
+	lastActive := g.active
+	g.active = member
+	g.lock.Unlock()
+
+	if lastActive == member {
+		return
+	}
+	if lastActive != nil {
+		lastActive.Invalidate(false)
+	}
+	if member != nil {
+		member.Invalidate(false)
+	}
+}
+
+func (g *ButtonGroup) IsActive(member *GroupButton) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.active == member
+}
+
+func (g *ButtonGroup) StateChanged(connection string, name string, value string) {
+	g.lock.Lock()
+	if !g.hasSource || g.connection != connection || g.state != name {
+		g.lock.Unlock()
+		return
+	}
+	var member *GroupButton
+	for _, m := range g.members {
+		if m.value != "" && strings.EqualFold(m.value, value) {
+			member = m
+			break
+		}
+	}
+	g.lock.Unlock()
+
+	g.Activate(member)
+}
+
+type buttonGroups struct {
+	lock   *sync.Mutex
+	groups map[string]*ButtonGroup
+}
+
+func newButtonGroups() *buttonGroups {
+	return &buttonGroups{
+		lock:   new(sync.Mutex),
+		groups: make(map[string]*ButtonGroup),
+	}
+}
+
+func (g *buttonGroups) Clear() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.groups = make(map[string]*ButtonGroup)
+}
+
+func (g *buttonGroups) Get(name string) *ButtonGroup {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	group, ok := g.groups[name]
+	if !ok {
+		group = NewButtonGroup(name)
+		g.groups[name] = group
+	}
+	return group
+}
+
+func (g *buttonGroups) StateChanged(connection string, name string, value string) {
+	g.lock.Lock()
+	groups := make([]*ButtonGroup, 0, len(g.groups))
+	for _, group := range g.groups {
+		groups = append(groups, group)
+	}
+	g.lock.Unlock()
+
+	for _, group := range groups {
+		group.StateChanged(connection, name, value)
+	}
+}
+
+func (d *HamDeck) loadGroups(configuration map[string]any) error {
+	for name, rawGroup := range configuration {
+		groupConfiguration, ok := rawGroup.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s is not a valid group", name)
+		}
+		connection, haveConnection := ToString(groupConfiguration[ConfigConnection])
+		state, haveState := ToString(groupConfiguration[ConfigState])
+		if !(haveConnection && haveState) {
+			return fmt.Errorf("group %s must have connection and state fields", name)
+		}
+
+		group := d.groups.Get(name)
+		group.SetSource(connection, state)
+		err := d.RequestState(connection, state)
+		if err != nil {
+			logger.Error("invalid group", "group", name, "error", err)
+		}
+		value, ok := d.GetState(connection, state)
+		if ok {
+			group.StateChanged(connection, state, value)
+		}
+	}
+	return nil
+}
+
+/*
+	GroupButton
+*/
+
+// GroupButton wraps a member of a ButtonGroup and marks the active member with a frame.
+type GroupButton struct {
+	BaseButton
+	button Button
+	group  *ButtonGroup
+	value  string
+
+	buttonImage image.Image
+	image       image.Image
+}
+
+func (b *GroupButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	buttonImage := b.button.Image(gc, redrawImages)
+	if !b.group.IsActive(b) {
+		return buttonImage
+	}
+	if b.image == nil || redrawImages || buttonImage != b.buttonImage {
+		gc.Reset()
+		gc.SetForeground(Yellow)
+		b.image = gc.DrawFrame(buttonImage)
+		b.buttonImage = buttonImage
+	}
+	return b.image
+}
+
+func (b *GroupButton) Pressed() {
+	b.button.Pressed()
+	b.group.Activate(b)
+}
+
+func (b *GroupButton) Released() {
+	b.button.Released()
+}
+
+func (b *GroupButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.button.Attached(ctx)
+}
+
+func (b *GroupButton) Detached() {
+	b.button.Detached()
+	b.BaseButton.Detached()
+}
+
+func (b *GroupButton) Close() {
+	CloseButton(b.button)
+}
+
+func (b *GroupButton) Flash(on bool) {
+	flashingButton, ok := b.button.(FlashingButton)
+	if ok {
+		flashingButton.Flash(on)
+	}
+}
@@ -0,0 +1,63 @@
+package hamdeck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const groupsTestConfig = `{
+	"groups": {
+		"bands": { "connection": "rig", "state": "band" }
+	},
+	"buttons": [
+		{ "type": "test.Button", "index": 0, "group": "bands", "group_value": "40m" },
+		{ "type": "test.Button", "index": 1, "group": "bands", "group_value": "20m" },
+		{ "type": "test.Button", "index": 2, "group": "antennas" },
+		{ "type": "test.Button", "index": 3, "group": "antennas" }
+	]
+}`
+
+func TestGroups_PressActivatesMember(t *testing.T) {
+	runWithConfigString(t, groupsTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		antenna1, ok := deck.buttons[2].(*GroupButton)
+		require.True(t, ok)
+		antenna2, ok := deck.buttons[3].(*GroupButton)
+		require.True(t, ok)
+		assert.False(t, antenna1.group.IsActive(antenna1))
+		assert.False(t, antenna2.group.IsActive(antenna2))
+
+		device.Press(2)
+		device.WaitForLastKey()
+		assert.True(t, antenna1.button.(*testButton).pressed)
+		assert.True(t, antenna1.group.IsActive(antenna1))
+		assert.False(t, antenna2.group.IsActive(antenna2))
+
+		device.Press(3)
+		device.WaitForLastKey()
+		assert.False(t, antenna1.group.IsActive(antenna1))
+		assert.True(t, antenna2.group.IsActive(antenna2))
+	})
+}
+
+func TestGroups_StateSourceActivatesMember(t *testing.T) {
+	runWithConfigString(t, groupsTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		band40, ok := deck.buttons[0].(*GroupButton)
+		require.True(t, ok)
+		band20, ok := deck.buttons[1].(*GroupButton)
+		require.True(t, ok)
+
+		deck.PublishState("rig", StateBand, "20m")
+		assert.False(t, band40.group.IsActive(band40))
+		assert.True(t, band20.group.IsActive(band20))
+
+		deck.PublishState("rig", StateBand, "40m")
+		assert.True(t, band40.group.IsActive(band40))
+		assert.False(t, band20.group.IsActive(band20))
+
+		deck.PublishState("rig", StateBand, "80m")
+		assert.False(t, band40.group.IsActive(band40))
+		assert.False(t, band20.group.IsActive(band20))
+	})
+}
@@ -1,15 +1,22 @@
 package hamdeck
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"io"
-	"log"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/ftl/hamdeck/pkg/logging"
+	"github.com/ftl/hamdeck/pkg/metrics"
 )
 
+var logger = logging.For("hamdeck")
+
 type Key struct {
 	Index   int
 	Pressed bool
@@ -46,6 +53,7 @@ type GraphicContext interface {
 	LoadIconAsset(name string) image.Image
 	DrawIconButton(icon image.Image) image.Image
 	DrawIconLabelButton(icon image.Image, label string) image.Image
+	DrawFrame(button image.Image) image.Image
 }
 
 type ButtonContext interface {
@@ -60,6 +68,20 @@ type Button interface {
 	Detached()
 }
 
+// ClosableButton is implemented by buttons that hold resources beyond their attachment, e.g. a listener on their
+// connection. The buttons of a configuration are closed when the configuration is replaced.
+type ClosableButton interface {
+	Close()
+}
+
+// CloseButton closes the given button if it is a ClosableButton.
+func CloseButton(button Button) {
+	closable, ok := button.(ClosableButton)
+	if ok {
+		closable.Close()
+	}
+}
+
 type FlashingButton interface {
 	Flash(on bool)
 }
@@ -79,6 +101,69 @@ func NotifyEnablers(listeners []interface{}, enabled bool) {
 	}
 }
 
+// Listeners is a list of listeners that may be changed while the listeners are notified.
+type Listeners struct {
+	lock      *sync.Mutex
+	listeners []interface{}
+}
+
+func NewListeners() *Listeners {
+	return &Listeners{
+		lock: new(sync.Mutex),
+	}
+}
+
+func (l *Listeners) Add(listener interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.listeners = append(l.listeners, listener)
+}
+
+// Remove removes all occurrences of the given listener.
+func (l *Listeners) Remove(listener interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	result := make([]interface{}, 0, len(l.listeners))
+	for _, existing := range l.listeners {
+		if !sameListener(existing, listener) {
+			result = append(result, existing)
+		}
+	}
+	l.listeners = result
+}
+
+// All returns the current listeners. The result is not changed by later calls of Add or Remove.
+func (l *Listeners) All() []interface{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.listeners[:len(l.listeners):len(l.listeners)]
+}
+
+func sameListener(a interface{}, b interface{}) bool {
+	typeA := reflect.TypeOf(a)
+	if typeA != reflect.TypeOf(b) || typeA == nil || !typeA.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// ErrorListener is notified when a connection cannot be established or gets lost.
+type ErrorListener interface {
+	ConnectionFailed(err error)
+}
+
+func NotifyErrorListeners(listeners []interface{}, err error) {
+	for _, listener := range listeners {
+		errorListener, ok := listener.(ErrorListener)
+		if ok {
+			errorListener.ConnectionFailed(err)
+		}
+	}
+}
+
+// ErrConnectionLost is reported to the ErrorListeners when an established connection gets lost.
+var ErrConnectionLost = errors.New("connection lost")
+
 type ButtonFactory interface {
 	Close()
 	CreateButton(config map[string]interface{}) Button
@@ -98,28 +183,107 @@ type HamDeck struct {
 	buttons           []Button
 	noButton          Button
 	flashOn           bool
+	now               func() time.Time
+	overlay           []image.Image
+	overlayKind       overlayKind
+	splashImage       image.Image
+	splashSince       time.Time
+	screensaver       screensaver
+	lastActivity      time.Time
+	wakeKey           int
 	factories         []ButtonFactory
 	buttonsPerFactory []int
 
-	startPageID string
-	pages       map[string]Page
+	startPageID      string
+	pages            map[string]Page
+	pageLock         *sync.Mutex
+	configGeneration int
+	currentPageID    string
+
+	profiles       Profiles
+	configProfiles Profiles
+	profileLock    *sync.Mutex
+	activeProfile  string
+	commands       chan command
+	queueLock      *sync.Mutex
+	queue          []func()
+	wake           chan struct{}
 
 	connections map[connectionKey]ConnectionConfig
+	state       *State
+	rules       *ruleEngine
+	schedules   *scheduler
+	groups      *buttonGroups
+	listeners   *stateListeners
+	interlock   *Interlock
+	health      *healthMonitor
+	store       *Store
+
+	audit       *AuditLog
+	infoLock    *sync.Mutex
+	infoPageID  string
+	buttonInfos []buttonInfo
 }
 
 type Page struct {
 	buttons []Button
+	infos   []buttonInfo
+}
+
+// setPageButton puts a generated button that switches to another page on the given key.
+func (p Page) setPageButton(index int, button *PageButton) {
+	p.buttons[index] = button
+	p.infos[index] = buttonInfo{buttonType: PageButtonType}
+}
+
+// buttonInfo describes a button in the audit log and in the metrics.
+type buttonInfo struct {
+	buttonType string
+	connection string
+}
+
+func newButtonInfo(config map[string]any) buttonInfo {
+	buttonType, _ := ToString(config[ConfigType])
+	connection, _ := ToString(config[ConfigConnection])
+	connectionType, _, _ := strings.Cut(buttonType, ".")
+	return buttonInfo{
+		buttonType: buttonType,
+		connection: StateConnectionName(connection, connectionType),
+	}
 }
 
 func New(device Device) *HamDeck {
 	buttonCount := device.Columns() * device.Rows()
 	result := &HamDeck{
-		device:   device,
-		drawLock: new(sync.Mutex),
-		gc:       NewGraphicContext(device.Pixels()),
-		buttons:  make([]Button, buttonCount),
-		pages:    make(map[string]Page),
+		device:    device,
+		drawLock:  new(sync.Mutex),
+		gc:        NewGraphicContext(device.Pixels()),
+		buttons:   make([]Button, buttonCount),
+		pages:     make(map[string]Page),
+		pageLock:  new(sync.Mutex),
+		state:     NewState(),
+		groups:    newButtonGroups(),
+		listeners: newStateListeners(),
+		health:    newHealthMonitor(),
+		store:     NewStore(""),
+		infoLock:  new(sync.Mutex),
+		now:       time.Now,
+		wakeKey:   -1,
+
+		profileLock: new(sync.Mutex),
+		commands:    make(chan command),
+		queueLock:   new(sync.Mutex),
+		wake:        make(chan struct{}, 1),
 	}
+	result.rules = newRuleEngine(result)
+	result.schedules = newScheduler(result)
+	result.interlock = newInterlock(result)
+	result.state.Listen(result.rules)
+	result.state.Listen(result.groups)
+	result.state.Listen(result.listeners)
+	result.state.Listen(result.interlock)
+	result.state.Listen(result.health)
+	result.lastActivity = result.now()
 	result.noButton = &noButton{image: result.gc.DrawNoButton()}
 	for i := range result.buttons {
 		result.buttons[i] = result.noButton
@@ -140,13 +304,40 @@ func (d *HamDeck) GetConnection(name string, connectionType string) (ConnectionC
 	return connection, found
 }
 
+func (d *HamDeck) PublishState(connection string, name string, value string) {
+	d.state.Publish(connection, name, value)
+}
+
+func (d *HamDeck) GetState(connection string, name string) (string, bool) {
+	return d.state.Get(connection, name)
+}
+
+// ListenToState registers a listener for state changes. The listener is dropped when the configuration is reloaded.
+func (d *HamDeck) ListenToState(listener StateListener) {
+	d.listeners.Add(listener)
+}
+
+// RequestState asks the button factories to provide the given state value of the given connection.
+func (d *HamDeck) RequestState(connection string, name string) error {
+	for i, factory := range d.factories {
+		source, ok := factory.(StateSource)
+		if !ok {
+			continue
+		}
+		if source.RequestState(connection, name) {
+			d.buttonsPerFactory[i] += 1
+			return nil
+		}
+	}
+	return fmt.Errorf("no state %s available for connection %s", name, connection)
+}
+
 func (d *HamDeck) RedrawAll(redrawImages bool) {
 	d.drawLock.Lock()
 	defer d.drawLock.Unlock()
 
-	for i, b := range d.buttons {
-		d.gc.Reset()
-		d.device.SetImage(i, b.Image(d.gc, redrawImages))
+	for i := range d.buttons {
+		d.redraw(i, redrawImages)
 	}
 }
 
@@ -154,11 +345,27 @@ func (d *HamDeck) Redraw(index int, redrawImages bool) {
 	d.drawLock.Lock()
 	defer d.drawLock.Unlock()
 
+	d.redraw(index, redrawImages)
+}
+
+// redraw draws the image of the button with the given index. The drawLock must be held.
+func (d *HamDeck) redraw(index int, redrawImages bool) {
+	if d.overlay != nil {
+		d.device.SetImage(index, d.overlay[index])
+		return
+	}
+	start := time.Now()
 	d.gc.Reset()
-	d.device.SetImage(index, d.buttons[index].Image(d.gc, redrawImages))
+	buttonImage := d.buttons[index].Image(d.gc, redrawImages)
+	metrics.Redraw(time.Since(start))
+
+	d.device.SetImage(index, buttonImage)
 }
 
 func (d *HamDeck) AttachPage(id string) error {
+	d.pageLock.Lock()
+	defer d.pageLock.Unlock()
+
 	page, ok := d.pages[id]
 	if !ok {
 		return fmt.Errorf("no page defined with name %s", id)
@@ -167,10 +374,37 @@ func (d *HamDeck) AttachPage(id string) error {
 	for i, button := range page.buttons {
 		d.Attach(i, button)
 	}
+	d.currentPageID = id
+	d.store.Set(storeKeyPage, id)
+	d.setButtonInfos(id, page.infos)
+	d.auditPage(id)
 
 	return nil
 }
 
+func (d *HamDeck) setButtonInfos(pageID string, infos []buttonInfo) {
+	d.infoLock.Lock()
+	defer d.infoLock.Unlock()
+	d.infoPageID = pageID
+	d.buttonInfos = infos
+}
+
+// buttonInfo returns the current page and the description of the button with the given index.
+func (d *HamDeck) buttonInfo(index int) (string, buttonInfo) {
+	d.infoLock.Lock()
+	defer d.infoLock.Unlock()
+	if index < 0 || index >= len(d.buttonInfos) {
+		return d.infoPageID, buttonInfo{}
+	}
+	return d.infoPageID, d.buttonInfos[index]
+}
+
+func (d *HamDeck) CurrentPage() string {
+	d.pageLock.Lock()
+	defer d.pageLock.Unlock()
+	return d.currentPageID
+}
+
 func (d *HamDeck) Attach(index int, button Button) {
 	if d.buttons[index] != d.noButton {
 		d.buttons[index].Detached()
@@ -193,6 +427,31 @@ func (d *HamDeck) Detach(index int) {
 	d.Redraw(index, true)
 }
 
+// releasePages detaches all keys and closes the buttons of all pages before the pages are replaced by another
+// configuration.
+func (d *HamDeck) releasePages() {
+	for i := range d.buttons {
+		if d.buttons[i] != d.noButton {
+			d.Detach(i)
+		}
+	}
+
+	d.pageLock.Lock()
+	pages := d.pages
+	d.pageLock.Unlock()
+	closePages(pages)
+}
+
+func closePages(pages map[string]Page) {
+	for _, page := range pages {
+		for _, button := range page.buttons {
+			if button != nil {
+				CloseButton(button)
+			}
+		}
+	}
+}
+
 func (d *HamDeck) Run(stop <-chan struct{}) error {
 	keys, err := d.device.ReadKeys()
 	if err != nil {
@@ -207,12 +466,20 @@ MainLoop:
 		select {
 		case key, ok := <-keys:
 			if !ok {
-				log.Print("The Stream Deck device closed the connection.")
+				logger.Warn("The Stream Deck device closed the connection")
 				return nil
 			}
 			d.handleKey(key)
 		case <-flashTicker.C:
 			d.flash()
+			d.updateOverlay()
+		case <-d.schedules.C():
+			d.schedules.Trigger()
+		case <-d.wake:
+			d.runQueued()
+		case command := <-d.commands:
+			d.runQueued()
+			command.result <- command.f()
 		case <-stop:
 			break MainLoop
 		}
@@ -230,8 +497,20 @@ func (d *HamDeck) handleKey(key Key) {
 		return
 	}
 	button := d.buttons[key.Index]
+	d.auditKey(key)
+
+	if key.Pressed && d.wakeUp() {
+		d.wakeKey = key.Index
+		return
+	}
+	if !key.Pressed && key.Index == d.wakeKey {
+		d.wakeKey = -1
+		return
+	}
 
 	if key.Pressed {
+		pageID, info := d.buttonInfo(key.Index)
+		metrics.KeyPressed(pageID, info.buttonType)
 		button.Pressed()
 	} else {
 		button.Released()
@@ -327,7 +606,9 @@ type ConnectionManager[T any] struct {
 	connectionType   string
 	provider         ConnectionConfigProvider
 	factory          ConnectionFactory[T]
+	closer           func(T)
 	connections      map[string]T
+	configs          map[string]ConnectionConfig
 	hasLegacy        bool
 	legacyConnection T
 }
@@ -338,9 +619,15 @@ func NewConnectionManager[T any](connectionType string, provider ConnectionConfi
 		provider:       provider,
 		factory:        factory,
 		connections:    make(map[string]T),
+		configs:        make(map[string]ConnectionConfig),
 	}
 }
 
+// SetCloser sets the function that closes a connection when it is replaced because its configuration changed.
+func (m *ConnectionManager[T]) SetCloser(closer func(T)) {
+	m.closer = closer
+}
+
 func (m *ConnectionManager[T]) SetLegacy(legacyConnection T) {
 	m.hasLegacy = true
 	m.legacyConnection = legacyConnection
@@ -356,14 +643,20 @@ func (m *ConnectionManager[T]) Get(name string) (T, error) {
 	}
 
 	connection, ok := m.connections[name]
-	if ok {
+	config, defined := m.provider.GetConnection(name, m.connectionType)
+	if ok && (!defined || reflect.DeepEqual(config, m.configs[name])) {
 		return connection, nil
 	}
-
-	config, ok := m.provider.GetConnection(name, m.connectionType)
-	if !ok {
+	if !defined {
 		return connection, fmt.Errorf("no %s connection defined with name %s", m.connectionType, name)
 	}
+	if ok {
+		logger.Info("the configuration of the connection changed, reconnecting", "connection", name, "type", m.connectionType)
+		delete(m.connections, name)
+		if m.closer != nil {
+			m.closer(connection)
+		}
+	}
 
 	connection, err := m.factory(name, config)
 	if err != nil {
@@ -371,10 +664,21 @@ func (m *ConnectionManager[T]) Get(name string) (T, error) {
 	}
 
 	m.connections[name] = connection
+	m.configs[name] = config
 
 	return connection, nil
 }
 
+// GetByStateName returns the connection with the given name, as it is used to publish the connection's state.
+func (m *ConnectionManager[T]) GetByStateName(name string) (T, error) {
+	if name == m.connectionType {
+		if _, defined := m.provider.GetConnection(name, m.connectionType); !defined {
+			name = LegacyConnectionName
+		}
+	}
+	return m.Get(name)
+}
+
 func (m *ConnectionManager[T]) ForEach(f func(T)) {
 	for _, connection := range m.connections {
 		f(connection)
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 
@@ -97,6 +98,27 @@ func runWithConfigString(t *testing.T, config string, f func(*testing.T, *HamDec
 	wg.Wait()
 }
 
+// startDeck runs the deck until the test is finished.
+func startDeck(t *testing.T, deck *HamDeck) {
+	t.Helper()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		deck.Run(stop)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+}
+
+// waitForQueue waits until the deck ran all functions that were queued before, e.g. the page switches of rules.
+func waitForQueue(t *testing.T, deck *HamDeck) {
+	t.Helper()
+	require.NoError(t, deck.Do(func() error { return nil }))
+}
+
 func openTestConfigFile(filename string) (io.ReadCloser, error) {
 	return os.Open(filepath.Join("testdata", filename+".json"))
 }
@@ -115,6 +137,22 @@ type testDevice struct {
 	keys            chan Key
 }
 
+// setupTestDeck creates a HamDeck on the default test device and reads the given configuration. The setup function is
+// called before the configuration is read, e.g. to set the store or to register additional factories. The test
+// buttons and the buttons of the hamdeck package are always available.
+func setupTestDeck(t *testing.T, config string, setup func(deck *HamDeck)) (*HamDeck, *testDevice) {
+	t.Helper()
+	device := newDefaultTestDevice()
+	deck := New(device)
+	if setup != nil {
+		setup(deck)
+	}
+	deck.RegisterFactory(new(testButtonFactory))
+	deck.RegisterFactory(NewButtonFactory(deck))
+	require.NoError(t, deck.ReadConfig(strings.NewReader(config)))
+	return deck, device
+}
+
 func newDefaultTestDevice() *testDevice {
 	return newTestDevice(128, 4, 8)
 }
@@ -183,15 +221,34 @@ func (f *testButtonFactory) createTestButton(config map[string]any) *testButton
 
 type testButton struct {
 	config map[string]any
+	ctx    ButtonContext
 
 	pressed  bool
 	released bool
 	attached bool
 	detached bool
+	closed   bool
 }
 
 func (b *testButton) Image(GraphicContext, bool) image.Image { return nil }
 func (b *testButton) Pressed()                               { b.pressed = true }
 func (b *testButton) Released()                              { b.released = true }
-func (b *testButton) Attached(ButtonContext)                 { b.attached = true }
+func (b *testButton) Attached(ctx ButtonContext)             { b.ctx, b.attached = ctx, true }
 func (b *testButton) Detached()                              { b.detached = true }
+func (b *testButton) Close()                                 { b.closed = true }
+
+func TestListeners_Remove(t *testing.T) {
+	listeners := NewListeners()
+	button1 := &testButton{}
+	button2 := &testButton{}
+	listeners.Add(button1)
+	listeners.Add(StateListenerFunc(func(string, string, string) {}))
+	listeners.Add(button2)
+	snapshot := listeners.All()
+
+	listeners.Remove(button1)
+
+	require.Len(t, listeners.All(), 2)
+	assert.Same(t, button2, listeners.All()[1])
+	assert.Len(t, snapshot, 3, "the listeners that are notified are not changed")
+}
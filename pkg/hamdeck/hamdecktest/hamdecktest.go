@@ -0,0 +1,200 @@
+// Package hamdecktest turns recorded sessions into regression tests: it plays back a recording of key events on a
+// HamDeck and asserts on the resulting images and on the commands the buttons sent.
+package hamdecktest
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertFrames write the golden file instead of comparing
+// against it, e.g. HAMDECK_UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "HAMDECK_UPDATE_GOLDEN"
+
+// Result is the outcome of a replayed recording.
+type Result struct {
+	Deck   *hamdeck.HamDeck
+	Device *hamdeck.ReplayDevice
+	Audit  []hamdeck.AuditEntry
+}
+
+// Replay plays back the given recording file on a HamDeck with the given configuration. The setup function is
+// called before the configuration is read, it registers the button factories. Replay returns when all keys were
+// handled.
+func Replay(t *testing.T, recordingFilename string, config io.Reader, setup func(deck *hamdeck.HamDeck)) *Result {
+	t.Helper()
+	recording, err := hamdeck.LoadRecording(recordingFilename)
+	require.NoError(t, err)
+	return ReplayRecording(t, recording, config, setup)
+}
+
+// ReplayRecording plays back the given recording on a HamDeck with the given configuration.
+func ReplayRecording(t *testing.T, recording *hamdeck.Recording, config io.Reader, setup func(deck *hamdeck.HamDeck)) *Result {
+	t.Helper()
+	auditFilename := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := hamdeck.OpenAuditLog(auditFilename, 0, 0)
+	require.NoError(t, err)
+
+	device := hamdeck.NewReplayDevice(recording)
+	deck := hamdeck.New(device)
+	deck.SetAuditLog(audit)
+	if setup != nil {
+		setup(deck)
+	}
+	require.NoError(t, deck.ReadConfig(config))
+
+	err = deck.Run(nil)
+	assert.NoError(t, err)
+	device.Close()
+	require.NoError(t, audit.Close())
+
+	return &Result{
+		Deck:   deck,
+		Device: device,
+		Audit:  readAuditEntries(t, auditFilename),
+	}
+}
+
+func readAuditEntries(t *testing.T, filename string) []hamdeck.AuditEntry {
+	t.Helper()
+	file, err := os.Open(filename)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var result []hamdeck.AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry hamdeck.AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		result = append(result, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return result
+}
+
+// Commands returns the commands the buttons sent during the replay, in the order they were sent.
+func (r *Result) Commands() []string {
+	var result []string
+	for _, entry := range r.Audit {
+		if entry.Event == hamdeck.AuditCommand {
+			result = append(result, entry.Command)
+		}
+	}
+	return result
+}
+
+// Pages returns the pages that were attached during the replay, in the order they were attached.
+func (r *Result) Pages() []string {
+	var result []string
+	for _, entry := range r.Audit {
+		if entry.Event == hamdeck.AuditPageChanged {
+			result = append(result, entry.Page)
+		}
+	}
+	return result
+}
+
+// AssertCommands asserts that the buttons sent exactly the given commands.
+func (r *Result) AssertCommands(t *testing.T, expected ...string) bool {
+	t.Helper()
+	return assert.Equal(t, expected, r.Commands())
+}
+
+// Frame is the digest of an image that was set on a key.
+type Frame struct {
+	Index  int    `json:"index"`
+	Digest string `json:"digest"`
+}
+
+// Frames returns the digests of all images that were set on the device during the replay.
+func (r *Result) Frames() []Frame {
+	replayFrames := r.Device.Frames()
+	result := make([]Frame, len(replayFrames))
+	for i, frame := range replayFrames {
+		result[i] = Frame{Index: frame.Index, Digest: Digest(frame.Image)}
+	}
+	return result
+}
+
+// AssertFrames compares the sequence of images with the given golden file, one frame per line. If the environment
+// variable HAMDECK_UPDATE_GOLDEN is set, the golden file is written instead.
+func (r *Result) AssertFrames(t *testing.T, goldenFilename string) bool {
+	t.Helper()
+	actual := r.Frames()
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		require.NoError(t, writeFrames(goldenFilename, actual))
+		return true
+	}
+
+	expected, err := readFrames(goldenFilename)
+	require.NoError(t, err)
+	return assert.Equal(t, expected, actual, "the images differ from %s, set %s=1 to update it", goldenFilename, UpdateGoldenEnv)
+}
+
+func writeFrames(filename string, frames []Frame) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("cannot create the golden file: %w", err)
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, frame := range frames {
+		err := encoder.Encode(frame)
+		if err != nil {
+			return fmt.Errorf("cannot write the golden file: %w", err)
+		}
+	}
+	return nil
+}
+
+func readFrames(filename string) ([]Frame, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the golden file: %w", err)
+	}
+	defer file.Close()
+
+	result := []Frame{}
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var frame Frame
+		err := decoder.Decode(&frame)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the golden file: %w", err)
+		}
+		result = append(result, frame)
+	}
+	return result, nil
+}
+
+// Digest returns the SHA-256 digest of the pixels of the given image. A nil image has the digest "none".
+func Digest(img image.Image) string {
+	if img == nil {
+		return "none"
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Bounds().Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%dx%d:", rgba.Bounds().Dx(), rgba.Bounds().Dy())
+	for y := 0; y < rgba.Bounds().Dy(); y++ {
+		hash.Write(rgba.Pix[y*rgba.Stride : y*rgba.Stride+rgba.Bounds().Dx()*4])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
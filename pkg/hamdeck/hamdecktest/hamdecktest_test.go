@@ -0,0 +1,76 @@
+package hamdecktest
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+const testConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": {
+			"buttons": [
+				{ "type": "hamdeck.Page", "index": 0, "page": "second", "label": "Second" },
+				{ "type": "test.Command", "index": 1, "command": "first" }
+			]
+		},
+		"second": {
+			"buttons": [
+				{ "type": "test.Command", "index": 1, "command": "second" }
+			]
+		}
+	}
+}`
+
+type commandButtonFactory struct{}
+
+func (f *commandButtonFactory) Close() {}
+
+func (f *commandButtonFactory) CreateButton(config map[string]any) hamdeck.Button {
+	if config[hamdeck.ConfigType] != "test.Command" {
+		return nil
+	}
+	return &commandButton{command: fmt.Sprint(config["command"])}
+}
+
+type commandButton struct {
+	hamdeck.BaseButton
+	command string
+}
+
+func (b *commandButton) Image(gc hamdeck.GraphicContext, _ bool) image.Image {
+	return gc.DrawSingleLineTextButton(b.command)
+}
+
+func (b *commandButton) Pressed() {
+	b.AuditCommand(b.command, nil)
+}
+
+func (b *commandButton) Released() {}
+
+func TestReplay(t *testing.T) {
+	result := Replay(t, "testdata/session.jsonl", strings.NewReader(testConfig), func(deck *hamdeck.HamDeck) {
+		deck.RegisterFactory(hamdeck.NewButtonFactory(deck))
+		deck.RegisterFactory(new(commandButtonFactory))
+	})
+
+	result.AssertCommands(t, "first", "second")
+	assert.Equal(t, []string{"main", "second"}, result.Pages())
+	result.AssertFrames(t, "testdata/session.frames.jsonl")
+}
+
+func TestDigest(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	offset := image.NewRGBA(image.Rect(5, 5, 7, 7))
+
+	assert.Equal(t, "none", Digest(nil))
+	assert.Equal(t, Digest(img), Digest(offset))
+	assert.Equal(t, Digest(img), Digest(img.SubImage(img.Bounds())))
+	assert.NotEqual(t, Digest(img), Digest(image.NewRGBA(image.Rect(0, 0, 2, 3))))
+}
@@ -0,0 +1,356 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ftl/hamdeck/pkg/metrics"
+)
+
+// ConnectionStatusPageID is the ID of the generated page with the details of the connections.
+const ConnectionStatusPageID = "hamdeck.connections"
+
+// ConnectionHealth describes the current state of a connection.
+type ConnectionHealth struct {
+	Name      string
+	Connected bool
+	Since     time.Time
+	LastError string
+
+	wasConnected bool
+}
+
+func (h ConnectionHealth) Age(now time.Time) string {
+	if h.Since.IsZero() {
+		return ""
+	}
+	return formatAge(now.Sub(h.Since))
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// healthMonitor keeps track of the connection state published by the connections.
+type healthMonitor struct {
+	lock        *sync.Mutex
+	now         func() time.Time
+	connections map[string]*ConnectionHealth
+}
+
+func newHealthMonitor() *healthMonitor {
+	return &healthMonitor{
+		lock:        new(sync.Mutex),
+		now:         time.Now,
+		connections: make(map[string]*ConnectionHealth),
+	}
+}
+
+func (m *healthMonitor) StateChanged(connection string, name string, value string) {
+	if name != StateConnected && name != StateError {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	health, ok := m.connections[connection]
+	if !ok {
+		health = &ConnectionHealth{Name: connection}
+		m.connections[connection] = health
+	}
+
+	switch name {
+	case StateConnected:
+		connected := (value == FormatBoolState(true))
+		if connected != health.Connected || health.Since.IsZero() {
+			reconnected := connected && health.wasConnected
+			health.Connected = connected
+			health.Since = m.now()
+			health.wasConnected = health.wasConnected || connected
+			metrics.ConnectionChanged(connection, connected, reconnected)
+		}
+	case StateError:
+		health.LastError = value
+	}
+}
+
+func (m *healthMonitor) Get(connection string) (ConnectionHealth, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	health, ok := m.connections[connection]
+	if !ok {
+		return ConnectionHealth{}, false
+	}
+	return *health, true
+}
+
+func (m *healthMonitor) All() []ConnectionHealth {
+	m.lock.Lock()
+	result := make([]ConnectionHealth, 0, len(m.connections))
+	for _, health := range m.connections {
+		result = append(result, *health)
+	}
+	m.lock.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// ConnectionHealth returns the current state of all known connections, ordered by name.
+func (d *HamDeck) ConnectionHealth() []ConnectionHealth {
+	return d.health.All()
+}
+
+// ShowPage attaches a generated page with the given buttons. The buttons of a page with the same ID are closed.
+func (d *HamDeck) ShowPage(id string, buttons []Button) error {
+	d.pageLock.Lock()
+	previous, replaced := d.pages[id]
+	page := Page{buttons: make([]Button, len(d.buttons))}
+	copy(page.buttons, buttons)
+	d.pages[id] = page
+	d.pageLock.Unlock()
+
+	err := d.AttachPage(id)
+	if replaced {
+		// the buttons of the previous page are detached now
+		closePages(map[string]Page{id: previous})
+	}
+	return err
+}
+
+// ShowConnectionDetails attaches a generated page with one key per connection and a key to return to the current page.
+func (d *HamDeck) ShowConnectionDetails(connection string) error {
+	var healths []ConnectionHealth
+	if connection == "" {
+		healths = d.health.All()
+	} else {
+		health, ok := d.health.Get(connection)
+		if !ok {
+			health = ConnectionHealth{Name: connection}
+		}
+		healths = []ConnectionHealth{health}
+	}
+
+	buttons := make([]Button, len(d.buttons))
+	for i, health := range healths {
+		if i >= len(buttons)-1 {
+			break
+		}
+		buttons[i] = NewConnectionDetailButton(d.health, health.Name)
+	}
+	returnTo := d.CurrentPage()
+	if returnTo != ConnectionStatusPageID {
+		buttons[len(buttons)-1] = NewPageButton(d, returnTo, "Back")
+	}
+
+	return d.ShowPage(ConnectionStatusPageID, buttons)
+}
+
+/*
+	ConnectionStatusButton
+*/
+
+// ConnectionStatusButton shows the state of one connection, or a summary of all connections.
+// Pressing the button shows the details on a separate page.
+func NewConnectionStatusButton(deck *HamDeck, connection string, label string) *ConnectionStatusButton {
+	if label == "" {
+		label = connection
+	}
+	if label == "" {
+		label = "Conn"
+	}
+	return &ConnectionStatusButton{
+		deck:       deck,
+		monitor:    deck.health,
+		ticker:     newTicker(),
+		connection: connection,
+		label:      label,
+	}
+}
+
+type ConnectionStatusButton struct {
+	BaseButton
+	deck       *HamDeck
+	monitor    *healthMonitor
+	ticker     *ticker
+	image      image.Image
+	text       string
+	connection string
+	label      string
+}
+
+func (b *ConnectionStatusButton) status() (string, statusColor) {
+	now := b.monitor.now()
+	if b.connection != "" {
+		health, ok := b.monitor.Get(b.connection)
+		switch {
+		case !ok:
+			return "?", statusUnknown
+		case health.Connected:
+			return "OK", statusOK
+		default:
+			return "down " + health.Age(now), statusFailed
+		}
+	}
+
+	healths := b.monitor.All()
+	if len(healths) == 0 {
+		return "-", statusUnknown
+	}
+	connected := 0
+	for _, health := range healths {
+		if health.Connected {
+			connected++
+		}
+	}
+	text := fmt.Sprintf("%d/%d", connected, len(healths))
+	if connected < len(healths) {
+		return text, statusFailed
+	}
+	return text, statusOK
+}
+
+type statusColor int
+
+const (
+	statusUnknown statusColor = iota
+	statusOK
+	statusFailed
+)
+
+func setStatusColors(gc GraphicContext, status statusColor) {
+	switch status {
+	case statusOK:
+		gc.SetForeground(White)
+		gc.SetBackground(DarkGreen)
+	case statusFailed:
+		gc.SetForeground(White)
+		gc.SetBackground(Red)
+	default:
+		gc.SetForeground(DisabledGray)
+		gc.SetBackground(Black)
+	}
+}
+
+func (b *ConnectionStatusButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	text, status := b.status()
+	if b.image != nil && !redrawImages && text == b.text {
+		return b.image
+	}
+	b.text = text
+	setStatusColors(gc, status)
+	b.image = gc.DrawDoubleLineToggleTextButton(b.label, text, 2)
+	return b.image
+}
+
+func (b *ConnectionStatusButton) Pressed() {
+	err := b.deck.ShowConnectionDetails(b.connection)
+	if err != nil {
+		logger.Error("cannot show the connection details", "error", err)
+	}
+}
+
+func (b *ConnectionStatusButton) Released() {
+	// nop
+}
+
+func (b *ConnectionStatusButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.ticker.Start(func() {
+		b.Invalidate(false)
+	})
+}
+
+func (b *ConnectionStatusButton) Detached() {
+	b.ticker.Stop()
+	b.BaseButton.Detached()
+}
+
+/*
+	ConnectionDetailButton
+*/
+
+// ConnectionDetailButton shows how long a connection is up or down. Pressing the button toggles the display
+// of the last error.
+func NewConnectionDetailButton(monitor *healthMonitor, connection string) *ConnectionDetailButton {
+	return &ConnectionDetailButton{
+		monitor:    monitor,
+		ticker:     newTicker(),
+		connection: connection,
+	}
+}
+
+type ConnectionDetailButton struct {
+	BaseButton
+	monitor    *healthMonitor
+	ticker     *ticker
+	image      image.Image
+	text       string
+	connection string
+	showError  bool
+}
+
+func (b *ConnectionDetailButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	health, ok := b.monitor.Get(b.connection)
+	var text string
+	var status statusColor
+	switch {
+	case !ok:
+		text = "unknown"
+		status = statusUnknown
+	case b.showError && health.LastError == "":
+		text = "no error"
+		status = statusUnknown
+	case b.showError:
+		text = health.LastError
+		status = statusUnknown
+	case health.Connected:
+		text = "up " + health.Age(b.monitor.now())
+		status = statusOK
+	default:
+		text = "down " + health.Age(b.monitor.now())
+		status = statusFailed
+	}
+	if b.image != nil && !redrawImages && text == b.text {
+		return b.image
+	}
+	b.text = text
+	setStatusColors(gc, status)
+	b.image = gc.DrawDoubleLineToggleTextButton(b.connection, text, 2)
+	return b.image
+}
+
+func (b *ConnectionDetailButton) Pressed() {
+	b.showError = !b.showError
+	b.Invalidate(true)
+}
+
+func (b *ConnectionDetailButton) Released() {
+	// nop
+}
+
+func (b *ConnectionDetailButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.ticker.Start(func() {
+		b.Invalidate(false)
+	})
+}
+
+func (b *ConnectionDetailButton) Detached() {
+	b.ticker.Stop()
+	b.BaseButton.Detached()
+}
@@ -0,0 +1,86 @@
+package hamdeck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	monitor := newHealthMonitor()
+	monitor.now = clock.Now
+
+	monitor.StateChanged("rig", StateConnected, "false")
+	monitor.StateChanged("rig", StateError, "connection refused")
+	monitor.StateChanged("rig", StateFrequency, "7050000")
+	monitor.StateChanged("mqtt", StateConnected, "true")
+	clock.Add(3 * time.Minute)
+
+	healths := monitor.All()
+	require.Len(t, healths, 2)
+	assert.Equal(t, "mqtt", healths[0].Name)
+	assert.True(t, healths[0].Connected)
+	assert.Equal(t, "rig", healths[1].Name)
+	assert.False(t, healths[1].Connected)
+	assert.Equal(t, "connection refused", healths[1].LastError)
+	assert.Equal(t, "3m", healths[1].Age(clock.Now()))
+
+	monitor.StateChanged("rig", StateConnected, "true")
+	rig, ok := monitor.Get("rig")
+	require.True(t, ok)
+	assert.True(t, rig.Connected)
+	assert.Equal(t, "0s", rig.Age(clock.Now()))
+}
+
+func TestConnectionStatusButton(t *testing.T) {
+	config := `{
+		"buttons": [
+			{ "type": "hamdeck.ConnectionStatus", "index": 1 },
+			{ "type": "hamdeck.ConnectionStatus", "index": 2, "connection": "rig" }
+		]
+	}`
+	runWithConfigString(t, config, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		all, ok := deck.buttons[1].(*ConnectionStatusButton)
+		require.True(t, ok)
+		rig, ok := deck.buttons[2].(*ConnectionStatusButton)
+		require.True(t, ok)
+
+		deck.PublishState("rig", StateConnected, "true")
+		deck.PublishState("mqtt", StateConnected, "false")
+		text, status := all.status()
+		assert.Equal(t, "1/2", text)
+		assert.Equal(t, statusFailed, status)
+		text, status = rig.status()
+		assert.Equal(t, "OK", text)
+		assert.Equal(t, statusOK, status)
+
+		startPage := deck.CurrentPage()
+		device.Press(1)
+		device.Release(1)
+		device.WaitForLastKey()
+		assert.Equal(t, ConnectionStatusPageID, deck.CurrentPage())
+		assert.Equal(t, "mqtt", deck.buttons[0].(*ConnectionDetailButton).connection)
+		assert.Equal(t, "rig", deck.buttons[1].(*ConnectionDetailButton).connection)
+
+		back := len(deck.buttons) - 1
+		device.Press(back)
+		device.Release(back)
+		device.WaitForLastKey()
+		assert.Equal(t, startPage, deck.CurrentPage())
+	})
+}
+
+func TestShowPage_ClosesTheButtonsOfTheReplacedPage(t *testing.T) {
+	deck, _ := setupTestDeck(t, `{}`, nil)
+	previous := &testButton{}
+	require.NoError(t, deck.ShowPage(ConnectionStatusPageID, []Button{previous}))
+	require.True(t, previous.attached)
+
+	require.NoError(t, deck.ShowPage(ConnectionStatusPageID, []Button{&testButton{}}))
+
+	assert.True(t, previous.detached)
+	assert.True(t, previous.closed)
+}
@@ -0,0 +1,399 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"sync"
+)
+
+const (
+	ConfigInterlock = "interlock"
+	ConfigSegments  = "segments"
+	ConfigFrom      = "from"
+	ConfigTo        = "to"
+)
+
+// TransmitButton is implemented by buttons that may key the transmitter or increase the output power.
+// Pressing such a button is blocked while the interlock is tripped.
+type TransmitButton interface {
+	Button
+	// KeysTransmitter reports if the next press keys the transmitter or increases the output power.
+	KeysTransmitter() bool
+}
+
+// TXController is implemented by button factories that are able to unkey the transmitter of their connections.
+// StopTX is called in the goroutine that runs the deck. It returns false if the connection does not belong to the
+// factory, otherwise it returns a function that unkeys the transmitter. This function may block, it returns an
+// error if the transmitter could not be unkeyed.
+type TXController interface {
+	StopTX(connection string) (func() error, bool)
+}
+
+// InterlockListener is notified when the state of the interlock changes.
+type InterlockListener interface {
+	SetInterlock(tripped bool, reason string)
+}
+
+/*
+	Interlock
+*/
+
+// InterlockCondition is a condition that must be fulfilled to allow transmitting.
+type InterlockCondition struct {
+	Condition
+	Label string
+}
+
+// Segment is a frequency range in Hz where transmitting is allowed.
+type Segment struct {
+	From float64
+	To   float64
+}
+
+func (s Segment) Contains(frequency float64) bool {
+	return s.From <= frequency && frequency <= s.To
+}
+
+// The Interlock blocks all transmit buttons as long as a safety condition is violated. If it trips while the
+// transmitter is keyed, it unkeys the transmitter of its connection.
+type Interlock struct {
+	deck *HamDeck
+	lock *sync.Mutex
+
+	configured bool
+	connection string
+	conditions []InterlockCondition
+	segments   []Segment
+
+	tripped   bool
+	reason    string
+	listeners []InterlockListener
+}
+
+func newInterlock(deck *HamDeck) *Interlock {
+	return &Interlock{
+		deck: deck,
+		lock: new(sync.Mutex),
+	}
+}
+
+func (i *Interlock) Clear() {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.configured = false
+	i.connection = ""
+	i.conditions = nil
+	i.segments = nil
+	i.tripped = false
+	i.reason = ""
+	i.listeners = nil
+}
+
+func (i *Interlock) Configure(connection string, conditions []InterlockCondition, segments []Segment) {
+	i.lock.Lock()
+	i.configured = true
+	i.connection = connection
+	i.conditions = conditions
+	i.segments = segments
+	i.lock.Unlock()
+
+	i.update(false)
+}
+
+func (i *Interlock) Notify(listener InterlockListener) {
+	i.lock.Lock()
+	i.listeners = append(i.listeners, listener)
+	tripped := i.tripped
+	reason := i.reason
+	i.lock.Unlock()
+
+	listener.SetInterlock(tripped, reason)
+}
+
+// Tripped reports if transmitting is currently blocked, and why.
+func (i *Interlock) Tripped() (bool, string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.tripped, i.reason
+}
+
+func (i *Interlock) StateChanged(connection string, name string, value string) {
+	i.lock.Lock()
+	pttChanged := (connection == i.connection && name == StatePTT)
+	i.lock.Unlock()
+
+	i.update(pttChanged)
+}
+
+func (i *Interlock) update(pttChanged bool) {
+	i.lock.Lock()
+	if !i.configured {
+		i.lock.Unlock()
+		return
+	}
+	wasTripped := i.tripped
+	i.tripped, i.reason = i.evaluate()
+	changed := (i.tripped != wasTripped)
+	tripped := i.tripped
+	reason := i.reason
+	connection := i.connection
+	listeners := i.listeners
+	i.lock.Unlock()
+
+	if changed {
+		if tripped {
+			logger.Warn("TX interlock tripped", "reason", reason)
+		} else {
+			logger.Info("TX interlock released")
+		}
+		for _, listener := range listeners {
+			listener.SetInterlock(tripped, reason)
+		}
+	}
+
+	if !tripped || !(changed || pttChanged) || connection == "" {
+		return
+	}
+	ptt, _ := i.deck.GetState(connection, StatePTT)
+	if ptt != FormatBoolState(true) {
+		return
+	}
+	i.deck.post(func() {
+		i.deck.stopTX(connection)
+	})
+}
+
+func (i *Interlock) evaluate() (bool, string) {
+	for _, condition := range i.conditions {
+		value, available := i.deck.GetState(condition.Connection, condition.State)
+		if !condition.Evaluate(value, available) {
+			return true, condition.Label
+		}
+	}
+
+	if len(i.segments) == 0 {
+		return false, ""
+	}
+	rawFrequency, available := i.deck.GetState(i.connection, StateFrequency)
+	if !available {
+		return true, "Band"
+	}
+	frequency, err := strconv.ParseFloat(rawFrequency, 64)
+	if err != nil {
+		return true, "Band"
+	}
+	for _, segment := range i.segments {
+		if segment.Contains(frequency) {
+			return false, ""
+		}
+	}
+	return true, "Band"
+}
+
+func (d *HamDeck) loadInterlock(configuration map[string]any) error {
+	connection, _ := ToString(configuration[ConfigConnection])
+
+	var conditions []InterlockCondition
+	rawConditions, ok := configuration[ConfigConditions].([]any)
+	if ok {
+		for j, rawCondition := range rawConditions {
+			conditionConfiguration, ok := rawCondition.(map[string]any)
+			if !ok {
+				return fmt.Errorf("interlock condition %d is not a valid condition", j)
+			}
+			condition, err := ParseCondition(conditionConfiguration)
+			if err != nil {
+				return fmt.Errorf("interlock condition %d: %w", j, err)
+			}
+			label, ok := ToString(conditionConfiguration[ConfigLabel])
+			if !ok {
+				label = condition.State
+			}
+			conditions = append(conditions, InterlockCondition{Condition: condition, Label: label})
+
+			err = d.RequestState(condition.Connection, condition.State)
+			if err != nil {
+				logger.Error("invalid interlock condition", "index", j, "error", err)
+			}
+		}
+	}
+
+	var segments []Segment
+	rawSegments, ok := configuration[ConfigSegments].([]any)
+	if ok {
+		if connection == "" {
+			return fmt.Errorf("the interlock needs a connection to check the segments")
+		}
+		for j, rawSegment := range rawSegments {
+			segmentConfiguration, ok := rawSegment.(map[string]any)
+			if !ok {
+				return fmt.Errorf("interlock segment %d is not a valid segment", j)
+			}
+			from, haveFrom := ToFloat(segmentConfiguration[ConfigFrom])
+			to, haveTo := ToFloat(segmentConfiguration[ConfigTo])
+			if !(haveFrom && haveTo) {
+				return fmt.Errorf("interlock segment %d must have from and to fields", j)
+			}
+			segments = append(segments, Segment{From: from, To: to})
+		}
+	}
+
+	if connection != "" {
+		err := d.RequestState(connection, StatePTT)
+		if err != nil {
+			logger.Error("interlock", "error", err)
+		}
+	}
+
+	d.interlock.Configure(connection, conditions, segments)
+	return nil
+}
+
+// stopTX asks the button factories to unkey the transmitter of the given connection. It must be called from the
+// goroutine that runs the deck, the request to the transmitter is sent asynchronously.
+func (d *HamDeck) stopTX(connection string) {
+	stop, err := d.txStopper(connection)
+	if err != nil {
+		d.stopTXDone(connection, err)
+		return
+	}
+	go func() {
+		d.stopTXDone(connection, stop())
+	}()
+}
+
+func (d *HamDeck) txStopper(connection string) (func() error, error) {
+	for _, factory := range d.factories {
+		controller, ok := factory.(TXController)
+		if !ok {
+			continue
+		}
+		stop, ok := controller.StopTX(connection)
+		if ok {
+			return stop, nil
+		}
+	}
+	return nil, fmt.Errorf("no transmitter available for connection %s", connection)
+}
+
+func (d *HamDeck) stopTXDone(connection string, err error) {
+	d.auditConnectionCommand(InterlockButtonType, connection, "stop_tx", err)
+	if err != nil {
+		logger.Error("cannot stop transmitting", "connection", connection, "error", err)
+	}
+}
+
+/*
+	InterlockButton
+*/
+
+// InterlockButton wraps a TransmitButton and blocks it while the interlock is tripped.
+type InterlockButton struct {
+	BaseButton
+	button    TransmitButton
+	interlock *Interlock
+	blocked   bool
+}
+
+func (b *InterlockButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	return b.button.Image(gc, redrawImages)
+}
+
+func (b *InterlockButton) Pressed() {
+	tripped, reason := b.interlock.Tripped()
+	b.blocked = tripped && b.button.KeysTransmitter()
+	if b.blocked {
+		logger.Warn("TX blocked by the interlock", "reason", reason)
+		return
+	}
+	b.button.Pressed()
+}
+
+func (b *InterlockButton) Released() {
+	if b.blocked {
+		return
+	}
+	b.button.Released()
+}
+
+func (b *InterlockButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.button.Attached(ctx)
+}
+
+func (b *InterlockButton) Detached() {
+	b.button.Detached()
+	b.BaseButton.Detached()
+}
+
+func (b *InterlockButton) Close() {
+	CloseButton(b.button)
+}
+
+func (b *InterlockButton) Flash(on bool) {
+	flashingButton, ok := b.button.(FlashingButton)
+	if ok {
+		flashingButton.Flash(on)
+	}
+}
+
+/*
+	InterlockStatusButton
+*/
+
+func NewInterlockStatusButton(interlock *Interlock, label string) *InterlockStatusButton {
+	if label == "" {
+		label = "TX"
+	}
+	result := &InterlockStatusButton{
+		lock:  new(sync.Mutex),
+		label: label,
+	}
+	interlock.Notify(result)
+	return result
+}
+
+type InterlockStatusButton struct {
+	BaseButton
+	lock    *sync.Mutex
+	image   image.Image
+	label   string
+	tripped bool
+	reason  string
+}
+
+func (b *InterlockStatusButton) SetInterlock(tripped bool, reason string) {
+	b.lock.Lock()
+	b.tripped = tripped
+	b.reason = reason
+	b.lock.Unlock()
+	b.Invalidate(true)
+}
+
+func (b *InterlockStatusButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	if b.image == nil || redrawImages {
+		b.lock.Lock()
+		tripped := b.tripped
+		reason := b.reason
+		b.lock.Unlock()
+
+		gc.SetForeground(White)
+		if tripped {
+			gc.SetBackground(Red)
+			b.image = gc.DrawDoubleLineToggleTextButton(b.label, reason, 2)
+		} else {
+			gc.SetBackground(DarkGreen)
+			b.image = gc.DrawDoubleLineToggleTextButton(b.label, "OK", 2)
+		}
+	}
+	return b.image
+}
+
+func (b *InterlockStatusButton) Pressed() {
+	// nop
+}
+
+func (b *InterlockStatusButton) Released() {
+	// nop
+}
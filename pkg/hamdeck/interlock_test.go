@@ -0,0 +1,172 @@
+package hamdeck
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const interlockTestConfig = `{
+	"interlock": {
+		"connection": "rig",
+		"conditions": [
+			{ "connection": "atu", "state": "atu100/swr", "below": 2.5, "label": "SWR" },
+			{ "connection": "atu", "state": "amp/relay", "equals": "ready", "label": "AMP" }
+		],
+		"segments": [
+			{ "from": 7000000, "to": 7200000 },
+			{ "from": 14000000, "to": 14350000 }
+		]
+	},
+	"buttons": [
+		{ "type": "test.TransmitButton", "index": 0 },
+		{ "type": "hamdeck.Interlock", "index": 1 },
+		{ "type": "hamdeck.Cycle", "index": 2, "states": [
+			{ "label": "TX", "action": { "type": "test.TransmitButton" } }
+		] }
+	]
+}`
+
+func TestInterlock(t *testing.T) {
+	deck, txFactory := setupInterlockTest(t)
+	startDeck(t, deck)
+	button, ok := deck.buttons[0].(*InterlockButton)
+	require.True(t, ok)
+	transmitButton := button.button.(*testTransmitButton)
+	status, ok := deck.buttons[1].(*InterlockStatusButton)
+	require.True(t, ok)
+
+	tripped, _ := deck.interlock.Tripped()
+	assert.True(t, tripped, "tripped without state")
+
+	deck.PublishState("atu", "atu100/swr", "1.2")
+	deck.PublishState("atu", "amp/relay", "ready")
+	deck.PublishState("rig", StateFrequency, "7050000")
+	tripped, _ = deck.interlock.Tripped()
+	assert.False(t, tripped)
+	assert.False(t, status.tripped)
+
+	button.Pressed()
+	button.Released()
+	assert.True(t, transmitButton.pressed)
+	deck.PublishState("rig", StatePTT, "true")
+
+	deck.PublishState("atu", "atu100/swr", "3.1")
+	tripped, reason := deck.interlock.Tripped()
+	assert.True(t, tripped)
+	assert.Equal(t, "SWR", reason)
+	assert.True(t, status.tripped)
+	select {
+	case connection := <-txFactory.stoppedTX:
+		assert.Equal(t, "rig", connection)
+	case <-time.After(time.Second):
+		assert.Fail(t, "TX was not stopped")
+	}
+
+	transmitButton.pressed = false
+	button.Pressed()
+	button.Released()
+	assert.False(t, transmitButton.pressed)
+
+	transmitButton.keysTransmitter = false
+	button.Pressed()
+	button.Released()
+	assert.True(t, transmitButton.pressed, "unkeying must always be possible")
+}
+
+func TestInterlock_Segments(t *testing.T) {
+	deck, _ := setupInterlockTest(t)
+	deck.PublishState("atu", "atu100/swr", "1.2")
+	deck.PublishState("atu", "amp/relay", "ready")
+
+	deck.PublishState("rig", StateFrequency, "14100000")
+	tripped, _ := deck.interlock.Tripped()
+	assert.False(t, tripped)
+
+	deck.PublishState("rig", StateFrequency, "14400000")
+	tripped, reason := deck.interlock.Tripped()
+	assert.True(t, tripped)
+	assert.Equal(t, "Band", reason)
+}
+
+func TestInterlock_DecoratesActions(t *testing.T) {
+	deck, _ := setupInterlockTest(t)
+	cycle, ok := deck.buttons[2].(*CycleButton)
+	require.True(t, ok)
+
+	action, ok := cycle.states[0].Action.(*InterlockButton)
+	require.True(t, ok, "the action must be blocked by the interlock")
+	transmitButton := action.button.(*testTransmitButton)
+
+	cycle.Pressed()
+	cycle.Released()
+	assert.False(t, transmitButton.pressed)
+}
+
+func TestInterlock_AuditsTheResultOfStopTX(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := OpenAuditLog(filename, 0, 0)
+	require.NoError(t, err)
+	txFactory := &testTXFactory{stoppedTX: make(chan string, 1), err: errors.New("not connected")}
+	deck, _ := setupTestDeck(t, interlockTestConfig, func(deck *HamDeck) {
+		deck.SetAuditLog(audit)
+		deck.RegisterFactory(txFactory)
+	})
+	startDeck(t, deck)
+
+	deck.PublishState("rig", StatePTT, "true")
+	<-txFactory.stoppedTX
+	waitForQueue(t, deck)
+	require.Eventually(t, func() bool {
+		for _, entry := range readAuditEntries(t, filename) {
+			if entry.Command == "stop_tx" {
+				return entry.Result == "not connected"
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func setupInterlockTest(t *testing.T) (*HamDeck, *testTXFactory) {
+	txFactory := &testTXFactory{stoppedTX: make(chan string, 1)}
+	deck, _ := setupTestDeck(t, interlockTestConfig, func(deck *HamDeck) {
+		deck.RegisterFactory(txFactory)
+	})
+	return deck, txFactory
+}
+
+type testTXFactory struct {
+	stoppedTX chan string
+	err       error
+}
+
+func (f *testTXFactory) Close() {}
+
+func (f *testTXFactory) CreateButton(config map[string]any) Button {
+	if config[ConfigType] != "test.TransmitButton" {
+		return nil
+	}
+	return &testTransmitButton{keysTransmitter: true}
+}
+
+func (f *testTXFactory) RequestState(connection string, name string) bool {
+	return true
+}
+
+func (f *testTXFactory) StopTX(connection string) (func() error, bool) {
+	return func() error {
+		f.stoppedTX <- connection
+		return f.err
+	}, true
+}
+
+type testTransmitButton struct {
+	testButton
+	keysTransmitter bool
+}
+
+func (b *testTransmitButton) KeysTransmitter() bool { return b.keysTransmitter }
@@ -0,0 +1,165 @@
+package hamdeck
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	ConfigRow     = "row"
+	ConfigColumn  = "column"
+	ConfigLayout  = "layout"
+	ConfigRows    = "rows"
+	ConfigColumns = "columns"
+)
+
+// MoreButtonLabel is the label of the key that leads to the next continuation page.
+const MoreButtonLabel = "More"
+
+// Layout is the geometry of a Stream Deck: the number of rows and columns of keys.
+type Layout struct {
+	Rows    int
+	Columns int
+}
+
+// ParseLayout reads a layout configuration, e.g. {"rows": 3, "columns": 5}.
+func ParseLayout(raw any) (Layout, error) {
+	configuration, ok := raw.(map[string]any)
+	if !ok {
+		return Layout{}, fmt.Errorf("%s must be an object with rows and columns", ConfigLayout)
+	}
+	rows, rowsOK := ToInt(configuration[ConfigRows])
+	columns, columnsOK := ToInt(configuration[ConfigColumns])
+	if !rowsOK || !columnsOK || rows < 1 || columns < 1 {
+		return Layout{}, fmt.Errorf("%s needs a positive number of rows and columns", ConfigLayout)
+	}
+	return Layout{Rows: rows, Columns: columns}, nil
+}
+
+func (l Layout) Keys() int {
+	return l.Rows * l.Columns
+}
+
+// Fits indicates if all keys of this layout are also available in the given layout at the same row and column.
+func (l Layout) Fits(other Layout) bool {
+	return l.Rows <= other.Rows && l.Columns <= other.Columns
+}
+
+// Index returns the index of the key at the given row and column. Negative values count backwards from the last
+// row or column.
+func (l Layout) Index(row int, column int) (int, bool) {
+	if row < 0 {
+		row += l.Rows
+	}
+	if column < 0 {
+		column += l.Columns
+	}
+	if row < 0 || row >= l.Rows || column < 0 || column >= l.Columns {
+		return 0, false
+	}
+	return row*l.Columns + column, true
+}
+
+// Position returns the row and the column of the key with the given index.
+func (l Layout) Position(index int) (row int, column int) {
+	return index / l.Columns, index % l.Columns
+}
+
+// ButtonIndex returns the index of the key that is addressed in the given button configuration, either with
+// row and column or with an index. A negative index counts backwards from the last key.
+func (l Layout) ButtonIndex(config map[string]any) (int, error) {
+	rawRow, hasRow := config[ConfigRow]
+	rawColumn, hasColumn := config[ConfigColumn]
+	if hasRow || hasColumn {
+		row, rowOK := ToInt(rawRow)
+		column, columnOK := ToInt(rawColumn)
+		if !rowOK || !columnOK {
+			return 0, fmt.Errorf("%s and %s must both be numbers", ConfigRow, ConfigColumn)
+		}
+		index, ok := l.Index(row, column)
+		if !ok {
+			return 0, fmt.Errorf("row %d, column %d is not a valid key in %d rows and %d columns", row, column, l.Rows, l.Columns)
+		}
+		return index, nil
+	}
+
+	index, ok := ToInt(config[ConfigIndex])
+	if !ok {
+		return 0, fmt.Errorf("no valid %s", ConfigIndex)
+	}
+	if index < 0 {
+		index += l.Keys()
+	}
+	if index < 0 || index >= l.Keys() {
+		return 0, fmt.Errorf("%d is not a valid button index in [0, %d)", index, l.Keys())
+	}
+	return index, nil
+}
+
+func (d *HamDeck) deviceLayout() Layout {
+	return Layout{Rows: d.device.Rows(), Columns: d.device.Columns()}
+}
+
+// ContinuationPageID returns the ID of the n-th page (counted from 0) of a page whose buttons do not fit
+// on the device.
+func ContinuationPageID(pageID string, n int) string {
+	if n == 0 {
+		return pageID
+	}
+	return fmt.Sprintf("%s.%d", pageID, n+1)
+}
+
+// placedButton is the configuration of a button together with the index of its key.
+type placedButton struct {
+	index  int
+	config map[string]any
+	source int
+}
+
+// arrangeButtons distributes the buttons, which are placed on the given layout, onto the keys of the device.
+// If the layout fits on the device, every button keeps its row and column. Otherwise the buttons are reflowed
+// in their order on the layout and the buttons that do not fit go to continuation pages. In this case the last key
+// of every page is left free for the key that leads to the next page.
+func arrangeButtons(buttons []placedButton, layout Layout, device Layout) [][]placedButton {
+	if layout.Fits(device) {
+		result := make([]placedButton, 0, len(buttons))
+		for _, button := range buttons {
+			row, column := layout.Position(button.index)
+			button.index, _ = device.Index(row, column)
+			result = append(result, button)
+		}
+		return [][]placedButton{result}
+	}
+
+	byIndex := make(map[int]placedButton, len(buttons))
+	for _, button := range buttons {
+		byIndex[button.index] = button
+	}
+	ordered := make([]placedButton, 0, len(byIndex))
+	for _, button := range byIndex {
+		ordered = append(ordered, button)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].index < ordered[j].index
+	})
+
+	keysPerPage := device.Keys()
+	if len(ordered) > keysPerPage && keysPerPage > 1 {
+		keysPerPage--
+	}
+	var result [][]placedButton
+	for len(ordered) > 0 || len(result) == 0 {
+		count := min(keysPerPage, len(ordered))
+		page := make([]placedButton, count)
+		for i := range page {
+			page[i] = ordered[i]
+			page[i].index = i
+		}
+		result = append(result, page)
+		ordered = ordered[count:]
+		if keysPerPage == device.Keys() {
+			break
+		}
+	}
+	return result
+}
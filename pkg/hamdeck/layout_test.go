@@ -0,0 +1,137 @@
+package hamdeck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout_ButtonIndex(t *testing.T) {
+	layout := Layout{Rows: 3, Columns: 5}
+	tt := []struct {
+		name     string
+		config   map[string]any
+		expected int
+		invalid  bool
+	}{
+		{name: "index", config: map[string]any{"index": float64(7)}, expected: 7},
+		{name: "negative index", config: map[string]any{"index": float64(-1)}, expected: 14},
+		{name: "row and column", config: map[string]any{"row": float64(1), "column": float64(2)}, expected: 7},
+		{name: "negative row and column", config: map[string]any{"row": float64(-1), "column": float64(-2)}, expected: 13},
+		{name: "row and column as string", config: map[string]any{"row": "2", "column": "0"}, expected: 10},
+		{name: "row and column win over index", config: map[string]any{"index": float64(3), "row": float64(0), "column": float64(0)}, expected: 0},
+		{name: "index out of range", config: map[string]any{"index": float64(15)}, invalid: true},
+		{name: "negative index out of range", config: map[string]any{"index": float64(-16)}, invalid: true},
+		{name: "row out of range", config: map[string]any{"row": float64(3), "column": float64(0)}, invalid: true},
+		{name: "column without row", config: map[string]any{"column": float64(1)}, invalid: true},
+		{name: "no position", config: map[string]any{}, invalid: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := layout.ButtonIndex(tc.config)
+			if tc.invalid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestParseLayout(t *testing.T) {
+	layout, err := ParseLayout(map[string]any{"rows": float64(3), "columns": float64(5)})
+	require.NoError(t, err)
+	assert.Equal(t, Layout{Rows: 3, Columns: 5}, layout)
+
+	_, err = ParseLayout(map[string]any{"rows": float64(3)})
+	assert.Error(t, err)
+	_, err = ParseLayout("3x5")
+	assert.Error(t, err)
+}
+
+func TestLoadButtons_LayoutFitsOnDevice(t *testing.T) {
+	runWithConfigString(t, `{
+		"layout": { "rows": 3, "columns": 5 },
+		"buttons": [
+			{ "type": "test.Button", "row": 1, "column": 4 },
+			{ "type": "test.Button", "index": -1 }
+		]
+	}`, func(t *testing.T, deck *HamDeck, _ *testDevice, _ chan struct{}) {
+		assert.IsType(t, &testButton{}, deck.buttons[12])
+		assert.IsType(t, &testButton{}, deck.buttons[20])
+		assert.Equal(t, []string{legacyPageID}, pageIDs(deck))
+	})
+}
+
+func TestLoadButtons_NegativeIndexOnDevice(t *testing.T) {
+	runWithConfigString(t, `{
+		"buttons": [
+			{ "type": "test.Button", "index": -1 },
+			{ "type": "test.Button", "row": -1, "column": 0 },
+			{ "type": "test.Button", "index": 32 }
+		]
+	}`, func(t *testing.T, deck *HamDeck, _ *testDevice, _ chan struct{}) {
+		assert.IsType(t, &testButton{}, deck.buttons[31])
+		assert.IsType(t, &testButton{}, deck.buttons[24])
+	})
+}
+
+func TestLoadButtons_ReflowWithContinuationPages(t *testing.T) {
+	device := newTestDevice(80, 2, 3)
+	deck := New(device)
+	deck.RegisterFactory(new(testButtonFactory))
+	buttons := make([]string, 0, 12)
+	for i := 0; i < 12; i += 1 {
+		if i == 5 {
+			continue
+		}
+		buttons = append(buttons, fmt.Sprintf(`{ "type": "test.Button", "index": %d, "label": "%d" }`, i, i))
+	}
+	config := fmt.Sprintf(`{
+		"start_page": "bands",
+		"pages": {
+			"bands": {
+				"layout": { "rows": 3, "columns": 4 },
+				"buttons": [ %s ]
+			}
+		}
+	}`, strings.Join(buttons, ","))
+
+	require.NoError(t, deck.ReadConfig(strings.NewReader(config)))
+
+	assert.Equal(t, []string{"bands", "bands.2", "bands.3"}, pageIDs(deck))
+	labels := func(id string) []string {
+		var result []string
+		for _, button := range deck.pages[id].buttons {
+			switch button := button.(type) {
+			case *testButton:
+				result = append(result, button.config["label"].(string))
+			case *PageButton:
+				result = append(result, "->"+button.id)
+			case nil:
+				result = append(result, "")
+			}
+		}
+		return result
+	}
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "->bands.2"}, labels("bands"))
+	assert.Equal(t, []string{"6", "7", "8", "9", "10", "->bands.3"}, labels("bands.2"))
+	assert.Equal(t, []string{"11", "", "", "", "", "->bands"}, labels("bands.3"))
+
+	deck.handleKey(Key{Index: 5, Pressed: true})
+	assert.Equal(t, "bands.2", deck.CurrentPage())
+}
+
+func pageIDs(deck *HamDeck) []string {
+	var result []string
+	for id := range deck.pages {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,79 @@
+package hamdeck
+
+import "fmt"
+
+const (
+	ListPageType = "list"
+	ConfigBack   = "back"
+)
+
+// The labels of the reserved keys on list pages.
+const (
+	PreviousButtonLabel = "Prev"
+	NextButtonLabel     = "Next"
+	BackButtonLabel     = "Back"
+)
+
+// loadListPage creates a page from a list of buttons of any length. The buttons are placed in their order, and as
+// many pages are generated as needed (<page>, <page>.2, <page>.3, ...). The last keys of every page are reserved
+// for the keys to the previous and the next page, and for the key back to the page given in the back field
+// (the start page by default).
+func (d *HamDeck) loadListPage(id string, configuration map[string]any, buttonsConfiguration []any) (map[string]Page, error) {
+	backID, ok := ToString(configuration[ConfigBack])
+	if !ok {
+		backID = d.startPageID
+	}
+	if backID == id {
+		backID = ""
+	}
+
+	var buttons []placedButton
+	for i, rawButtonConfig := range buttonsConfiguration {
+		buttonConfig, ok := rawButtonConfig.(map[string]any)
+		if !ok {
+			logger.Error("button is not a button object", "index", i)
+			continue
+		}
+		buttons = append(buttons, placedButton{config: buttonConfig, source: i})
+	}
+
+	keys := len(d.buttons)
+	reserved := 0
+	if backID != "" {
+		reserved++
+	}
+	if len(buttons) > keys-reserved {
+		reserved += 2
+	}
+	keysPerPage := keys - reserved
+	if keysPerPage < 1 {
+		return nil, fmt.Errorf("page %s: the device has not enough keys for a list page", id)
+	}
+	pageCount := max(1, (len(buttons)+keysPerPage-1)/keysPerPage)
+
+	result := make(map[string]Page, pageCount)
+	for n := 0; n < pageCount; n++ {
+		pageButtons := buttons[n*keysPerPage : min((n+1)*keysPerPage, len(buttons))]
+		for i := range pageButtons {
+			pageButtons[i].index = i
+		}
+
+		pageID := ContinuationPageID(id, n)
+		page := d.createPage(pageID, pageButtons)
+		reservedIndex := keys - reserved
+		if pageCount > 1 {
+			if n > 0 {
+				page.setPageButton(reservedIndex, NewPageButton(d, ContinuationPageID(id, n-1), PreviousButtonLabel))
+			}
+			if n < pageCount-1 {
+				page.setPageButton(reservedIndex+1, NewPageButton(d, ContinuationPageID(id, n+1), NextButtonLabel))
+			}
+			reservedIndex += 2
+		}
+		if backID != "" {
+			page.setPageButton(reservedIndex, NewPageButton(d, backID, BackButtonLabel))
+		}
+		result[pageID] = page
+	}
+	return result, nil
+}
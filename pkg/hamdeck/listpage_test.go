@@ -0,0 +1,85 @@
+package hamdeck
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listPageTestConfig(startPage string, buttonCount int) string {
+	buttons := make([]string, buttonCount)
+	for i := range buttons {
+		buttons[i] = fmt.Sprintf(`{ "type": "test.Button", "label": "%d" }`, i)
+	}
+	return fmt.Sprintf(`{
+		"start_page": "%s",
+		"pages": {
+			"main": { "buttons": [] },
+			"memories": { "type": "list", "buttons": [ %s ] }
+		}
+	}`, startPage, strings.Join(buttons, ","))
+}
+
+func pageLabels(deck *HamDeck, id string) []string {
+	var result []string
+	for _, button := range deck.pages[id].buttons {
+		switch button := button.(type) {
+		case *testButton:
+			result = append(result, button.config["label"].(string))
+		case *PageButton:
+			result = append(result, button.label+":"+button.id)
+		case nil:
+			result = append(result, "")
+		}
+	}
+	return result
+}
+
+func TestListPage_GeneratesSubPages(t *testing.T) {
+	deck := New(newTestDevice(80, 2, 3))
+	deck.RegisterFactory(new(testButtonFactory))
+
+	require.NoError(t, deck.ReadConfig(strings.NewReader(listPageTestConfig("main", 8))))
+
+	assert.Equal(t, []string{"main", "memories", "memories.2", "memories.3"}, pageIDs(deck))
+	assert.Equal(t, []string{"0", "1", "2", "", "Next:memories.2", "Back:main"}, pageLabels(deck, "memories"))
+	assert.Equal(t, []string{"3", "4", "5", "Prev:memories", "Next:memories.3", "Back:main"}, pageLabels(deck, "memories.2"))
+	assert.Equal(t, []string{"6", "7", "", "Prev:memories.2", "", "Back:main"}, pageLabels(deck, "memories.3"))
+
+	require.NoError(t, deck.AttachPage("memories"))
+	deck.handleKey(Key{Index: 4, Pressed: true})
+	assert.Equal(t, "memories.2", deck.CurrentPage())
+	deck.handleKey(Key{Index: 5, Pressed: true})
+	assert.Equal(t, "main", deck.CurrentPage())
+}
+
+func TestListPage_FitsOnOnePage(t *testing.T) {
+	deck := New(newTestDevice(80, 2, 3))
+	deck.RegisterFactory(new(testButtonFactory))
+
+	require.NoError(t, deck.ReadConfig(strings.NewReader(listPageTestConfig("main", 5))))
+
+	assert.Equal(t, []string{"main", "memories"}, pageIDs(deck))
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "Back:main"}, pageLabels(deck, "memories"))
+}
+
+func TestListPage_NoBackKeyOnTheStartPage(t *testing.T) {
+	deck := New(newTestDevice(80, 2, 3))
+	deck.RegisterFactory(new(testButtonFactory))
+
+	require.NoError(t, deck.ReadConfig(strings.NewReader(listPageTestConfig("memories", 6))))
+
+	assert.Equal(t, []string{"main", "memories"}, pageIDs(deck))
+	assert.Equal(t, []string{"0", "1", "2", "3", "4", "5"}, pageLabels(deck, "memories"))
+}
+
+func TestListPage_UnknownPageType(t *testing.T) {
+	deck := New(newDefaultTestDevice())
+
+	err := deck.ReadConfig(strings.NewReader(`{"pages": {"main": {"type": "grid", "buttons": []}}}`))
+
+	assert.ErrorContains(t, err, "grid")
+}
@@ -1,6 +1,7 @@
 package hamdeck
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -166,3 +167,26 @@ func TestPageButton(t *testing.T) {
 		assert.Same(t, legacyButton, deck.buttons[0])
 	})
 }
+
+func TestReadConfig_ReleasesTheButtonsOfThePreviousConfiguration(t *testing.T) {
+	runWithConfigString(t, `{
+	"start_page": "main",
+	"pages": {
+		"main": { "buttons": [ { "type": "test.Button", "index": 0 } ] },
+		"other": { "buttons": [ { "type": "test.Button", "index": 0, "confirm": true } ] }
+	}
+}`, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		attached := deck.pages["main"].buttons[0].(*testButton)
+		unattached := deck.pages["other"].buttons[0].(*ConfirmButton).button.(*testButton)
+
+		err := deck.Do(func() error {
+			return deck.ReadConfig(strings.NewReader(`{"start_page": "main", "pages": {"main": {"buttons": []}}}`))
+		})
+		require.NoError(t, err)
+
+		assert.True(t, attached.detached)
+		assert.True(t, attached.closed)
+		assert.False(t, unattached.attached)
+		assert.True(t, unattached.closed)
+	})
+}
@@ -0,0 +1,228 @@
+package hamdeck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultStoreFilename = "hamdeck.state.json"
+	DefaultStoreDelay    = 2 * time.Second
+)
+
+const (
+	storeKeyPage       = "page"
+	storeKeyBrightness = "brightness"
+	storeKeyProfile    = "profile"
+)
+
+// Store keeps runtime values across restarts. Changes are written to the state file with a delay,
+// so that a burst of changes results in only one write. A store without a filename keeps the values only in memory.
+type Store struct {
+	lock     *sync.Mutex
+	filename string
+	delay    time.Duration
+	values   map[string]json.RawMessage
+	timer    *time.Timer
+	dirty    bool
+}
+
+func NewStore(filename string) *Store {
+	return &Store{
+		lock:     new(sync.Mutex),
+		filename: filename,
+		delay:    DefaultStoreDelay,
+		values:   make(map[string]json.RawMessage),
+	}
+}
+
+// LoadStore creates a store for the given state file and reads the values stored in the file, if it exists.
+func LoadStore(filename string) (*Store, error) {
+	result := NewStore(filename)
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("cannot read the state file: %w", err)
+	}
+	err = json.Unmarshal(data, &result.values)
+	if err != nil {
+		result.values = make(map[string]json.RawMessage)
+		return result, fmt.Errorf("cannot unmarshal the state file: %w", err)
+	}
+	return result, nil
+}
+
+// Get reads the value stored under the given key into value. It returns false if there is no valid value stored.
+func (s *Store) Get(key string, value any) bool {
+	s.lock.Lock()
+	data, ok := s.values[key]
+	s.lock.Unlock()
+	if !ok {
+		return false
+	}
+
+	err := json.Unmarshal(data, value)
+	if err != nil {
+		logger.Error("invalid stored value", "key", key, "error", err)
+		return false
+	}
+	return true
+}
+
+// Set stores the given value under the given key and schedules a write of the state file.
+func (s *Store) Set(key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		logger.Error("cannot store value", "key", key, "error", err)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.values[key] = data
+	s.dirty = true
+	if s.filename == "" || s.timer != nil {
+		return
+	}
+	s.timer = time.AfterFunc(s.delay, func() {
+		err := s.Flush()
+		if err != nil {
+			logger.Error("cannot save the runtime state", "error", err)
+		}
+	})
+}
+
+// Flush writes pending changes to the state file immediately.
+func (s *Store) Flush() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.filename == "" || !s.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal the state: %w", err)
+	}
+	err = writeFileAtomically(s.filename, data)
+	if err != nil {
+		return fmt.Errorf("cannot write the state file: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// writeFileAtomically writes the data into a temporary file next to the given file and replaces the file
+// with the temporary file, so the file is never left half written.
+func writeFileAtomically(filename string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempFilename := file.Name()
+	defer os.Remove(tempFilename)
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(tempFilename, filename)
+}
+
+// PersistentButton is implemented by buttons that keep their state across restarts.
+type PersistentButton interface {
+	Button
+	Restore(store ButtonStore)
+}
+
+// ButtonStore is the part of the store that belongs to one button.
+type ButtonStore struct {
+	store *Store
+	key   string
+}
+
+// buttonStoreKey identifies the button on the given key of the given page. The buttons of a profile are stored
+// separately, because different profiles may define pages with the same ID.
+func buttonStoreKey(profile string, pageID string, index int) string {
+	if profile == "" {
+		return fmt.Sprintf("buttons/%s/%d", pageID, index)
+	}
+	return fmt.Sprintf("buttons/%s/%s/%d", profile, pageID, index)
+}
+
+func (s ButtonStore) Get(value any) bool {
+	if s.store == nil {
+		return false
+	}
+	return s.store.Get(s.key, value)
+}
+
+func (s ButtonStore) Set(value any) {
+	if s.store == nil {
+		return
+	}
+	s.store.Set(s.key, value)
+}
+
+// SetStore sets the store that keeps the runtime state of the deck and its buttons across restarts.
+// It must be called before the configuration is read.
+func (d *HamDeck) SetStore(store *Store) {
+	d.store = store
+}
+
+// SetBrightness sets the brightness of the device and remembers it.
+func (d *HamDeck) SetBrightness(brightness int) error {
+	err := d.device.SetBrightness(brightness)
+	if err != nil {
+		return err
+	}
+	d.store.Set(storeKeyBrightness, brightness)
+	return nil
+}
+
+// StoredBrightness returns the brightness that was set the last time.
+func (d *HamDeck) StoredBrightness() (int, bool) {
+	var result int
+	ok := d.store.Get(storeKeyBrightness, &result)
+	return result, ok
+}
+
+func (d *HamDeck) restoreButton(pageID string, index int, button Button) {
+	persistent, ok := button.(PersistentButton)
+	if !ok {
+		return
+	}
+	persistent.Restore(ButtonStore{store: d.store, key: buttonStoreKey(d.ActiveProfile(), pageID, index)})
+}
+
+// restoredPageID returns the page that was shown the last time, if it is still defined, or the start page.
+func (d *HamDeck) restoredPageID() string {
+	var pageID string
+	if !d.store.Get(storeKeyPage, &pageID) {
+		return d.startPageID
+	}
+	if _, ok := d.pages[pageID]; !ok {
+		return d.startPageID
+	}
+	return pageID
+}
@@ -0,0 +1,110 @@
+package hamdeck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const persistTestConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": {
+			"buttons": [
+				{
+					"type": "hamdeck.Cycle",
+					"index": 0,
+					"states": [
+						{ "label": "A", "action": { "type": "test.Button" } },
+						{ "label": "B", "action": { "type": "test.Button" } }
+					]
+				},
+				{ "type": "hamdeck.Timer", "index": 1, "duration": 600 }
+			]
+		},
+		"other": {
+			"buttons": []
+		}
+	}
+}`
+
+func setupPersistTest(t *testing.T, store *Store) *HamDeck {
+	deck, _ := setupTestDeck(t, persistTestConfig, func(deck *HamDeck) {
+		deck.SetStore(store)
+	})
+	return deck
+}
+
+func TestStore_RestoresRuntimeState(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), DefaultStoreFilename)
+	store, err := LoadStore(filename)
+	require.NoError(t, err)
+
+	deck := setupPersistTest(t, store)
+	cycle := deck.pages["main"].buttons[0].(*CycleButton)
+	cycle.Pressed()
+	cycle.Released()
+	timer := deck.pages["main"].buttons[1].(*TimerButton)
+	timer.now = func() time.Time { return time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC) }
+	timer.Start()
+	timer.now = func() time.Time { return time.Date(2023, 1, 1, 12, 3, 0, 0, time.UTC) }
+	timer.Stop()
+	require.NoError(t, deck.SetBrightness(40))
+	require.NoError(t, deck.AttachPage("other"))
+	require.NoError(t, store.Flush())
+
+	store, err = LoadStore(filename)
+	require.NoError(t, err)
+	deck = setupPersistTest(t, store)
+
+	assert.Equal(t, "other", deck.CurrentPage())
+	brightness, ok := deck.StoredBrightness()
+	assert.True(t, ok)
+	assert.Equal(t, 40, brightness)
+	assert.Equal(t, 1, deck.pages["main"].buttons[0].(*CycleButton).Current())
+	timer = deck.pages["main"].buttons[1].(*TimerButton)
+	assert.False(t, timer.Running())
+	assert.Equal(t, 3*time.Minute, timer.Elapsed())
+}
+
+func TestStore_UnknownPageFallsBackToStartPage(t *testing.T) {
+	store := NewStore("")
+	store.Set(storeKeyPage, "gone")
+
+	deck := setupPersistTest(t, store)
+
+	assert.Equal(t, "main", deck.CurrentPage())
+}
+
+func TestStore_WritesDebounced(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), DefaultStoreFilename)
+	store := NewStore(filename)
+	store.delay = 50 * time.Millisecond
+
+	store.Set("a", 1)
+	store.Set("b", "two")
+	_, err := os.Stat(filename)
+	assert.True(t, os.IsNotExist(err), "written immediately")
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filename)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	store, err = LoadStore(filename)
+	require.NoError(t, err)
+	var a int
+	var b string
+	assert.True(t, store.Get("a", &a))
+	assert.True(t, store.Get("b", &b))
+	assert.Equal(t, 1, a)
+	assert.Equal(t, "two", b)
+
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files left behind")
+}
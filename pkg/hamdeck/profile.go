@@ -0,0 +1,331 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	ConfigProfiles = "profiles"
+	ConfigProfile  = "profile"
+)
+
+const (
+	ProfileButtonType       = "hamdeck.Profile"
+	ProfileStatusButtonType = "hamdeck.ProfileStatus"
+)
+
+// Profiles provides named configurations that can be loaded at runtime.
+type Profiles interface {
+	Names() []string
+	Load(name string) (map[string]any, error)
+}
+
+// ProfileDirectory provides every configuration file in the directory as a profile. The name of the profile is the
+// filename without extension, e.g. contest.yaml is the profile "contest".
+type ProfileDirectory string
+
+func (d ProfileDirectory) Names() []string {
+	result := make([]string, 0)
+	for name := range d.filenames() {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (d ProfileDirectory) Load(name string) (map[string]any, error) {
+	filename, ok := d.filenames()[name]
+	if !ok {
+		return nil, fmt.Errorf("no profile defined with name %s", name)
+	}
+	return LoadConfig(filename)
+}
+
+func (d ProfileDirectory) filenames() map[string]string {
+	result := make(map[string]string)
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		logger.Error("cannot read the profile directory", "directory", string(d), "error", err)
+		return result
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := ConfigFormatByFilename(entry.Name()); !ok {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, exists := result[name]; exists {
+			continue
+		}
+		result[name] = filepath.Join(string(d), entry.Name())
+	}
+	return result
+}
+
+// configProfiles are the profiles defined in the profiles section of the configuration. Each profile is merged
+// into the rest of the configuration, which is shared by all profiles. If a profile defines pages, they replace
+// the pages of the shared configuration.
+type configProfiles struct {
+	base     map[string]any
+	profiles map[string]map[string]any
+}
+
+func loadConfigProfiles(configuration map[string]any) (Profiles, error) {
+	rawProfiles, ok := configuration[ConfigProfiles]
+	if !ok {
+		return nil, nil
+	}
+	profiles, ok := rawProfiles.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("the profiles must be an object with one configuration per profile")
+	}
+
+	result := &configProfiles{
+		base:     make(map[string]any, len(configuration)),
+		profiles: make(map[string]map[string]any, len(profiles)),
+	}
+	for key, value := range configuration {
+		if key != ConfigProfiles {
+			result.base[key] = value
+		}
+	}
+	for name, rawProfile := range profiles {
+		profile, ok := rawProfile.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("the profile %s must be an object", name)
+		}
+		result.profiles[name] = profile
+	}
+	return result, nil
+}
+
+func (p *configProfiles) Names() []string {
+	result := make([]string, 0, len(p.profiles))
+	for name := range p.profiles {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (p *configProfiles) Load(name string) (map[string]any, error) {
+	profile, ok := p.profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no profile defined with name %s", name)
+	}
+	result := mergeConfig(p.base, profile)
+	_, havePages := profile[ConfigPages]
+	_, haveButtons := profile[ConfigButtons]
+	if havePages || haveButtons {
+		for _, key := range []string{ConfigPages, ConfigButtons} {
+			if value, ok := profile[key]; ok {
+				result[key] = value
+			} else {
+				delete(result, key)
+			}
+		}
+	}
+	return result, nil
+}
+
+// SetProfiles sets the profiles that can be switched at runtime. The profiles section of the configuration
+// takes precedence.
+func (d *HamDeck) SetProfiles(profiles Profiles) {
+	d.profiles = profiles
+}
+
+func (d *HamDeck) availableProfiles() Profiles {
+	if d.configProfiles != nil {
+		return d.configProfiles
+	}
+	return d.profiles
+}
+
+// ProfileNames returns the names of all available profiles in alphabetical order.
+func (d *HamDeck) ProfileNames() []string {
+	profiles := d.availableProfiles()
+	if profiles == nil {
+		return nil
+	}
+	return profiles.Names()
+}
+
+// ActiveProfile returns the name of the profile that is currently loaded, or an empty string if no profile was loaded.
+func (d *HamDeck) ActiveProfile() string {
+	d.profileLock.Lock()
+	defer d.profileLock.Unlock()
+	return d.activeProfile
+}
+
+// StoredProfile returns the profile that was active the last time.
+func (d *HamDeck) StoredProfile() (string, bool) {
+	var result string
+	ok := d.store.Get(storeKeyProfile, &result)
+	return result, ok && result != ""
+}
+
+// SwitchProfile loads the configuration of the given profile in place and shows its start page. Connections with
+// an unchanged configuration are kept. SwitchProfile must be called from the goroutine that runs the deck, use
+// RequestProfile from other goroutines.
+func (d *HamDeck) SwitchProfile(name string) error {
+	profiles := d.availableProfiles()
+	if profiles == nil {
+		return fmt.Errorf("no profiles defined")
+	}
+	configuration, err := profiles.Load(name)
+	if err != nil {
+		return err
+	}
+
+	d.profileLock.Lock()
+	previousProfile := d.activeProfile
+	d.activeProfile = name
+	d.profileLock.Unlock()
+
+	err = d.loadConfiguration(configuration)
+	if err != nil {
+		d.profileLock.Lock()
+		d.activeProfile = previousProfile
+		d.profileLock.Unlock()
+		return fmt.Errorf("cannot load the profile %s: %w", name, err)
+	}
+	d.store.Set(storeKeyProfile, name)
+	logger.Info("switched the profile", "profile", name)
+
+	err = d.AttachPage(d.startPageID)
+	if err != nil {
+		return err
+	}
+	d.rules.EvaluateAll()
+	return nil
+}
+
+// RequestProfile switches to the given profile in the goroutine that runs the deck. It is safe to call
+// RequestProfile from any goroutine.
+func (d *HamDeck) RequestProfile(name string) error {
+	return d.Do(func() error {
+		return d.SwitchProfile(name)
+	})
+}
+
+// nextProfile returns the profile that follows the active profile in alphabetical order.
+func (d *HamDeck) nextProfile() (string, bool) {
+	names := d.ProfileNames()
+	if len(names) == 0 {
+		return "", false
+	}
+	active := d.ActiveProfile()
+	for i, name := range names {
+		if name == active {
+			return names[(i+1)%len(names)], true
+		}
+	}
+	return names[0], true
+}
+
+/*
+	ProfileButton
+*/
+
+// ProfileButton loads the given profile. The button is highlighted while the profile is active.
+func NewProfileButton(deck *HamDeck, profile string, label string) *ProfileButton {
+	if label == "" {
+		label = profile
+	}
+	return &ProfileButton{
+		deck:    deck,
+		profile: profile,
+		label:   label,
+	}
+}
+
+type ProfileButton struct {
+	BaseButton
+	deck    *HamDeck
+	image   image.Image
+	profile string
+	label   string
+}
+
+func (b *ProfileButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	if b.image == nil || redrawImages {
+		gc.SetForeground(White)
+		gc.SetBackground(Black)
+		if b.deck.ActiveProfile() == b.profile {
+			gc.SwapColors()
+		}
+		b.image = gc.DrawSingleLineTextButton(b.label)
+	}
+	return b.image
+}
+
+func (b *ProfileButton) Pressed() {
+	if b.deck.ActiveProfile() == b.profile {
+		return
+	}
+	err := b.deck.SwitchProfile(b.profile)
+	if err != nil {
+		logger.Error("cannot switch the profile", "profile", b.profile, "error", err)
+	}
+}
+
+func (b *ProfileButton) Released() {
+	// nop
+}
+
+/*
+	ProfileStatusButton
+*/
+
+// ProfileStatusButton shows the active profile. Pressing the button switches to the next profile.
+func NewProfileStatusButton(deck *HamDeck, label string) *ProfileStatusButton {
+	if label == "" {
+		label = "Profile"
+	}
+	return &ProfileStatusButton{
+		deck:  deck,
+		label: label,
+	}
+}
+
+type ProfileStatusButton struct {
+	BaseButton
+	deck  *HamDeck
+	image image.Image
+	label string
+}
+
+func (b *ProfileStatusButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	if b.image == nil || redrawImages {
+		gc.SetForeground(White)
+		gc.SetBackground(Black)
+		profile := b.deck.ActiveProfile()
+		if profile == "" {
+			profile = "-"
+		}
+		b.image = gc.DrawDoubleLineToggleTextButton(b.label, profile, 2)
+	}
+	return b.image
+}
+
+func (b *ProfileStatusButton) Pressed() {
+	next, ok := b.deck.nextProfile()
+	if !ok {
+		return
+	}
+	err := b.deck.SwitchProfile(next)
+	if err != nil {
+		logger.Error("cannot switch the profile", "profile", next, "error", err)
+	}
+}
+
+func (b *ProfileStatusButton) Released() {
+	// nop
+}
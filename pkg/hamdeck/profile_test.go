@@ -0,0 +1,171 @@
+package hamdeck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profileTestConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": {
+			"buttons": [
+				{ "type": "hamdeck.ProfileStatus", "index": 0 },
+				{ "type": "hamdeck.Profile", "index": 1, "profile": "contest", "label": "Contest" }
+			]
+		}
+	},
+	"profiles": {
+		"contest": {
+			"start_page": "run",
+			"pages": {
+				"run": {
+					"buttons": [
+						{ "type": "hamdeck.ProfileStatus", "index": 0 },
+						{ "type": "hamdeck.Profile", "index": 1, "profile": "ragchew" }
+					]
+				}
+			}
+		},
+		"ragchew": {}
+	}
+}`
+
+func setupProfileTest(t *testing.T, store *Store) *HamDeck {
+	deck, _ := setupTestDeck(t, profileTestConfig, func(deck *HamDeck) {
+		deck.SetStore(store)
+	})
+	return deck
+}
+
+func TestProfiles_FromConfiguration(t *testing.T) {
+	deck := setupProfileTest(t, NewStore(""))
+
+	assert.Equal(t, []string{"contest", "ragchew"}, deck.ProfileNames())
+	assert.Equal(t, "", deck.ActiveProfile())
+	assert.Equal(t, "main", deck.CurrentPage())
+
+	deck.buttons[1].Pressed()
+	assert.Equal(t, "contest", deck.ActiveProfile())
+	assert.Equal(t, "run", deck.CurrentPage())
+	assert.NotContains(t, deck.pages, "main", "the pages of the profile replace the pages of the base configuration")
+
+	deck.buttons[1].Pressed()
+	assert.Equal(t, "ragchew", deck.ActiveProfile())
+	assert.Equal(t, "main", deck.CurrentPage())
+	assert.NotContains(t, deck.pages, "run")
+}
+
+func TestProfiles_StoreTheButtonsOfEachProfileSeparately(t *testing.T) {
+	cycle := `{ "type": "hamdeck.Cycle", "index": 2, "states": [ { "label": "A" }, { "label": "B" } ] }`
+	deck, _ := setupTestDeck(t, `{
+		"start_page": "main",
+		"pages": { "main": { "buttons": [] } },
+		"profiles": {
+			"contest": { "pages": { "main": { "buttons": [`+cycle+`] } } },
+			"ragchew": { "pages": { "main": { "buttons": [`+cycle+`] } } }
+		}
+	}`, func(deck *HamDeck) {
+		deck.SetStore(NewStore(""))
+	})
+
+	require.NoError(t, deck.SwitchProfile("contest"))
+	deck.buttons[2].Pressed()
+	deck.buttons[2].Released()
+	assert.Equal(t, 1, deck.buttons[2].(*CycleButton).Current())
+
+	require.NoError(t, deck.SwitchProfile("ragchew"))
+	assert.Equal(t, 0, deck.buttons[2].(*CycleButton).Current())
+
+	require.NoError(t, deck.SwitchProfile("contest"))
+	assert.Equal(t, 1, deck.buttons[2].(*CycleButton).Current())
+}
+
+func TestProfiles_StatusButtonSwitchesToTheNextProfile(t *testing.T) {
+	deck := setupProfileTest(t, NewStore(""))
+
+	deck.buttons[0].Pressed()
+	assert.Equal(t, "contest", deck.ActiveProfile())
+	deck.buttons[0].Pressed()
+	assert.Equal(t, "ragchew", deck.ActiveProfile())
+	deck.buttons[0].Pressed()
+	assert.Equal(t, "contest", deck.ActiveProfile())
+}
+
+func TestProfiles_UnknownProfileKeepsTheActiveProfile(t *testing.T) {
+	deck := setupProfileTest(t, NewStore(""))
+	require.NoError(t, deck.SwitchProfile("contest"))
+
+	err := deck.SwitchProfile("dxpedition")
+
+	assert.Error(t, err)
+	assert.Equal(t, "contest", deck.ActiveProfile())
+	assert.Equal(t, "run", deck.CurrentPage())
+}
+
+func TestProfiles_RemembersTheActiveProfile(t *testing.T) {
+	store := NewStore("")
+	deck := setupProfileTest(t, store)
+	_, ok := deck.StoredProfile()
+	assert.False(t, ok)
+
+	require.NoError(t, deck.SwitchProfile("contest"))
+
+	deck = setupProfileTest(t, store)
+	profile, ok := deck.StoredProfile()
+	assert.True(t, ok)
+	assert.Equal(t, "contest", profile)
+}
+
+func TestProfiles_ReloadKeepsTheActiveProfileAndLoadsItOnce(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "hamdeck.json")
+	require.NoError(t, os.WriteFile(filename, []byte(profileTestConfig), 0644))
+	deck := setupProfileTest(t, NewStore(""))
+	require.NoError(t, deck.SwitchProfile("contest"))
+	generation := deck.configGeneration
+
+	err := deck.ReloadConfigFile(filename)
+
+	require.NoError(t, err)
+	assert.Equal(t, "contest", deck.ActiveProfile())
+	assert.Equal(t, "run", deck.CurrentPage())
+	assert.Equal(t, generation+1, deck.configGeneration)
+}
+
+func TestProfiles_RequestProfileSwitchesInTheRunLoop(t *testing.T) {
+	deck := setupProfileTest(t, NewStore(""))
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		deck.Run(stop)
+	}()
+
+	err := deck.RequestProfile("contest")
+	assert.NoError(t, err)
+	assert.Equal(t, "run", deck.CurrentPage())
+
+	close(stop)
+	<-done
+	assert.Equal(t, "contest", deck.ActiveProfile())
+}
+
+func TestProfileDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "contest.yaml"), []byte("start_page: run\npages:\n  run:\n    buttons: []\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ragchew.json"), []byte(`{"pages": {"main": {"buttons": []}}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a profile"), 0644))
+
+	deck, _ := setupTestDeck(t, `{"pages": {"main": {"buttons": []}}, "start_page": "main"}`, func(deck *HamDeck) {
+		deck.SetProfiles(ProfileDirectory(dir))
+	})
+
+	assert.Equal(t, []string{"contest", "ragchew"}, deck.ProfileNames())
+	require.NoError(t, deck.SwitchProfile("contest"))
+	assert.Equal(t, "run", deck.CurrentPage())
+	assert.Error(t, deck.SwitchProfile("notes"))
+}
@@ -0,0 +1,292 @@
+package hamdeck
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+/* Recording */
+
+// RecordingHeader is the first line of a recording and describes the device on which the keys were recorded.
+type RecordingHeader struct {
+	Time    time.Time `json:"time"`
+	ID      string    `json:"id,omitempty"`
+	Serial  string    `json:"serial,omitempty"`
+	Pixels  int       `json:"pixels"`
+	Rows    int       `json:"rows"`
+	Columns int       `json:"columns"`
+}
+
+// RecordedKey is a key event in a recording. Offset is the time in milliseconds since the recording started.
+type RecordedKey struct {
+	Offset  int64 `json:"offset"`
+	Index   int   `json:"index"`
+	Pressed bool  `json:"pressed"`
+}
+
+func (k RecordedKey) Key() Key {
+	return Key{Index: k.Index, Pressed: k.Pressed}
+}
+
+// Recording is a sequence of key events with their timing, stored as JSON lines: the header, then one line per key.
+type Recording struct {
+	Header RecordingHeader
+	Keys   []RecordedKey
+}
+
+// LoadRecording reads the recording from the given file.
+func LoadRecording(filename string) (*Recording, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the recording: %w", err)
+	}
+	defer file.Close()
+	return ReadRecording(file)
+}
+
+// ReadRecording reads a recording from the given reader.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	result := new(Recording)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var err error
+		if line == 1 {
+			err = json.Unmarshal(scanner.Bytes(), &result.Header)
+		} else {
+			var key RecordedKey
+			err = json.Unmarshal(scanner.Bytes(), &key)
+			result.Keys = append(result.Keys, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording in line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the recording: %w", err)
+	}
+	if line == 0 {
+		return nil, fmt.Errorf("the recording is empty")
+	}
+	return result, nil
+}
+
+/* Recorder */
+
+// Recorder is a Device that writes all key events of the wrapped device with their timing into a recording.
+type Recorder struct {
+	Device
+
+	lock  *sync.Mutex
+	out   io.WriteCloser
+	start time.Time
+	now   func() time.Time
+}
+
+// RecordKeys creates a new recording in the given file with the key events of the given device.
+func RecordKeys(device Device, filename string) (*Recorder, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the recording: %w", err)
+	}
+	result, err := NewRecorder(device, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return result, nil
+}
+
+// NewRecorder writes the key events of the given device into the given writer. The writer is closed when
+// the recorder is closed.
+func NewRecorder(device Device, out io.WriteCloser) (*Recorder, error) {
+	result := &Recorder{
+		Device: device,
+		lock:   new(sync.Mutex),
+		out:    out,
+		now:    time.Now,
+	}
+	result.start = result.now()
+	err := result.write(RecordingHeader{
+		Time:    result.start,
+		ID:      device.ID(),
+		Serial:  device.Serial(),
+		Pixels:  device.Pixels(),
+		Rows:    device.Rows(),
+		Columns: device.Columns(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *Recorder) write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("cannot marshal the recording: %w", err)
+	}
+	data = append(data, '\n')
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.out == nil {
+		return nil
+	}
+	_, err = r.out.Write(data)
+	if err != nil {
+		return fmt.Errorf("cannot write the recording: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) ReadKeys() (chan Key, error) {
+	keys, err := r.Device.ReadKeys()
+	if err != nil {
+		return nil, err
+	}
+	result := make(chan Key)
+	go func() {
+		defer close(result)
+		for key := range keys {
+			err := r.write(RecordedKey{
+				Offset:  r.now().Sub(r.start).Milliseconds(),
+				Index:   key.Index,
+				Pressed: key.Pressed,
+			})
+			if err != nil {
+				logger.Error("cannot record key", "error", err)
+			}
+			result <- key
+		}
+	}()
+	return result, nil
+}
+
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	out := r.out
+	r.out = nil
+	r.lock.Unlock()
+
+	err := r.Device.Close()
+	if out != nil {
+		outErr := out.Close()
+		if err == nil {
+			err = outErr
+		}
+	}
+	return err
+}
+
+/* ReplayDevice */
+
+// ReplayFrame is an image that was set on a key of the ReplayDevice.
+type ReplayFrame struct {
+	Index int
+	Image image.Image
+}
+
+// ReplayDevice is a Device that plays back a recording. It closes its key channel after the last key, which stops
+// HamDeck.Run. All images set on the device are kept as frames.
+type ReplayDevice struct {
+	recording *Recording
+	speed     float64
+
+	lock   *sync.Mutex
+	frames []ReplayFrame
+	closed chan struct{}
+	once   *sync.Once
+}
+
+// NewReplayDevice creates a device that plays back the given recording in real time.
+func NewReplayDevice(recording *Recording) *ReplayDevice {
+	return &ReplayDevice{
+		recording: recording,
+		speed:     1,
+		lock:      new(sync.Mutex),
+		closed:    make(chan struct{}),
+		once:      new(sync.Once),
+	}
+}
+
+// SetSpeed sets the factor for the playback speed, e.g. 2 plays back twice as fast. With a speed <= 0
+// the keys are played back without any delay.
+func (d *ReplayDevice) SetSpeed(speed float64) {
+	d.speed = speed
+}
+
+func (d *ReplayDevice) Close() error {
+	d.once.Do(func() {
+		close(d.closed)
+	})
+	return nil
+}
+
+func (d *ReplayDevice) ID() string              { return d.recording.Header.ID }
+func (d *ReplayDevice) Serial() string          { return d.recording.Header.Serial }
+func (d *ReplayDevice) FirmwareVersion() string { return "replay" }
+func (d *ReplayDevice) Pixels() int             { return d.recording.Header.Pixels }
+func (d *ReplayDevice) Rows() int               { return d.recording.Header.Rows }
+func (d *ReplayDevice) Columns() int            { return d.recording.Header.Columns }
+func (d *ReplayDevice) Clear() error            { return nil }
+func (d *ReplayDevice) Reset() error            { return nil }
+func (d *ReplayDevice) SetBrightness(int) error { return nil }
+
+func (d *ReplayDevice) SetImage(index int, img image.Image) error {
+	var frame image.Image
+	if img != nil {
+		copied := image.NewRGBA(img.Bounds())
+		draw.Draw(copied, copied.Bounds(), img, img.Bounds().Min, draw.Src)
+		frame = copied
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.frames = append(d.frames, ReplayFrame{Index: index, Image: frame})
+	return nil
+}
+
+// Frames returns all images that were set on the device so far, in the order they were set.
+func (d *ReplayDevice) Frames() []ReplayFrame {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	result := make([]ReplayFrame, len(d.frames))
+	copy(result, d.frames)
+	return result
+}
+
+func (d *ReplayDevice) ReadKeys() (chan Key, error) {
+	result := make(chan Key)
+	go func() {
+		defer close(result)
+		start := time.Now()
+		for _, key := range d.recording.Keys {
+			if d.speed > 0 {
+				due := start.Add(time.Duration(float64(key.Offset) * float64(time.Millisecond) / d.speed))
+				select {
+				case <-time.After(time.Until(due)):
+				case <-d.closed:
+					return
+				}
+			}
+			select {
+			case result <- key.Key():
+			case <-d.closed:
+				return
+			}
+		}
+	}()
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package hamdeck
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closingBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestRecorder_Roundtrip(t *testing.T) {
+	device := newTestDevice(72, 3, 5)
+	device.serial = "AL12345"
+	out := new(closingBuffer)
+	recorder, err := NewRecorder(device, out)
+	require.NoError(t, err)
+	times := []time.Time{recorder.start.Add(250 * time.Millisecond), recorder.start.Add(350 * time.Millisecond)}
+	recorder.now = func() time.Time {
+		result := times[0]
+		times = times[1:]
+		return result
+	}
+
+	keys, err := recorder.ReadKeys()
+	require.NoError(t, err)
+	go func() {
+		device.Press(3)
+		device.Release(3)
+		close(device.keys)
+	}()
+	assert.Equal(t, Key{Index: 3, Pressed: true}, <-keys)
+	assert.Equal(t, Key{Index: 3, Pressed: false}, <-keys)
+	_, ok := <-keys
+	assert.False(t, ok)
+	require.NoError(t, recorder.Close())
+	assert.True(t, out.closed)
+
+	recording, err := ReadRecording(&out.Buffer)
+	require.NoError(t, err)
+	assert.Equal(t, "AL12345", recording.Header.Serial)
+	assert.Equal(t, 72, recording.Header.Pixels)
+	assert.Equal(t, 3, recording.Header.Rows)
+	assert.Equal(t, 5, recording.Header.Columns)
+	assert.Equal(t, []RecordedKey{
+		{Offset: 250, Index: 3, Pressed: true},
+		{Offset: 350, Index: 3, Pressed: false},
+	}, recording.Keys)
+}
+
+func TestReadRecording_Invalid(t *testing.T) {
+	_, err := ReadRecording(strings.NewReader(""))
+	assert.Error(t, err)
+
+	_, err = ReadRecording(strings.NewReader("{\"pixels\":72,\"rows\":3,\"columns\":5}\n{\"offset\":\"soon\"}\n"))
+	assert.Error(t, err)
+}
+
+func TestReplayDevice_PlaysBackTheRecording(t *testing.T) {
+	recording, err := ReadRecording(strings.NewReader(`{"pixels":128,"rows":4,"columns":8}
+{"offset":0,"index":12,"pressed":true}
+{"offset":20,"index":12,"pressed":false}
+`))
+	require.NoError(t, err)
+	device := NewReplayDevice(recording)
+	deck := New(device)
+	deck.RegisterFactory(new(testButtonFactory))
+	require.NoError(t, deck.ReadConfig(strings.NewReader(`{"buttons": [{"type": "test.Button", "index": 12}]}`)))
+	button := deck.buttons[12].(*testButton)
+
+	start := time.Now()
+	require.NoError(t, deck.Run(nil))
+
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.True(t, button.pressed)
+	assert.True(t, button.released)
+	frames := device.Frames()
+	require.Len(t, frames, 32+32)
+	assert.Nil(t, frames[len(frames)-32+12].Image)
+	assert.NotNil(t, frames[0].Image)
+}
+
+func TestReplayDevice_Close(t *testing.T) {
+	recording := &Recording{
+		Header: RecordingHeader{Pixels: 72, Rows: 3, Columns: 5},
+		Keys:   []RecordedKey{{Offset: 60000, Index: 1, Pressed: true}},
+	}
+	device := NewReplayDevice(recording)
+	keys, err := device.ReadKeys()
+	require.NoError(t, err)
+
+	device.Close()
+	_, ok := <-keys
+	assert.False(t, ok)
+}
@@ -0,0 +1,263 @@
+package hamdeck
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	ConfigRules      = "rules"
+	ConfigConditions = "conditions"
+	ConfigState      = "state"
+	ConfigEquals     = "equals"
+	ConfigNotEquals  = "not_equals"
+	ConfigOneOf      = "one_of"
+	ConfigAbove      = "above"
+	ConfigBelow      = "below"
+	ConfigRestore    = "restore"
+)
+
+/*
+	Condition
+*/
+
+// Condition describes a constraint on a single state value of a connection.
+// A condition without any comparison is fulfilled if the state value is "true".
+type Condition struct {
+	Connection string
+	State      string
+	Equals     []string
+	NotEquals  []string
+	Above      *float64
+	Below      *float64
+}
+
+func ParseCondition(config map[string]any) (Condition, error) {
+	result := Condition{}
+	var ok bool
+	result.Connection, ok = ToString(config[ConfigConnection])
+	if !ok {
+		return Condition{}, fmt.Errorf("a condition must have a connection field")
+	}
+	result.State, ok = ToString(config[ConfigState])
+	if !ok {
+		return Condition{}, fmt.Errorf("a condition must have a state field")
+	}
+
+	if equals, ok := ToString(config[ConfigEquals]); ok {
+		result.Equals = append(result.Equals, equals)
+	}
+	if oneOf, ok := ToStringArray(config[ConfigOneOf]); ok {
+		result.Equals = append(result.Equals, oneOf...)
+	}
+	if notEquals, ok := ToString(config[ConfigNotEquals]); ok {
+		result.NotEquals = append(result.NotEquals, notEquals)
+	}
+	if above, ok := ToFloat(config[ConfigAbove]); ok {
+		result.Above = &above
+	}
+	if below, ok := ToFloat(config[ConfigBelow]); ok {
+		result.Below = &below
+	}
+
+	if len(result.Equals) == 0 && len(result.NotEquals) == 0 && result.Above == nil && result.Below == nil {
+		result.Equals = []string{"true"}
+	}
+
+	return result, nil
+}
+
+func (c Condition) Matches(connection string, name string) bool {
+	return c.Connection == connection && c.State == name
+}
+
+func (c Condition) Evaluate(value string, available bool) bool {
+	if !available {
+		return false
+	}
+	if len(c.Equals) > 0 && !containsFold(c.Equals, value) {
+		return false
+	}
+	if len(c.NotEquals) > 0 && containsFold(c.NotEquals, value) {
+		return false
+	}
+	if c.Above != nil || c.Below != nil {
+		number, ok := ToFloat(value)
+		if !ok {
+			return false
+		}
+		if c.Above != nil && number <= *c.Above {
+			return false
+		}
+		if c.Below != nil && number >= *c.Below {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+	Rules
+*/
+
+// A Rule attaches a page as long as all its conditions are fulfilled.
+type Rule struct {
+	Conditions []Condition
+	PageID     string
+	Restore    bool
+
+	active   bool
+	returnTo string
+}
+
+func ParseRule(config map[string]any) (*Rule, error) {
+	pageID, ok := ToString(config[ConfigPage])
+	if !ok {
+		return nil, fmt.Errorf("a rule must have a page field")
+	}
+	restore, _ := ToBool(config[ConfigRestore])
+
+	rawConditions, ok := config[ConfigConditions].([]any)
+	if !ok || len(rawConditions) == 0 {
+		return nil, fmt.Errorf("a rule must have at least one condition")
+	}
+	conditions := make([]Condition, 0, len(rawConditions))
+	for i, rawCondition := range rawConditions {
+		conditionConfig, ok := rawCondition.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("conditions[%d] is not a condition object", i)
+		}
+		condition, err := ParseCondition(conditionConfig)
+		if err != nil {
+			return nil, fmt.Errorf("conditions[%d]: %w", i, err)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return &Rule{
+		Conditions: conditions,
+		PageID:     pageID,
+		Restore:    restore,
+	}, nil
+}
+
+func (r *Rule) dependsOn(connection string, name string) bool {
+	for _, condition := range r.Conditions {
+		if condition.Matches(connection, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) evaluate(state *State) bool {
+	for _, condition := range r.Conditions {
+		value, available := state.Get(condition.Connection, condition.State)
+		if !condition.Evaluate(value, available) {
+			return false
+		}
+	}
+	return true
+}
+
+type ruleEngine struct {
+	deck  *HamDeck
+	lock  *sync.Mutex
+	rules []*Rule
+}
+
+func newRuleEngine(deck *HamDeck) *ruleEngine {
+	return &ruleEngine{
+		deck: deck,
+		lock: new(sync.Mutex),
+	}
+}
+
+func (e *ruleEngine) Add(rule *Rule) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.rules = append(e.rules, rule)
+}
+
+func (e *ruleEngine) Clear() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.rules = nil
+}
+
+// StateChanged evaluates the rules that depend on the changed state in the goroutine that runs the deck, since
+// the rules attach pages.
+func (e *ruleEngine) StateChanged(connection string, name string, _ string) {
+	e.deck.post(func() {
+		e.evaluate(connection, name)
+	})
+}
+
+func (e *ruleEngine) evaluate(connection string, name string) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, rule := range e.rules {
+		if rule.dependsOn(connection, name) {
+			e.update(rule)
+		}
+	}
+}
+
+// EvaluateAll evaluates all rules. It must be called from the goroutine that runs the deck, or before the deck runs.
+func (e *ruleEngine) EvaluateAll() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, rule := range e.rules {
+		e.update(rule)
+	}
+}
+
+func (e *ruleEngine) update(rule *Rule) {
+	active := rule.evaluate(e.deck.state)
+	if active == rule.active {
+		return
+	}
+	rule.active = active
+
+	currentPageID := e.deck.CurrentPage()
+	if active {
+		rule.returnTo = currentPageID
+		if currentPageID == rule.PageID {
+			return
+		}
+		err := e.deck.AttachPage(rule.PageID)
+		if err != nil {
+			logger.Error("cannot attach page of rule", "error", err)
+		}
+		return
+	}
+
+	if !rule.Restore {
+		return
+	}
+	if currentPageID != rule.PageID {
+		// the page was switched in between, let the other active rules skip this rule's page
+		for _, other := range e.rules {
+			if other.active && other.returnTo == rule.PageID {
+				other.returnTo = rule.returnTo
+			}
+		}
+		return
+	}
+	err := e.deck.AttachPage(rule.returnTo)
+	if err != nil {
+		logger.Error("cannot restore page after rule", "error", err)
+	}
+}
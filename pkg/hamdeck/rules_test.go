@@ -0,0 +1,150 @@
+package hamdeck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const rulesTestConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": {
+			"buttons": [
+				{ "type": "test.Button", "index": 0, "page": "main" }
+			]
+		},
+		"cw": {
+			"buttons": [
+				{ "type": "test.Button", "index": 0, "page": "cw" }
+			]
+		},
+		"tx": {
+			"buttons": [
+				{ "type": "test.Button", "index": 0, "page": "tx" }
+			]
+		}
+	},
+	"rules": [
+		{
+			"conditions": [{ "connection": "rig", "state": "mode", "equals": "CW" }],
+			"page": "cw",
+			"restore": true
+		},
+		{
+			"conditions": [
+				{ "connection": "rig", "state": "ptt" },
+				{ "connection": "rig", "state": "frequency", "above": 14000000, "below": 14350000 }
+			],
+			"page": "tx",
+			"restore": true
+		}
+	]
+}`
+
+func TestRules_AttachPageWhenConditionIsFulfilled(t *testing.T) {
+	runWithConfigString(t, rulesTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		waitForQueue(t, deck)
+		assert.Equal(t, "main", deck.CurrentPage())
+
+		deck.PublishState("rig", StateMode, "USB")
+		waitForQueue(t, deck)
+		assert.Equal(t, "main", deck.CurrentPage())
+
+		deck.PublishState("rig", StateMode, "cw")
+		waitForQueue(t, deck)
+		assert.Equal(t, "cw", deck.CurrentPage())
+		assert.Equal(t, "cw", deck.buttons[0].(*testButton).config["page"])
+
+		deck.PublishState("rig", StateMode, "USB")
+		waitForQueue(t, deck)
+		assert.Equal(t, "main", deck.CurrentPage())
+	})
+}
+
+func TestRules_AllConditionsMustBeFulfilled(t *testing.T) {
+	runWithConfigString(t, rulesTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		deck.PublishState("rig", StatePTT, "true")
+		waitForQueue(t, deck)
+		assert.Equal(t, "main", deck.CurrentPage())
+
+		deck.PublishState("rig", StateFrequency, "7050000")
+		waitForQueue(t, deck)
+		assert.Equal(t, "main", deck.CurrentPage())
+
+		deck.PublishState("rig", StateFrequency, "14050000")
+		waitForQueue(t, deck)
+		assert.Equal(t, "tx", deck.CurrentPage())
+
+		deck.PublishState("rig", StatePTT, "false")
+		waitForQueue(t, deck)
+		assert.Equal(t, "main", deck.CurrentPage())
+	})
+}
+
+func TestRules_RestoreNestedRules(t *testing.T) {
+	runWithConfigString(t, rulesTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		deck.PublishState("rig", StateFrequency, "14050000")
+		deck.PublishState("rig", StateMode, "CW")
+		waitForQueue(t, deck)
+		assert.Equal(t, "cw", deck.CurrentPage())
+
+		deck.PublishState("rig", StatePTT, "true")
+		waitForQueue(t, deck)
+		assert.Equal(t, "tx", deck.CurrentPage())
+
+		deck.PublishState("rig", StatePTT, "false")
+		waitForQueue(t, deck)
+		assert.Equal(t, "cw", deck.CurrentPage())
+
+		deck.PublishState("rig", StatePTT, "true")
+		deck.PublishState("rig", StateMode, "USB")
+		waitForQueue(t, deck)
+		assert.Equal(t, "tx", deck.CurrentPage())
+
+		deck.PublishState("rig", StatePTT, "false")
+		waitForQueue(t, deck)
+		assert.Equal(t, "main", deck.CurrentPage())
+	})
+}
+
+func TestRules_NoRestoreAfterManualPageSwitch(t *testing.T) {
+	runWithConfigString(t, rulesTestConfig, func(t *testing.T, deck *HamDeck, device *testDevice, _ chan struct{}) {
+		deck.PublishState("rig", StateMode, "CW")
+		waitForQueue(t, deck)
+		assert.Equal(t, "cw", deck.CurrentPage())
+
+		err := deck.AttachPage("tx")
+		assert.NoError(t, err)
+
+		deck.PublishState("rig", StateMode, "USB")
+		waitForQueue(t, deck)
+		assert.Equal(t, "tx", deck.CurrentPage())
+	})
+}
+
+func TestCondition_Evaluate(t *testing.T) {
+	above := 1.5
+	tt := []struct {
+		desc      string
+		condition Condition
+		value     string
+		available bool
+		expected  bool
+	}{
+		{"not available", Condition{Equals: []string{"true"}}, "true", false, false},
+		{"equals", Condition{Equals: []string{"true"}}, "true", true, true},
+		{"equals ignores case", Condition{Equals: []string{"CW"}}, "cw", true, true},
+		{"one of", Condition{Equals: []string{"USB", "LSB"}}, "LSB", true, true},
+		{"not one of", Condition{Equals: []string{"USB", "LSB"}}, "CW", true, false},
+		{"not equals", Condition{NotEquals: []string{"CW"}}, "USB", true, true},
+		{"above", Condition{Above: &above}, "2.1", true, true},
+		{"not above", Condition{Above: &above}, "1.5", true, false},
+		{"not a number", Condition{Above: &above}, "high", true, false},
+	}
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.condition.Evaluate(tc.value, tc.available))
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	ConfigSchedules  = "schedules"
+	ConfigName       = "name"
+	ConfigCron       = "cron"
+	ConfigTimezone   = "timezone"
+	ConfigBrightness = "brightness"
+	ConfigRemind     = "remind"
+	ConfigSchedule   = "schedule"
+)
+
+const NextEventButtonType = "hamdeck.NextEvent"
+
+/*
+	Schedule
+*/
+
+// A Schedule triggers a page switch, an action, a brightness change, or a reminder at the times given by
+// a cron expression, e.g. "0 6 * * *" for every day at 06:00, or a descriptor like "@hourly".
+type Schedule struct {
+	Name       string
+	Expression string
+	PageID     string
+	Action     Button
+	Brightness *int
+	Remind     bool
+
+	schedule cron.Schedule
+	location *time.Location
+	next     time.Time
+}
+
+func (d *HamDeck) parseSchedule(config map[string]any) (*Schedule, error) {
+	expression, ok := ToString(config[ConfigCron])
+	if !ok {
+		return nil, fmt.Errorf("a schedule must have a cron field")
+	}
+	location := time.Local
+	if timezone, ok := ToString(config[ConfigTimezone]); ok {
+		var err error
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %s: %w", timezone, err)
+		}
+	}
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expression, err)
+	}
+
+	result := &Schedule{
+		Expression: expression,
+		schedule:   schedule,
+		location:   location,
+	}
+	result.Name, ok = ToString(config[ConfigName])
+	if !ok {
+		result.Name = expression
+	}
+	result.PageID, _ = ToString(config[ConfigPage])
+	if brightness, ok := ToInt(config[ConfigBrightness]); ok {
+		result.Brightness = &brightness
+	}
+	result.Remind, _ = ToBool(config[ConfigRemind])
+	if actionConfig, ok := config[ConfigAction].(map[string]any); ok {
+		result.Action = d.createAction(actionConfig)
+		if result.Action == nil {
+			return nil, fmt.Errorf("cannot create the action of schedule %s", result.Name)
+		}
+		result.Action.Attached(&scheduleContext{deck: d, schedule: result.Name, info: newButtonInfo(actionConfig)})
+	}
+
+	if result.PageID == "" && result.Action == nil && result.Brightness == nil && !result.Remind {
+		return nil, fmt.Errorf("the schedule %s does nothing, it needs a page, action, brightness, or remind field", result.Name)
+	}
+	return result, nil
+}
+
+// Next returns the next time after the given time at which the schedule is triggered.
+func (s *Schedule) Next(after time.Time) time.Time {
+	return s.schedule.Next(after.In(s.location))
+}
+
+// ScheduledEvent is the next time a schedule is triggered.
+type ScheduledEvent struct {
+	Name string
+	Time time.Time
+}
+
+/*
+	scheduler
+*/
+
+// scheduler triggers the schedules in the goroutine that runs the deck. The timer fires when the next schedule
+// is due.
+type scheduler struct {
+	deck      *HamDeck
+	lock      *sync.Mutex
+	now       func() time.Time
+	timer     *time.Timer
+	schedules []*Schedule
+	reminder  string
+}
+
+func newScheduler(deck *HamDeck) *scheduler {
+	result := &scheduler{
+		deck:  deck,
+		lock:  new(sync.Mutex),
+		now:   time.Now,
+		timer: time.NewTimer(time.Hour),
+	}
+	result.timer.Stop()
+	return result
+}
+
+func (s *scheduler) Add(schedule *Schedule) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	schedule.next = schedule.Next(s.now())
+	s.schedules = append(s.schedules, schedule)
+	s.arm()
+}
+
+// Clear removes all schedules and closes their actions.
+func (s *scheduler) Clear() {
+	s.lock.Lock()
+	schedules := s.schedules
+	s.schedules = nil
+	s.reminder = ""
+	s.arm()
+	s.lock.Unlock()
+
+	for _, schedule := range schedules {
+		if schedule.Action != nil {
+			schedule.Action.Detached()
+			CloseButton(schedule.Action)
+		}
+	}
+}
+
+// C returns the channel on which the time is sent when the next schedule is due.
+func (s *scheduler) C() <-chan time.Time {
+	return s.timer.C
+}
+
+// arm sets the timer to the earliest next time of all schedules. The caller must hold the lock.
+func (s *scheduler) arm() {
+	if !s.timer.Stop() {
+		select {
+		case <-s.timer.C:
+		default:
+		}
+	}
+	var next time.Time
+	for _, schedule := range s.schedules {
+		if next.IsZero() || schedule.next.Before(next) {
+			next = schedule.next
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	s.timer.Reset(next.Sub(s.now()))
+}
+
+// Trigger triggers all schedules that are due and sets the timer to the next schedule.
+func (s *scheduler) Trigger() {
+	s.lock.Lock()
+	now := s.now()
+	due := make([]*Schedule, 0)
+	for _, schedule := range s.schedules {
+		if schedule.next.After(now) {
+			continue
+		}
+		due = append(due, schedule)
+		schedule.next = schedule.Next(now)
+		if schedule.Remind {
+			s.reminder = schedule.Name
+		}
+	}
+	s.arm()
+	s.lock.Unlock()
+
+	for _, schedule := range due {
+		s.trigger(schedule)
+	}
+	if len(due) > 0 {
+		s.deck.RedrawAll(false)
+	}
+}
+
+func (s *scheduler) trigger(schedule *Schedule) {
+	logger.Info("triggered schedule", "schedule", schedule.Name)
+	if schedule.Brightness != nil {
+		err := s.deck.SetBrightness(*schedule.Brightness)
+		if err != nil {
+			logger.Error("cannot set the brightness of schedule", "schedule", schedule.Name, "error", err)
+		}
+	}
+	if schedule.PageID != "" {
+		err := s.deck.AttachPage(schedule.PageID)
+		if err != nil {
+			logger.Error("cannot attach page of schedule", "schedule", schedule.Name, "error", err)
+		}
+	}
+	if schedule.Action != nil {
+		schedule.Action.Pressed()
+		schedule.Action.Released()
+	}
+}
+
+// NextEvents returns the next time of each schedule, ordered by time.
+func (s *scheduler) NextEvents() []ScheduledEvent {
+	s.lock.Lock()
+	result := make([]ScheduledEvent, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		result = append(result, ScheduledEvent{Name: schedule.Name, Time: schedule.next})
+	}
+	s.lock.Unlock()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+// Reminder returns the name of the schedule that reminds the operator, or an empty string.
+func (s *scheduler) Reminder() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.reminder
+}
+
+func (s *scheduler) Acknowledge() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.reminder = ""
+}
+
+func (d *HamDeck) loadSchedules(configuration []any) error {
+	for i, rawSchedule := range configuration {
+		scheduleConfiguration, ok := rawSchedule.(map[string]any)
+		if !ok {
+			return fmt.Errorf("schedules[%d] is not a schedule object", i)
+		}
+
+		schedule, err := d.parseSchedule(scheduleConfiguration)
+		if err != nil {
+			return fmt.Errorf("schedules[%d]: %w", i, err)
+		}
+		if _, ok := d.pages[schedule.PageID]; schedule.PageID != "" && !ok {
+			return fmt.Errorf("schedules[%d]: no page defined with name %s", i, schedule.PageID)
+		}
+
+		d.schedules.Add(schedule)
+	}
+	return nil
+}
+
+// NextEvents returns the next time of each schedule, ordered by time.
+func (d *HamDeck) NextEvents() []ScheduledEvent {
+	return d.schedules.NextEvents()
+}
+
+/*
+	NextEventButton
+*/
+
+// NextEventButton shows the next scheduled event, or the next event of the given schedule. When a schedule with
+// a reminder is triggered, the button flashes until it is pressed.
+func NewNextEventButton(deck *HamDeck, schedule string) *NextEventButton {
+	return &NextEventButton{
+		lock:      new(sync.Mutex),
+		scheduler: deck.schedules,
+		ticker:    newTicker(),
+		schedule:  schedule,
+	}
+}
+
+type NextEventButton struct {
+	BaseButton
+	lock      *sync.Mutex
+	scheduler *scheduler
+	ticker    *ticker
+	image     image.Image
+	text      string
+	flashOn   bool
+	schedule  string
+}
+
+func (b *NextEventButton) nextEvent() (ScheduledEvent, bool) {
+	for _, event := range b.scheduler.NextEvents() {
+		if b.schedule == "" || event.Name == b.schedule {
+			return event, true
+		}
+	}
+	return ScheduledEvent{}, false
+}
+
+func (b *NextEventButton) status() (string, string, bool) {
+	if reminder := b.scheduler.Reminder(); reminder != "" && (b.schedule == "" || reminder == b.schedule) {
+		return reminder, "now", true
+	}
+	event, ok := b.nextEvent()
+	if !ok {
+		return "Next", "-", false
+	}
+	return event.Name, "in " + formatAge(event.Time.Sub(b.scheduler.now())), false
+}
+
+func (b *NextEventButton) tick() {
+	name, when, reminding := b.status()
+	text := fmt.Sprintf("%s|%s|%t", name, when, reminding)
+
+	b.lock.Lock()
+	changed := (text != b.text)
+	b.lock.Unlock()
+
+	if changed {
+		b.Invalidate(true)
+	}
+}
+
+func (b *NextEventButton) Image(gc GraphicContext, redrawImages bool) image.Image {
+	name, when, reminding := b.status()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	text := fmt.Sprintf("%s|%s|%t", name, when, reminding)
+	if b.image != nil && !redrawImages && text == b.text {
+		return b.image
+	}
+	b.text = text
+	gc.SetForeground(White)
+	gc.SetBackground(Black)
+	if reminding && b.flashOn {
+		gc.SetBackground(Red)
+	}
+	b.image = gc.DrawDoubleLineToggleTextButton(name, when, 2)
+	return b.image
+}
+
+func (b *NextEventButton) Flash(on bool) {
+	if b.scheduler.Reminder() == "" {
+		return
+	}
+	b.lock.Lock()
+	b.flashOn = on
+	b.lock.Unlock()
+	b.Invalidate(true)
+}
+
+func (b *NextEventButton) Pressed() {
+	if b.scheduler.Reminder() == "" {
+		return
+	}
+	b.scheduler.Acknowledge()
+	b.Invalidate(true)
+}
+
+func (b *NextEventButton) Released() {
+	// nop
+}
+
+func (b *NextEventButton) Attached(ctx ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.ticker.Start(b.tick)
+}
+
+func (b *NextEventButton) Detached() {
+	b.ticker.Stop()
+	b.BaseButton.Detached()
+}
@@ -0,0 +1,145 @@
+package hamdeck
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scheduleTestConfig = `{
+	"start_page": "main",
+	"pages": {
+		"main": {
+			"buttons": [
+				{ "type": "hamdeck.NextEvent", "index": 0 }
+			]
+		},
+		"ft8": {
+			"buttons": []
+		}
+	},
+	"schedules": [
+		{ "name": "FT8", "cron": "0 6 * * *", "timezone": "UTC", "page": "ft8", "action": { "type": "test.Button" } },
+		{ "name": "Night", "cron": "0 22 * * *", "timezone": "UTC", "brightness": 10 },
+		{ "name": "Net", "cron": "55 18 * * 3", "timezone": "UTC", "remind": true }
+	]
+}`
+
+func setupScheduleTest(t *testing.T, now time.Time) (*HamDeck, *testDevice, func(time.Time)) {
+	deck, device := setupTestDeck(t, scheduleTestConfig, func(deck *HamDeck) {
+		deck.schedules.now = func() time.Time { return now }
+	})
+	setNow := func(t time.Time) {
+		now = t
+	}
+	return deck, device, setNow
+}
+
+func TestSchedules_NextEvents(t *testing.T) {
+	// a Wednesday
+	deck, _, _ := setupScheduleTest(t, time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC))
+
+	events := deck.NextEvents()
+
+	require.Len(t, events, 3)
+	assert.Equal(t, "Net", events[0].Name)
+	assert.True(t, time.Date(2024, 3, 13, 18, 55, 0, 0, time.UTC).Equal(events[0].Time))
+	assert.Equal(t, "Night", events[1].Name)
+	assert.Equal(t, "FT8", events[2].Name)
+	assert.True(t, time.Date(2024, 3, 14, 6, 0, 0, 0, time.UTC).Equal(events[2].Time))
+}
+
+func TestSchedules_TriggerDueSchedules(t *testing.T) {
+	deck, _, setNow := setupScheduleTest(t, time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC))
+	action := deck.schedules.schedules[0].Action.(*testButton)
+
+	setNow(time.Date(2024, 3, 13, 22, 0, 0, 0, time.UTC))
+	deck.schedules.Trigger()
+
+	brightness, ok := deck.StoredBrightness()
+	assert.True(t, ok)
+	assert.Equal(t, 10, brightness)
+	assert.Equal(t, "main", deck.CurrentPage())
+	assert.False(t, action.pressed)
+	assert.Equal(t, "Net", deck.schedules.Reminder(), "the missed reminder is shown")
+
+	setNow(time.Date(2024, 3, 14, 6, 0, 0, 0, time.UTC))
+	deck.schedules.Trigger()
+
+	assert.Equal(t, "ft8", deck.CurrentPage())
+	assert.True(t, action.pressed)
+	assert.True(t, action.released)
+	assert.Equal(t, "Night", deck.NextEvents()[0].Name)
+}
+
+func TestSchedules_NextEventButtonShowsReminderUntilPressed(t *testing.T) {
+	deck, _, setNow := setupScheduleTest(t, time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC))
+	button := deck.buttons[0].(*NextEventButton)
+
+	name, when, reminding := button.status()
+	assert.Equal(t, "Net", name)
+	assert.Equal(t, "in 6h", when)
+	assert.False(t, reminding)
+
+	setNow(time.Date(2024, 3, 13, 18, 55, 0, 0, time.UTC))
+	deck.schedules.Trigger()
+	name, when, reminding = button.status()
+	assert.Equal(t, "Net", name)
+	assert.Equal(t, "now", when)
+	assert.True(t, reminding)
+
+	button.Pressed()
+	name, _, reminding = button.status()
+	assert.Equal(t, "Night", name)
+	assert.False(t, reminding)
+}
+
+func TestSchedules_RunLoopTriggersSchedules(t *testing.T) {
+	deck, _ := setupTestDeck(t, `{
+		"start_page": "main",
+		"pages": { "main": { "buttons": [] }, "other": { "buttons": [] } },
+		"schedules": [ { "cron": "@every 10ms", "page": "other" } ]
+	}`, nil)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		deck.Run(stop)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return deck.CurrentPage() == "other"
+	}, time.Second, time.Millisecond)
+
+	close(stop)
+	<-done
+}
+
+func TestSchedules_InvalidSchedules(t *testing.T) {
+	tt := []struct {
+		desc     string
+		schedule string
+	}{
+		{"no cron", `{ "page": "main" }`},
+		{"invalid cron", `{ "cron": "every morning", "page": "main" }`},
+		{"invalid timezone", `{ "cron": "0 6 * * *", "timezone": "Mars/Olympus", "page": "main" }`},
+		{"unknown page", `{ "cron": "0 6 * * *", "page": "ft8" }`},
+		{"unknown action", `{ "cron": "0 6 * * *", "action": { "type": "unknown" } }`},
+		{"nothing to do", `{ "cron": "0 6 * * *" }`},
+	}
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			deck := New(newDefaultTestDevice())
+			deck.RegisterFactory(NewButtonFactory(deck))
+			err := deck.ReadConfig(strings.NewReader(`{
+				"start_page": "main",
+				"pages": { "main": { "buttons": [] } },
+				"schedules": [` + tc.schedule + `]
+			}`))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	ImagePageType     = "image"
+	ConfigImage       = "image"
+	ConfigSplash      = "splash"
+	ConfigScreensaver = "screensaver"
+)
+
+const (
+	// MinimumSplashDuration is the time the splash image is shown at least.
+	MinimumSplashDuration = 2 * time.Second
+	// SplashTimeout is the time after which the splash image is hidden, even if not all connections are established.
+	SplashTimeout = 15 * time.Second
+)
+
+// LoadImageFile reads an image in PNG, JPEG, or GIF format from the given file.
+func LoadImageFile(filename string) (image.Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the image: %w", err)
+	}
+	defer file.Close()
+	result, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode the image %s: %w", filename, err)
+	}
+	return result, nil
+}
+
+// SliceImage scales the given image to fit on all keys of a device with the given rows, columns, and key size in
+// pixels, and slices it into one image per key, row by row. The image keeps its aspect ratio and is centered on
+// a black background.
+func SliceImage(img image.Image, rows int, columns int, pixels int) []image.Image {
+	canvas := image.NewRGBA(image.Rect(0, 0, columns*pixels, rows*pixels))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(Black), image.Point{}, draw.Src)
+
+	bounds := img.Bounds()
+	if !bounds.Empty() {
+		scale := math.Min(float64(canvas.Bounds().Dx())/float64(bounds.Dx()), float64(canvas.Bounds().Dy())/float64(bounds.Dy()))
+		width := int(math.Round(float64(bounds.Dx()) * scale))
+		height := int(math.Round(float64(bounds.Dy()) * scale))
+		left := (canvas.Bounds().Dx() - width) / 2
+		top := (canvas.Bounds().Dy() - height) / 2
+		xdraw.CatmullRom.Scale(canvas, image.Rect(left, top, left+width, top+height), img, bounds, draw.Over, nil)
+	}
+
+	result := make([]image.Image, rows*columns)
+	for i := range result {
+		row, column := i/columns, i%columns
+		tile := image.NewRGBA(image.Rect(0, 0, pixels, pixels))
+		draw.Draw(tile, tile.Bounds(), canvas, image.Pt(column*pixels, row*pixels), draw.Src)
+		result[i] = tile
+	}
+	return result
+}
+
+func (d *HamDeck) sliceImage(img image.Image) []image.Image {
+	return SliceImage(img, d.device.Rows(), d.device.Columns(), d.device.Pixels())
+}
+
+/*
+	Overlay
+*/
+
+// overlayKind tells why the overlay is shown.
+type overlayKind int
+
+const (
+	noOverlay overlayKind = iota
+	splashOverlay
+	screensaverOverlay
+)
+
+// showOverlay shows the given images on all keys instead of the images of the attached buttons.
+func (d *HamDeck) showOverlay(kind overlayKind, images []image.Image) {
+	d.drawLock.Lock()
+	defer d.drawLock.Unlock()
+	d.overlay = images
+	d.overlayKind = kind
+	for i := range d.buttons {
+		d.redraw(i, false)
+	}
+}
+
+// hideOverlay shows the images of the attached buttons again.
+func (d *HamDeck) hideOverlay() {
+	d.drawLock.Lock()
+	d.overlay = nil
+	d.overlayKind = noOverlay
+	d.drawLock.Unlock()
+	d.RedrawAll(true)
+}
+
+func (d *HamDeck) currentOverlay() overlayKind {
+	d.drawLock.Lock()
+	defer d.drawLock.Unlock()
+	return d.overlayKind
+}
+
+// ShowSplash shows the given image across all keys until all connections are established. Any key hides the splash
+// image.
+func (d *HamDeck) ShowSplash(img image.Image) {
+	d.splashImage = img
+	d.splashSince = d.now()
+	d.showOverlay(splashOverlay, d.sliceImage(img))
+}
+
+func (d *HamDeck) loadSplash(configuration map[string]any) error {
+	d.screensaver = screensaver{}
+	filename, ok := ToString(configuration[ConfigSplash])
+	if ok {
+		img, err := LoadImageFile(filename)
+		if err != nil {
+			return fmt.Errorf("splash: %w", err)
+		}
+		if d.splashImage == nil {
+			d.ShowSplash(img)
+		}
+		d.splashImage = img
+	}
+
+	screensaverConfiguration, ok := configuration[ConfigScreensaver].(map[string]any)
+	if !ok {
+		return nil
+	}
+	screensaver, err := d.parseScreensaver(screensaverConfiguration)
+	if err != nil {
+		return fmt.Errorf("screensaver: %w", err)
+	}
+	d.screensaver = screensaver
+	return nil
+}
+
+// splashDone indicates if the splash image was shown long enough.
+func (d *HamDeck) splashDone(now time.Time) bool {
+	shown := now.Sub(d.splashSince)
+	if shown < MinimumSplashDuration {
+		return false
+	}
+	if shown >= SplashTimeout {
+		return true
+	}
+	healths := d.health.All()
+	if len(healths) < len(d.connections) {
+		return false
+	}
+	for _, health := range healths {
+		if !health.Connected {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+	Screensaver
+*/
+
+// screensaver shows an image across all keys after the deck was not used for the given time.
+type screensaver struct {
+	timeout    time.Duration
+	images     []image.Image
+	brightness *int
+}
+
+func (d *HamDeck) parseScreensaver(configuration map[string]any) (screensaver, error) {
+	seconds, ok := ToInt(configuration[ConfigTimeout])
+	if !ok || seconds <= 0 {
+		return screensaver{}, fmt.Errorf("the timeout must be given in seconds")
+	}
+	result := screensaver{timeout: time.Duration(seconds) * time.Second}
+
+	if filename, ok := ToString(configuration[ConfigImage]); ok {
+		img, err := LoadImageFile(filename)
+		if err != nil {
+			return screensaver{}, err
+		}
+		result.images = d.sliceImage(img)
+	} else if d.splashImage != nil {
+		result.images = d.sliceImage(d.splashImage)
+	} else {
+		result.images = make([]image.Image, len(d.buttons))
+		for i := range result.images {
+			result.images[i] = d.gc.DrawNoButton()
+		}
+	}
+
+	if brightness, ok := ToInt(configuration[ConfigBrightness]); ok {
+		result.brightness = &brightness
+	}
+	return result, nil
+}
+
+// updateOverlay hides the splash image when it was shown long enough, and starts the screensaver when the deck
+// was not used for the configured time.
+func (d *HamDeck) updateOverlay() {
+	now := d.now()
+	switch d.currentOverlay() {
+	case splashOverlay:
+		if d.splashDone(now) {
+			d.lastActivity = now
+			d.hideOverlay()
+		}
+	case noOverlay:
+		if d.screensaver.timeout > 0 && now.Sub(d.lastActivity) >= d.screensaver.timeout {
+			d.startScreensaver()
+		}
+	}
+}
+
+func (d *HamDeck) startScreensaver() {
+	logger.Debug("starting the screensaver")
+	if d.screensaver.brightness != nil {
+		err := d.device.SetBrightness(*d.screensaver.brightness)
+		if err != nil {
+			logger.Error("cannot set the brightness of the screensaver", "error", err)
+		}
+	}
+	d.showOverlay(screensaverOverlay, d.screensaver.images)
+}
+
+// wakeUp hides the splash image or the screensaver. It returns false if nothing was hidden.
+func (d *HamDeck) wakeUp() bool {
+	d.lastActivity = d.now()
+	switch d.currentOverlay() {
+	case noOverlay:
+		return false
+	case screensaverOverlay:
+		if brightness, ok := d.StoredBrightness(); ok && d.screensaver.brightness != nil {
+			err := d.device.SetBrightness(brightness)
+			if err != nil {
+				logger.Error("cannot restore the brightness", "error", err)
+			}
+		}
+	}
+	d.hideOverlay()
+	return true
+}
+
+/*
+	Image Page
+*/
+
+// loadImagePage creates a page that shows a single image across all keys. Pressing any key attaches the page
+// given in the back field (the start page by default).
+func (d *HamDeck) loadImagePage(id string, configuration map[string]any) (map[string]Page, error) {
+	filename, ok := ToString(configuration[ConfigImage])
+	if !ok {
+		return nil, fmt.Errorf("page %s has no image defined", id)
+	}
+	img, err := LoadImageFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("page %s: %w", id, err)
+	}
+	backID, ok := ToString(configuration[ConfigBack])
+	if !ok {
+		backID = d.startPageID
+	}
+	if backID == id {
+		backID = ""
+	}
+
+	tiles := d.sliceImage(img)
+	page := Page{
+		buttons: make([]Button, len(d.buttons)),
+		infos:   make([]buttonInfo, len(d.buttons)),
+	}
+	for i, tile := range tiles {
+		page.buttons[i] = NewImageTileButton(d, tile, backID)
+		page.infos[i] = buttonInfo{buttonType: ImageTileButtonType}
+	}
+	return map[string]Page{id: page}, nil
+}
+
+/*
+	ImageTileButton
+*/
+
+const ImageTileButtonType = "hamdeck.ImageTile"
+
+// ImageTileButton shows a part of a full-deck image. Pressing the button attaches the given page.
+func NewImageTileButton(pageSwitcher PageSwitcher, tile image.Image, backID string) *ImageTileButton {
+	return &ImageTileButton{
+		pageSwitcher: pageSwitcher,
+		tile:         tile,
+		backID:       backID,
+	}
+}
+
+type ImageTileButton struct {
+	BaseButton
+	pageSwitcher PageSwitcher
+	tile         image.Image
+	backID       string
+}
+
+func (b *ImageTileButton) Image(GraphicContext, bool) image.Image {
+	return b.tile
+}
+
+func (b *ImageTileButton) Pressed() {
+	if b.backID == "" {
+		return
+	}
+	err := b.pageSwitcher.AttachPage(b.backID)
+	if err != nil {
+		logger.Error("cannot leave the image page", "error", err)
+	}
+}
+
+func (b *ImageTileButton) Released() {
+	// nop
+}
@@ -0,0 +1,172 @@
+package hamdeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestImage writes an image with a red left half and a blue right half.
+func writeTestImage(t *testing.T, width int, height int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, image.Rect(0, 0, width/2, height), image.NewUniform(Red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(width/2, 0, width, height), image.NewUniform(Blue), image.Point{}, draw.Src)
+
+	filename := filepath.Join(t.TempDir(), "splash.png")
+	file, err := os.Create(filename)
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, png.Encode(file, img))
+	return filename
+}
+
+func centerColor(img image.Image) color.RGBA {
+	bounds := img.Bounds()
+	return color.RGBAModel.Convert(img.At(bounds.Min.X+bounds.Dx()/2, bounds.Min.Y+bounds.Dy()/2)).(color.RGBA)
+}
+
+func TestSliceImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	draw.Draw(img, image.Rect(0, 0, 150, 100), image.NewUniform(Red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(150, 0, 300, 100), image.NewUniform(Blue), image.Point{}, draw.Src)
+
+	tiles := SliceImage(img, 2, 4, 10)
+
+	require.Len(t, tiles, 8)
+	for _, tile := range tiles {
+		assert.Equal(t, image.Rect(0, 0, 10, 10), tile.Bounds())
+	}
+	// the image is scaled to 40x13 and centered vertically
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, color.RGBAModel.Convert(tiles[0].At(5, 0)))
+	assert.Equal(t, Red, color.RGBAModel.Convert(tiles[4].At(5, 1)))
+	assert.Equal(t, Red, color.RGBAModel.Convert(tiles[1].At(5, 9)))
+	assert.Equal(t, Blue, color.RGBAModel.Convert(tiles[2].At(5, 9)))
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, color.RGBAModel.Convert(tiles[7].At(5, 9)))
+}
+
+func setupSplashTest(t *testing.T, config string) (*HamDeck, *time.Time) {
+	now := time.Date(2024, 3, 16, 9, 0, 0, 0, time.UTC)
+	deck, _ := setupTestDeck(t, config, func(deck *HamDeck) {
+		deck.now = func() time.Time { return now }
+		deck.lastActivity = now
+	})
+	return deck, &now
+}
+
+func TestSplash_ShownOnStartup(t *testing.T) {
+	filename := writeTestImage(t, 64, 32)
+	deck, now := setupSplashTest(t, `{
+		"splash": "`+filename+`",
+		"buttons": [ { "type": "test.Button", "index": 0 } ]
+	}`)
+
+	assert.Equal(t, splashOverlay, deck.currentOverlay())
+	assert.Len(t, deck.overlay, 32)
+
+	*now = now.Add(time.Second)
+	deck.updateOverlay()
+	assert.Equal(t, splashOverlay, deck.currentOverlay())
+
+	*now = now.Add(MinimumSplashDuration)
+	deck.updateOverlay()
+	assert.Equal(t, noOverlay, deck.currentOverlay())
+}
+
+func TestSplash_WaitsForConnections(t *testing.T) {
+	filename := writeTestImage(t, 64, 32)
+	deck, now := setupSplashTest(t, `{
+		"splash": "`+filename+`",
+		"connections": { "rig": { "type": "test" } },
+		"buttons": []
+	}`)
+
+	*now = now.Add(MinimumSplashDuration)
+	deck.updateOverlay()
+	assert.Equal(t, splashOverlay, deck.currentOverlay())
+
+	deck.PublishState("test:rig", StateConnected, FormatBoolState(true))
+	deck.updateOverlay()
+	assert.Equal(t, noOverlay, deck.currentOverlay())
+}
+
+func TestSplash_KeyHidesSplashWithoutPressingTheButton(t *testing.T) {
+	filename := writeTestImage(t, 64, 32)
+	deck, _ := setupSplashTest(t, `{
+		"splash": "`+filename+`",
+		"buttons": [ { "type": "test.Button", "index": 0 } ]
+	}`)
+	button := deck.buttons[0].(*testButton)
+
+	deck.handleKey(Key{Index: 0, Pressed: true})
+	deck.handleKey(Key{Index: 0, Pressed: false})
+
+	assert.Equal(t, noOverlay, deck.currentOverlay())
+	assert.False(t, button.pressed)
+	assert.False(t, button.released)
+
+	deck.handleKey(Key{Index: 0, Pressed: true})
+	assert.True(t, button.pressed)
+}
+
+func TestScreensaver(t *testing.T) {
+	deck, now := setupSplashTest(t, `{
+		"screensaver": { "timeout": 600 },
+		"buttons": [ { "type": "test.Button", "index": 0 } ]
+	}`)
+	button := deck.buttons[0].(*testButton)
+
+	*now = now.Add(599 * time.Second)
+	deck.updateOverlay()
+	assert.Equal(t, noOverlay, deck.currentOverlay())
+
+	*now = now.Add(time.Second)
+	deck.updateOverlay()
+	assert.Equal(t, screensaverOverlay, deck.currentOverlay())
+
+	deck.handleKey(Key{Index: 0, Pressed: true})
+	deck.handleKey(Key{Index: 0, Pressed: false})
+	assert.Equal(t, noOverlay, deck.currentOverlay())
+	assert.False(t, button.pressed)
+
+	*now = now.Add(599 * time.Second)
+	deck.updateOverlay()
+	assert.Equal(t, noOverlay, deck.currentOverlay())
+}
+
+func TestImagePage(t *testing.T) {
+	filename := writeTestImage(t, 64, 32)
+	deck, _ := setupSplashTest(t, `{
+		"start_page": "main",
+		"pages": {
+			"main": { "buttons": [] },
+			"bandmap": { "type": "image", "image": "`+filename+`" }
+		}
+	}`)
+	require.NoError(t, deck.AttachPage("bandmap"))
+
+	tile, ok := deck.buttons[0].(*ImageTileButton)
+	require.True(t, ok)
+	assert.Equal(t, Red, centerColor(tile.Image(deck.gc, false)))
+	assert.Equal(t, Blue, centerColor(deck.buttons[31].Image(deck.gc, false)))
+
+	deck.buttons[10].Pressed()
+	assert.Equal(t, "main", deck.CurrentPage())
+}
+
+func TestImagePage_MissingImage(t *testing.T) {
+	deck := New(newDefaultTestDevice())
+	err := deck.ReadConfig(strings.NewReader(`{
+		"pages": { "bandmap": { "type": "image" } }
+	}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package hamdeck
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Names of the state values that are published by the connections.
+const (
+	StateConnected = "connected"
+	StateFrequency = "frequency"
+	StateBand      = "band"
+	StateMode      = "mode"
+	StatePTT       = "ptt"
+	StateVFO       = "vfo"
+	StateError     = "error"
+)
+
+type StateListener interface {
+	StateChanged(connection string, name string, value string)
+}
+
+type StateListenerFunc func(string, string, string)
+
+func (f StateListenerFunc) StateChanged(connection string, name string, value string) {
+	f(connection, name, value)
+}
+
+type StatePublisher interface {
+	PublishState(connection string, name string, value string)
+}
+
+// Station is what the button factories need to know about the HamDeck to manage their connections.
+type Station interface {
+	ConnectionConfigProvider
+	StatePublisher
+}
+
+// StateSource is implemented by button factories that can provide the state of their connections on request,
+// even if there is no button that uses the connection.
+type StateSource interface {
+	RequestState(connection string, name string) bool
+}
+
+// StateConnectionName returns the name under which the state of the given connection is published.
+// The state of a legacy connection is published under the name of its connection type.
+func StateConnectionName(name string, connectionType string) string {
+	if name == LegacyConnectionName {
+		return connectionType
+	}
+	return name
+}
+
+func FormatBoolState(value bool) string {
+	return strconv.FormatBool(value)
+}
+
+type stateKey struct {
+	connection string
+	name       string
+}
+
+type State struct {
+	lock      *sync.RWMutex
+	values    map[stateKey]string
+	listeners []StateListener
+}
+
+func NewState() *State {
+	return &State{
+		lock:   new(sync.RWMutex),
+		values: make(map[stateKey]string),
+	}
+}
+
+func (s *State) Publish(connection string, name string, value string) {
+	s.lock.Lock()
+	key := stateKey{connection, name}
+	lastValue, ok := s.values[key]
+	if ok && lastValue == value {
+		s.lock.Unlock()
+		return
+	}
+	s.values[key] = value
+	listeners := s.listeners
+	s.lock.Unlock()
+
+	for _, listener := range listeners {
+		listener.StateChanged(connection, name, value)
+	}
+}
+
+func (s *State) Get(connection string, name string) (string, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	value, ok := s.values[stateKey{connection, name}]
+	return value, ok
+}
+
+func (s *State) Listen(listener StateListener) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// stateListeners fans out the state changes to the listeners that belong to the current configuration.
+type stateListeners struct {
+	lock      *sync.Mutex
+	listeners []StateListener
+}
+
+func newStateListeners() *stateListeners {
+	return &stateListeners{
+		lock: new(sync.Mutex),
+	}
+}
+
+func (l *stateListeners) Add(listener StateListener) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.listeners = append(l.listeners, listener)
+}
+
+func (l *stateListeners) Clear() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.listeners = nil
+}
+
+func (l *stateListeners) StateChanged(connection string, name string, value string) {
+	l.lock.Lock()
+	listeners := l.listeners
+	l.lock.Unlock()
+
+	for _, listener := range listeners {
+		listener.StateChanged(connection, name, value)
+	}
+}
+
+// ForEach calls the given function for a snapshot of all current state values.
+func (s *State) ForEach(f func(connection string, name string, value string)) {
+	s.lock.RLock()
+	values := make(map[stateKey]string, len(s.values))
+	for key, value := range s.values {
+		values[key] = value
+	}
+	s.lock.RUnlock()
+
+	for key, value := range values {
+		f(key.connection, key.name, value)
+	}
+}
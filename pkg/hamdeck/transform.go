@@ -0,0 +1,224 @@
+package hamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+	"sync"
+)
+
+const (
+	ConfigDevices = "devices"
+	ConfigRotate  = "rotate"
+	ConfigMirror  = "mirror"
+)
+
+// The values of the mirror field in a device configuration.
+const (
+	MirrorHorizontal = "horizontal"
+	MirrorVertical   = "vertical"
+	MirrorBoth       = "both"
+)
+
+/*
+	Transform
+*/
+
+// Transform describes how a device is mounted: rotated clockwise by 0, 90, 180, or 270 degrees, and mirrored.
+// The keys are mirrored first, then rotated.
+type Transform struct {
+	Rotation         int
+	MirrorHorizontal bool
+	MirrorVertical   bool
+}
+
+func ParseTransform(config map[string]any) (Transform, error) {
+	var result Transform
+	if rotation, ok := ToInt(config[ConfigRotate]); ok {
+		result.Rotation = ((rotation % 360) + 360) % 360
+	}
+	if result.Rotation%90 != 0 {
+		return Transform{}, fmt.Errorf("the rotation must be 0, 90, 180, or 270 degrees")
+	}
+	mirror, _ := ToString(config[ConfigMirror])
+	switch strings.ToLower(mirror) {
+	case "":
+	case MirrorHorizontal:
+		result.MirrorHorizontal = true
+	case MirrorVertical:
+		result.MirrorVertical = true
+	case MirrorBoth:
+		result.MirrorHorizontal = true
+		result.MirrorVertical = true
+	default:
+		return Transform{}, fmt.Errorf("invalid mirror %s, use horizontal, vertical, or both", mirror)
+	}
+	return result, nil
+}
+
+func (t Transform) IsIdentity() bool {
+	return t == Transform{}
+}
+
+// Fits indicates if the transform can be applied to a grid of the given size. A rotation by 90 or 270 degrees
+// needs a square grid.
+func (t Transform) Fits(width int, height int) bool {
+	return width == height || t.Rotation%180 == 0
+}
+
+// Apply maps the position x, y that the user sees on a grid of the given size to the position on the device.
+// It is used for the keys and for the pixels of the key images.
+func (t Transform) Apply(x int, y int, width int, height int) (int, int) {
+	if t.MirrorHorizontal {
+		x = width - 1 - x
+	}
+	if t.MirrorVertical {
+		y = height - 1 - y
+	}
+	switch t.Rotation {
+	case 90:
+		return y, height - 1 - x
+	case 180:
+		return width - 1 - x, height - 1 - y
+	case 270:
+		return width - 1 - y, x
+	default:
+		return x, y
+	}
+}
+
+// Image returns the given image as it must be set on the device.
+func (t Transform) Image(img image.Image) image.Image {
+	if img == nil || t.IsIdentity() {
+		return img
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if !t.Fits(width, height) {
+		return img
+	}
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tx, ty := t.Apply(x, y, width, height)
+			result.SetRGBA(tx, ty, src.RGBAAt(x, y))
+		}
+	}
+	return result
+}
+
+/*
+	TransformedDevice
+*/
+
+// TransformableDevice is a device whose keys can be rotated and mirrored.
+type TransformableDevice interface {
+	SetTransform(Transform) error
+}
+
+// TransformedDevice wraps a device that is mounted rotated or mirrored. It maps the key indices and the images
+// in both directions, so the buttons appear upright at the position the user sees.
+type TransformedDevice struct {
+	Device
+
+	lock       *sync.Mutex
+	transform  Transform
+	toDevice   []int
+	fromDevice []int
+}
+
+func NewTransformedDevice(device Device) *TransformedDevice {
+	result := &TransformedDevice{
+		Device: device,
+		lock:   new(sync.Mutex),
+	}
+	result.SetTransform(Transform{})
+	return result
+}
+
+func (d *TransformedDevice) SetTransform(transform Transform) error {
+	columns, rows := d.Device.Columns(), d.Device.Rows()
+	if !transform.Fits(columns, rows) {
+		return fmt.Errorf("the %dx%d keys of the device cannot be rotated by %d degrees", rows, columns, transform.Rotation)
+	}
+	toDevice := make([]int, rows*columns)
+	fromDevice := make([]int, rows*columns)
+	for i := range toDevice {
+		x, y := transform.Apply(i%columns, i/columns, columns, rows)
+		toDevice[i] = y*columns + x
+		fromDevice[toDevice[i]] = i
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.transform = transform
+	d.toDevice = toDevice
+	d.fromDevice = fromDevice
+	return nil
+}
+
+func (d *TransformedDevice) Transform() Transform {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.transform
+}
+
+func (d *TransformedDevice) SetImage(index int, img image.Image) error {
+	d.lock.Lock()
+	transform := d.transform
+	if index >= 0 && index < len(d.toDevice) {
+		index = d.toDevice[index]
+	}
+	d.lock.Unlock()
+
+	return d.Device.SetImage(index, transform.Image(img))
+}
+
+func (d *TransformedDevice) ReadKeys() (chan Key, error) {
+	keys, err := d.Device.ReadKeys()
+	if err != nil {
+		return nil, err
+	}
+	result := make(chan Key)
+	go func() {
+		defer close(result)
+		for key := range keys {
+			d.lock.Lock()
+			if key.Index >= 0 && key.Index < len(d.fromDevice) {
+				key.Index = d.fromDevice[key.Index]
+			}
+			d.lock.Unlock()
+			result <- key
+		}
+	}()
+	return result, nil
+}
+
+// loadDevices applies the transform that is configured for the serial number of the device. The device is not
+// transformed if there is no configuration for it.
+func (d *HamDeck) loadDevices(configuration map[string]any) error {
+	transformable, ok := d.device.(TransformableDevice)
+	if !ok {
+		if len(configuration) > 0 {
+			logger.Warn("the device cannot be rotated or mirrored")
+		}
+		return nil
+	}
+
+	var transform Transform
+	if deviceConfiguration, ok := configuration[d.device.Serial()].(map[string]any); ok {
+		var err error
+		transform, err = ParseTransform(deviceConfiguration)
+		if err != nil {
+			return fmt.Errorf("device %s: %w", d.device.Serial(), err)
+		}
+	}
+	err := transformable.SetTransform(transform)
+	if err != nil {
+		return fmt.Errorf("device %s: %w", d.device.Serial(), err)
+	}
+	return nil
+}
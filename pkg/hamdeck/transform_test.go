@@ -0,0 +1,97 @@
+package hamdeck
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform_Apply(t *testing.T) {
+	tt := []struct {
+		desc      string
+		transform Transform
+		width     int
+		height    int
+		x, y      int
+		expectedX int
+		expectedY int
+	}{
+		{"identity", Transform{}, 5, 3, 1, 2, 1, 2},
+		{"180", Transform{Rotation: 180}, 5, 3, 0, 0, 4, 2},
+		{"180 center", Transform{Rotation: 180}, 5, 3, 2, 1, 2, 1},
+		{"90 top left", Transform{Rotation: 90}, 3, 3, 0, 0, 0, 2},
+		{"90 top right", Transform{Rotation: 90}, 3, 3, 2, 0, 0, 0},
+		{"270 top left", Transform{Rotation: 270}, 3, 3, 0, 0, 2, 0},
+		{"mirror horizontal", Transform{MirrorHorizontal: true}, 5, 3, 0, 1, 4, 1},
+		{"mirror vertical", Transform{MirrorVertical: true}, 5, 3, 0, 0, 0, 2},
+		{"mirror and rotate", Transform{Rotation: 90, MirrorHorizontal: true}, 3, 3, 0, 0, 0, 0},
+	}
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			x, y := tc.transform.Apply(tc.x, tc.y, tc.width, tc.height)
+			assert.Equal(t, tc.expectedX, x, "x")
+			assert.Equal(t, tc.expectedY, y, "y")
+		})
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	transform, err := ParseTransform(map[string]any{"rotate": -90.0, "mirror": "both"})
+	require.NoError(t, err)
+	assert.Equal(t, Transform{Rotation: 270, MirrorHorizontal: true, MirrorVertical: true}, transform)
+
+	_, err = ParseTransform(map[string]any{"rotate": 45.0})
+	assert.Error(t, err)
+	_, err = ParseTransform(map[string]any{"mirror": "diagonal"})
+	assert.Error(t, err)
+}
+
+func TestTransform_Image(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, Red)
+
+	rotated := Transform{Rotation: 90}.Image(img)
+
+	assert.Equal(t, Red, color.RGBAModel.Convert(rotated.At(0, 1)))
+	assert.Equal(t, color.RGBA{}, color.RGBAModel.Convert(rotated.At(0, 0)))
+}
+
+func TestTransformedDevice(t *testing.T) {
+	recording := &Recording{
+		Header: RecordingHeader{Serial: "ABC", Pixels: 72, Rows: 3, Columns: 5},
+		Keys: []RecordedKey{
+			{Index: 0, Pressed: true},
+			{Index: 0, Pressed: false},
+		},
+	}
+	replay := NewReplayDevice(recording)
+	replay.SetSpeed(0)
+	device := NewTransformedDevice(replay)
+	deck := New(device)
+	deck.RegisterFactory(new(testButtonFactory))
+	require.NoError(t, deck.ReadConfig(strings.NewReader(`{
+		"devices": { "ABC": { "rotate": 180 }, "XYZ": { "rotate": 90 } },
+		"buttons": [ { "type": "test.Button", "index": 14 } ]
+	}`)))
+	assert.Equal(t, Transform{Rotation: 180}, device.Transform())
+
+	require.NoError(t, deck.Run(nil))
+
+	button := deck.buttons[14].(*testButton)
+	assert.True(t, button.pressed, "the top left key on the device is the bottom right key for the user")
+	assert.True(t, button.released)
+	frames := replay.Frames()
+	assert.Equal(t, 0, frames[len(frames)-1].Index, "the last key for the user is the first key on the device")
+}
+
+func TestTransformedDevice_RotationByNinetyDegreesNeedsSquareGrid(t *testing.T) {
+	device := NewTransformedDevice(newTestDevice(72, 3, 5))
+
+	assert.Error(t, device.SetTransform(Transform{Rotation: 90}))
+	assert.NoError(t, device.SetTransform(Transform{Rotation: 180}))
+	assert.NoError(t, NewTransformedDevice(newTestDevice(72, 3, 3)).SetTransform(Transform{Rotation: 270}))
+}
@@ -1,8 +1,11 @@
 package hamlib
 
 import (
+	"context"
+	"fmt"
 	"image"
-	"log"
+	"strings"
+	"sync"
 
 	"github.com/ftl/hamradio"
 	"github.com/ftl/hamradio/bandplan"
@@ -146,10 +149,12 @@ func (b *SetModeButton) Pressed() {
 	if !b.enabled {
 		return
 	}
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.SetModeAndPassband(ctx, b.mode, hamradio.Frequency(b.bandwidth))
+	err := b.client.Request("set_mode", func(ctx context.Context) error {
+		return b.client.Conn.SetModeAndPassband(ctx, b.mode, hamradio.Frequency(b.bandwidth))
+	})
+	b.AuditCommand(fmt.Sprintf("set_mode %s %.0f", b.mode, b.bandwidth), err)
 	if err != nil {
-		log.Printf("cannot set mode: %v", err)
+		logger.Error("cannot set mode", "error", err)
 	}
 }
 
@@ -157,15 +162,21 @@ func (b *SetModeButton) Released() {
 	b.longpress.Released()
 }
 
+func (b *SetModeButton) Close() {
+	b.client.Unlisten(b)
+}
+
 func (b *SetModeButton) OnLongpress() {
 	if !b.enabled {
 		return
 	}
 	frequency := findModePortionCenter(b.currentFrequency, b.bandplanMode)
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.SetFrequency(ctx, frequency)
+	err := b.client.Request("set_freq", func(ctx context.Context) error {
+		return b.client.Conn.SetFrequency(ctx, frequency)
+	})
+	b.AuditCommand(fmt.Sprintf("set_freq %.0f", frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the beginning of the %s band portion: %v", b.mode, err)
+		logger.Error("cannot jump to the beginning of the band portion", "mode", b.mode, "error", err)
 	}
 }
 
@@ -333,10 +344,12 @@ func (b *ToggleModeButton) Pressed() {
 	if b.selected {
 		mode = (mode + 1) % len(b.modes)
 	}
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.SetModeAndPassband(ctx, b.modes[mode], 0)
+	err := b.client.Request("set_mode", func(ctx context.Context) error {
+		return b.client.Conn.SetModeAndPassband(ctx, b.modes[mode], 0)
+	})
+	b.AuditCommand(fmt.Sprintf("set_mode %s 0", b.modes[mode]), err)
 	if err != nil {
-		log.Printf("cannot set mode: %v", err)
+		logger.Error("cannot set mode", "error", err)
 	}
 }
 
@@ -344,15 +357,21 @@ func (b *ToggleModeButton) Released() {
 	b.longpress.Released()
 }
 
+func (b *ToggleModeButton) Close() {
+	b.client.Unlisten(b)
+}
+
 func (b *ToggleModeButton) OnLongpress() {
 	if !b.enabled {
 		return
 	}
 	frequency := findModePortionCenter(b.currentFrequency, b.bandplanModes[b.currentMode])
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.SetFrequency(ctx, frequency)
+	err := b.client.Request("set_freq", func(ctx context.Context) error {
+		return b.client.Conn.SetFrequency(ctx, frequency)
+	})
+	b.AuditCommand(fmt.Sprintf("set_freq %.0f", frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the beginning of the %s band portion: %v", b.modes[b.currentMode], err)
+		logger.Error("cannot jump to the beginning of the band portion", "mode", b.modes[b.currentMode], "error", err)
 	}
 }
 
@@ -408,10 +427,12 @@ func (b *SetButton) Pressed() {
 	if !b.enabled {
 		return
 	}
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.Set(ctx, b.command, b.args...)
+	err := b.client.Request(b.command, func(ctx context.Context) error {
+		return b.client.Conn.Set(ctx, b.command, b.args...)
+	})
+	b.AuditCommand(strings.Join(append([]string{b.command}, b.args...), " "), err)
 	if err != nil {
-		log.Printf("cannot execute %s: %v", b.command, err)
+		logger.Error("cannot execute command", "command", b.command, "error", err)
 	}
 }
 
@@ -419,6 +440,10 @@ func (b *SetButton) Released() {
 	// ignore
 }
 
+func (b *SetButton) Close() {
+	b.client.Unlisten(b)
+}
+
 /*
 	SwitchToBandButton
 */
@@ -426,7 +451,7 @@ func (b *SetButton) Released() {
 func NewSwitchToBandButton(hamlibClient *HamlibClient, label string, bandName string, useUpDown bool) *SwitchToBandButton {
 	band, ok := bandplan.IARURegion1[bandplan.BandName(bandName)]
 	if !ok {
-		log.Printf("cannot find band %s in IARU Region 1 bandplan", bandName)
+		logger.Error("cannot find band in IARU Region 1 bandplan", "band", bandName)
 		return nil
 	}
 	result := &SwitchToBandButton{
@@ -453,6 +478,28 @@ type SwitchToBandButton struct {
 	band          bandplan.Band
 	mode          client.Mode
 	useUpDown     bool
+	memory        bandMemory
+	store         hamdeck.ButtonStore
+}
+
+// bandMemory is the band stack memory of a SwitchToBandButton: the frequency and mode that were used last on the band.
+type bandMemory struct {
+	Frequency client.Frequency `json:"frequency"`
+	Mode      client.Mode      `json:"mode"`
+}
+
+// Restore restores the band stack memory that was stored the last time.
+func (b *SwitchToBandButton) Restore(store hamdeck.ButtonStore) {
+	b.store = store
+	store.Get(&b.memory)
+}
+
+func (b *SwitchToBandButton) remember(memory bandMemory) {
+	if memory == b.memory {
+		return
+	}
+	b.memory = memory
+	b.store.Set(b.memory)
 }
 
 func (b *SwitchToBandButton) Enable(enabled bool) {
@@ -466,6 +513,9 @@ func (b *SwitchToBandButton) Enable(enabled bool) {
 func (b *SwitchToBandButton) SetFrequency(frequency client.Frequency) {
 	wasSelected := b.selected
 	b.selected = b.band.Contains(frequency)
+	if b.selected {
+		b.remember(bandMemory{Frequency: frequency, Mode: b.mode})
+	}
 	if b.selected == wasSelected {
 		return
 	}
@@ -474,6 +524,9 @@ func (b *SwitchToBandButton) SetFrequency(frequency client.Frequency) {
 
 func (b *SwitchToBandButton) SetMode(mode client.Mode) {
 	b.mode = mode
+	if b.selected {
+		b.remember(bandMemory{Frequency: b.memory.Frequency, Mode: mode})
+	}
 }
 
 func (b *SwitchToBandButton) Image(gc hamdeck.GraphicContext, redrawImages bool) image.Image {
@@ -506,22 +559,34 @@ func (b *SwitchToBandButton) Pressed() {
 		return
 	}
 	if b.useUpDown {
-		ctx := b.client.WithRequestTimeout()
-		err := b.client.Conn.SwitchToBand(ctx, b.band)
+		err := b.client.Request("switch_to_band", func(ctx context.Context) error {
+			return b.client.Conn.SwitchToBand(ctx, b.band)
+		})
+		b.AuditCommand(fmt.Sprintf("switch_to_band %s", b.band.Name), err)
 		if err != nil {
-			log.Print(err)
+			logger.Error("cannot switch to band", "band", b.band.Name, "error", err)
 		}
 	} else {
-		frequency := findModePortionCenter(b.band.Center(), b.mode.ToBandplanMode())
-		ctx := b.client.WithRequestTimeout()
-		err := b.client.Conn.SetFrequency(ctx, frequency)
+		frequency, mode := b.memory.Frequency, b.memory.Mode
+		if frequency == 0 {
+			frequency = findModePortionCenter(b.band.Center(), b.mode.ToBandplanMode())
+		}
+		if mode == "" {
+			mode = b.mode
+		}
+		err := b.client.Request("set_freq", func(ctx context.Context) error {
+			return b.client.Conn.SetFrequency(ctx, frequency)
+		})
+		b.AuditCommand(fmt.Sprintf("set_freq %.0f", frequency), err)
 		if err != nil {
-			log.Printf("cannot switch to band %s: %v", b.band, err)
+			logger.Error("cannot switch band", "band", b.band, "error", err)
 		}
-		ctx = b.client.WithRequestTimeout()
-		err = b.client.Conn.SetModeAndPassband(ctx, b.mode, 0)
+		err = b.client.Request("set_mode", func(ctx context.Context) error {
+			return b.client.Conn.SetModeAndPassband(ctx, mode, 0)
+		})
+		b.AuditCommand(fmt.Sprintf("set_mode %s 0", mode), err)
 		if err != nil {
-			log.Printf("cannot switch band to mode %s: %v", b.mode, err)
+			logger.Error("cannot switch band to mode", "mode", mode, "error", err)
 		}
 	}
 }
@@ -530,12 +595,17 @@ func (b *SwitchToBandButton) Released() {
 	// ignore
 }
 
+func (b *SwitchToBandButton) Close() {
+	b.client.Unlisten(b)
+}
+
 /*
 	SetPowerLevelButton
 */
 
 func NewSetPowerLevelButton(hamlibClient *HamlibClient, label string, value float64) *SetPowerLevelButton {
 	result := &SetPowerLevelButton{
+		lock:    new(sync.Mutex),
 		client:  hamlibClient,
 		enabled: hamlibClient.Connected(),
 		label:   label,
@@ -556,6 +626,14 @@ type SetPowerLevelButton struct {
 	selected      bool
 	label         string
 	value         float64
+	lock          *sync.Mutex
+	currentValue  float64
+}
+
+func (b *SetPowerLevelButton) current() float64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.currentValue
 }
 
 func (b *SetPowerLevelButton) Enable(enabled bool) {
@@ -567,6 +645,9 @@ func (b *SetPowerLevelButton) Enable(enabled bool) {
 }
 
 func (b *SetPowerLevelButton) SetPowerLevel(powerLevel float64) {
+	b.lock.Lock()
+	b.currentValue = powerLevel
+	b.lock.Unlock()
 	wasSelected := b.selected
 	b.selected = (powerLevel == b.value)
 	if b.selected == wasSelected {
@@ -600,10 +681,12 @@ func (b *SetPowerLevelButton) Pressed() {
 	if !b.enabled {
 		return
 	}
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.SetPowerLevel(ctx, b.value)
+	err := b.client.Request("set_level", func(ctx context.Context) error {
+		return b.client.Conn.SetPowerLevel(ctx, b.value)
+	})
+	b.AuditCommand(fmt.Sprintf("set_level RFPOWER %f", b.value), err)
 	if err != nil {
-		log.Print(err)
+		logger.Error("cannot set the power level", "error", err)
 	}
 }
 
@@ -611,6 +694,14 @@ func (b *SetPowerLevelButton) Released() {
 	// ignore
 }
 
+func (b *SetPowerLevelButton) Close() {
+	b.client.Unlisten(b)
+}
+
+func (b *SetPowerLevelButton) KeysTransmitter() bool {
+	return b.value > b.current()
+}
+
 /*
 	MOXButton
 */
@@ -703,10 +794,12 @@ func (b *MOXButton) Pressed() {
 	if b.selected {
 		value = client.PTTRx
 	}
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.SetPTT(ctx, value)
+	err := b.client.Request("set_ptt", func(ctx context.Context) error {
+		return b.client.Conn.SetPTT(ctx, value)
+	})
+	b.AuditCommand(fmt.Sprintf("set_ptt %s", value), err)
 	if err != nil {
-		log.Print(err)
+		logger.Error("cannot set PTT", "error", err)
 	}
 }
 
@@ -714,6 +807,14 @@ func (b *MOXButton) Released() {
 	// ignore
 }
 
+func (b *MOXButton) Close() {
+	b.client.Unlisten(b)
+}
+
+func (b *MOXButton) KeysTransmitter() bool {
+	return !b.selected
+}
+
 /*
 	SetVFOButton
 */
@@ -784,13 +885,19 @@ func (b *SetVFOButton) Pressed() {
 	if !b.enabled {
 		return
 	}
-	ctx := b.client.WithRequestTimeout()
-	err := b.client.Conn.SetVFO(ctx, b.vfo)
+	err := b.client.Request("set_vfo", func(ctx context.Context) error {
+		return b.client.Conn.SetVFO(ctx, b.vfo)
+	})
+	b.AuditCommand(fmt.Sprintf("set_vfo %s", b.vfo), err)
 	if err != nil {
-		log.Print(err)
+		logger.Error("cannot set the VFO", "error", err)
 	}
 }
 
 func (b *SetVFOButton) Released() {
 	// ignore
 }
+
+func (b *SetVFOButton) Close() {
+	b.client.Unlisten(b)
+}
@@ -0,0 +1,24 @@
+package hamlib
+
+import (
+	"testing"
+
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/rigproxy/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwitchToBandButton_RemembersTheLastFrequencyAndModeOnTheBand(t *testing.T) {
+	button := &SwitchToBandButton{band: bandplan.IARURegion1[bandplan.Band40m]}
+
+	button.SetMode(client.ModeCW)
+	button.SetFrequency(7012000)
+	assert.Equal(t, bandMemory{Frequency: 7012000, Mode: client.ModeCW}, button.memory)
+
+	button.SetMode(client.ModeUSB)
+	assert.Equal(t, bandMemory{Frequency: 7012000, Mode: client.ModeUSB}, button.memory)
+
+	button.SetFrequency(14025000)
+	button.SetMode(client.ModeCW)
+	assert.Equal(t, bandMemory{Frequency: 7012000, Mode: client.ModeUSB}, button.memory, "other bands do not change the memory")
+}
@@ -2,12 +2,12 @@ package hamlib
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/ftl/rigproxy/pkg/client"
 
 	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/metrics"
 )
 
 type ReconnectListener interface {
@@ -143,20 +143,23 @@ func NotifyPTTListeners(listeners []interface{}, ptt client.PTT) {
 	}
 }
 
-func NewClient(address string) *HamlibClient {
+func NewClient(name string, address string) *HamlibClient {
 	return &HamlibClient{
+		name:            hamdeck.StateConnectionName(name, ConnectionType),
 		address:         address,
 		pollingInterval: 500 * time.Millisecond,
 		pollingTimeout:  2 * time.Second,
 		retryInterval:   5 * time.Second,
 		requestTimeout:  500 * time.Millisecond,
 		done:            make(chan struct{}),
+		listeners:       hamdeck.NewListeners(),
 	}
 }
 
 type HamlibClient struct {
 	Conn *client.Conn
 
+	name            string
 	address         string
 	pollingInterval time.Duration
 	pollingTimeout  time.Duration
@@ -166,7 +169,7 @@ type HamlibClient struct {
 	closed          chan struct{}
 	done            chan struct{}
 
-	listeners []interface{}
+	listeners *hamdeck.Listeners
 }
 
 func (c *HamlibClient) KeepOpen() {
@@ -179,20 +182,22 @@ func (c *HamlibClient) KeepOpen() {
 			if err == nil {
 				select {
 				case <-disconnected:
-					log.Print("Connection lost to Hamlib, waiting for retry.")
+					logger.Warn("Connection lost to Hamlib, waiting for retry")
+					hamdeck.NotifyErrorListeners(c.listeners.All(), hamdeck.ErrConnectionLost)
 				case <-c.done:
-					log.Print("Connection to Hamlib closed.")
+					logger.Info("Connection to Hamlib closed")
 					return
 				}
 			} else {
-				log.Printf("Cannot connect to Hamlib, waiting for retry: %v", err)
+				logger.Warn("Cannot connect to Hamlib, waiting for retry", "error", err)
+				hamdeck.NotifyErrorListeners(c.listeners.All(), err)
 			}
 
 			select {
 			case <-time.After(c.retryInterval):
-				log.Print("Retrying to connect to Hamlib")
+				logger.Info("Retrying to connect to Hamlib")
 			case <-c.done:
-				log.Print("Connection to Hamlib closed.")
+				logger.Info("Connection to Hamlib closed")
 				return
 			}
 		}
@@ -213,7 +218,7 @@ func (c *HamlibClient) connect(whenClosed func()) error {
 
 	c.closed = make(chan struct{})
 	c.connected = true
-	hamdeck.NotifyEnablers(c.listeners, true)
+	hamdeck.NotifyEnablers(c.listeners.All(), true)
 
 	c.Conn.StartPolling(c.pollingInterval, c.pollingTimeout,
 		client.PollCommand(client.OnVFO(c.setVFO)),
@@ -225,7 +230,7 @@ func (c *HamlibClient) connect(whenClosed func()) error {
 
 	c.Conn.WhenClosed(func() {
 		c.connected = false
-		hamdeck.NotifyEnablers(c.listeners, false)
+		hamdeck.NotifyEnablers(c.listeners.All(), false)
 
 		if whenClosed != nil {
 			whenClosed()
@@ -245,9 +250,14 @@ func (c *HamlibClient) Close() {
 	}
 }
 
-func (c *HamlibClient) WithRequestTimeout() context.Context {
-	ctx, _ := context.WithTimeout(context.Background(), c.requestTimeout)
-	return ctx
+// Request sends a request to rigctld using the request timeout and records the duration of the request.
+func (c *HamlibClient) Request(command string, request func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+	start := time.Now()
+	err := request(ctx)
+	metrics.HamlibRequest(c.name, command, start, err)
+	return err
 }
 
 func (c *HamlibClient) Connected() bool {
@@ -255,26 +265,31 @@ func (c *HamlibClient) Connected() bool {
 }
 
 func (c *HamlibClient) setVFO(vfo client.VFO) {
-	NotifyVFOListeners(c.listeners, vfo)
+	NotifyVFOListeners(c.listeners.All(), vfo)
 }
 
 func (c *HamlibClient) setFrequency(frequency client.Frequency) {
-	NotifyFrequencyListeners(c.listeners, frequency)
+	NotifyFrequencyListeners(c.listeners.All(), frequency)
 }
 
 func (c *HamlibClient) setModeAndPassband(mode client.Mode, passband client.Frequency) {
-	NotifyModeListeners(c.listeners, mode)
-	NotifyPassbandListeners(c.listeners, passband)
+	NotifyModeListeners(c.listeners.All(), mode)
+	NotifyPassbandListeners(c.listeners.All(), passband)
 }
 
 func (c *HamlibClient) setPowerLevel(powerLevel float64) {
-	NotifyPowerLevelListeners(c.listeners, powerLevel)
+	NotifyPowerLevelListeners(c.listeners.All(), powerLevel)
 }
 
 func (c *HamlibClient) setPTT(ptt client.PTT) {
-	NotifyPTTListeners(c.listeners, ptt)
+	NotifyPTTListeners(c.listeners.All(), ptt)
 }
 
 func (c *HamlibClient) Listen(listener interface{}) {
-	c.listeners = append(c.listeners, listener)
+	c.listeners.Add(listener)
+}
+
+// Unlisten removes the given listener, e.g. when its button is closed.
+func (c *HamlibClient) Unlisten(listener interface{}) {
+	c.listeners.Remove(listener)
 }
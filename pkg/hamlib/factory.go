@@ -1,14 +1,17 @@
 package hamlib
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/ftl/rigproxy/pkg/client"
 
 	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
 )
 
+var logger = logging.For("hamlib")
+
 const (
 	ConfigAddress   = "address"
 	ConfigCommand   = "command"
@@ -38,12 +41,16 @@ const (
 	SetVFOButtonType        = "hamlib.SetVFO"
 )
 
-func NewButtonFactory(provider hamdeck.ConnectionConfigProvider, legacyAddress string) *Factory {
-	result := &Factory{}
-	result.connections = hamdeck.NewConnectionManager(ConnectionType, provider, result.createHamlibClient)
+func NewButtonFactory(station hamdeck.Station, legacyAddress string) *Factory {
+	result := &Factory{
+		station: station,
+	}
+	result.connections = hamdeck.NewConnectionManager(ConnectionType, station, result.createHamlibClient)
+	result.connections.SetCloser((*HamlibClient).Close)
 
 	if legacyAddress != "" {
-		client := NewClient(legacyAddress)
+		client := NewClient(hamdeck.LegacyConnectionName, legacyAddress)
+		client.Listen(newStatePublisher(hamdeck.LegacyConnectionName, station))
 		client.KeepOpen()
 		result.connections.SetLegacy(client)
 	}
@@ -52,7 +59,9 @@ func NewButtonFactory(provider hamdeck.ConnectionConfigProvider, legacyAddress s
 }
 
 type Factory struct {
+	station     hamdeck.Station
 	connections *hamdeck.ConnectionManager[*HamlibClient]
+	simulators  map[*HamlibClient]*Simulator
 }
 
 func (f *Factory) createHamlibClient(name string, config hamdeck.ConnectionConfig) (*HamlibClient, error) {
@@ -61,7 +70,8 @@ func (f *Factory) createHamlibClient(name string, config hamdeck.ConnectionConfi
 		return nil, fmt.Errorf("no address defined for hamlib connection %s", name)
 	}
 
-	client := NewClient(address)
+	client := NewClient(name, address)
+	client.Listen(newStatePublisher(name, f.station))
 	client.KeepOpen()
 
 	return client, nil
@@ -71,6 +81,30 @@ func (f *Factory) Close() {
 	f.connections.ForEach(func(client *HamlibClient) {
 		client.Close()
 	})
+	for _, simulator := range f.simulators {
+		simulator.Close()
+	}
+}
+
+func (f *Factory) RequestState(connection string, _ string) bool {
+	_, err := f.connections.GetByStateName(connection)
+	return err == nil
+}
+
+// StopTX unkeys the transmitter of the given connection.
+func (f *Factory) StopTX(connection string) (func() error, bool) {
+	hamlibClient, err := f.connections.GetByStateName(connection)
+	if err != nil {
+		return nil, false
+	}
+	return func() error {
+		if !hamlibClient.Connected() {
+			return fmt.Errorf("hamlib connection %s is not connected", connection)
+		}
+		return hamlibClient.Request("set_ptt", func(ctx context.Context) error {
+			return hamlibClient.Conn.SetPTT(ctx, client.PTTRx)
+		})
+	}, true
 }
 
 func (f *Factory) CreateButton(config map[string]interface{}) hamdeck.Button {
@@ -100,7 +134,7 @@ func (f *Factory) createSetModeButton(config map[string]interface{}) hamdeck.But
 	label, _ := hamdeck.ToString(config[ConfigLabel])
 	icon, haveIcon := hamdeck.ToString(config[ConfigIcon])
 	if !haveMode {
-		log.Print("A hamlib.SetMode button must have a mode field.")
+		logger.Error("A hamlib.SetMode button must have a mode field")
 		return nil
 	}
 	if !haveBandwidth {
@@ -113,7 +147,7 @@ func (f *Factory) createSetModeButton(config map[string]interface{}) hamdeck.But
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	hamlibClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create hamlib.SetMode button: %v", err)
+		logger.Error("Cannot create hamlib.SetMode button", "error", err)
 		return nil
 	}
 
@@ -126,14 +160,14 @@ func (f *Factory) createToggleModeButton(config map[string]interface{}) hamdeck.
 	mode2, haveMode2 := hamdeck.ToString(config[ConfigMode2])
 	label2, _ := hamdeck.ToString(config[ConfigLabel])
 	if !(haveMode1 && haveMode2) {
-		log.Print("A hamlib.ToggleMode button must have mode1 and mode2 fields.")
+		logger.Error("A hamlib.ToggleMode button must have mode1 and mode2 fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	hamlibClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create hamlib.ToggleMode button: %v", err)
+		logger.Error("Cannot create hamlib.ToggleMode button", "error", err)
 		return nil
 	}
 
@@ -145,14 +179,14 @@ func (f *Factory) createSetButton(config map[string]interface{}) hamdeck.Button
 	label, haveLabel := hamdeck.ToString(config[ConfigLabel])
 	args, _ := hamdeck.ToStringArray(config[ConfigArgs])
 	if !(haveCommand && haveLabel) {
-		log.Print("A hamlib.Set button must have command and label fields.")
+		logger.Error("A hamlib.Set button must have command and label fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	hamlibClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create hamlib.Set button: %v", err)
+		logger.Error("Cannot create hamlib.Set button", "error", err)
 		return nil
 	}
 
@@ -164,14 +198,14 @@ func (f *Factory) createSwitchToBandButton(config map[string]interface{}) hamdec
 	label, _ := hamdeck.ToString(config[ConfigLabel])
 	useUpDown, _ := hamdeck.ToBool(config[ConfigUseUpDown])
 	if !(haveBand) {
-		log.Print("A hamlib.SwitchToBand button must have a band field.")
+		logger.Error("A hamlib.SwitchToBand button must have a band field")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	hamlibClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create hamlib.SwitchToBand button: %v", err)
+		logger.Error("Cannot create hamlib.SwitchToBand button", "error", err)
 		return nil
 	}
 
@@ -182,14 +216,14 @@ func (f *Factory) createSetPowerLevelButton(config map[string]interface{}) hamde
 	value, haveValue := hamdeck.ToFloat(config[ConfigValue])
 	label, haveLabel := hamdeck.ToString(config[ConfigLabel])
 	if !(haveValue && haveLabel) {
-		log.Print("A hamlib.SetPowerLevel button must have value and label fields.")
+		logger.Error("A hamlib.SetPowerLevel button must have value and label fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	hamlibClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create hamlib.SetPowerLevel button: %v", err)
+		logger.Error("Cannot create hamlib.SetPowerLevel button", "error", err)
 		return nil
 	}
 
@@ -202,7 +236,7 @@ func (f *Factory) createMOXButton(config map[string]interface{}) hamdeck.Button
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	hamlibClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create hamlib.MOX button: %v", err)
+		logger.Error("Cannot create hamlib.MOX button", "error", err)
 		return nil
 	}
 
@@ -213,14 +247,14 @@ func (f *Factory) createSetVFOButton(config map[string]interface{}) hamdeck.Butt
 	vfo, haveVFO := hamdeck.ToString(config[ConfigVFO])
 	label, haveLabel := hamdeck.ToString(config[ConfigLabel])
 	if !(haveVFO && haveLabel) {
-		log.Print("A hamlib.SetVFO button must have vfo and label fields.")
+		logger.Error("A hamlib.SetVFO button must have vfo and label fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	hamlibClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create hamlib.SetVFO button: %v", err)
+		logger.Error("Cannot create hamlib.SetVFO button", "error", err)
 		return nil
 	}
 
@@ -0,0 +1,146 @@
+package hamlib
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/rigproxy/pkg/client"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+const (
+	ConfigBands = "bands"
+	ConfigModes = "modes"
+)
+
+// The kinds of pages generated by the hamlib factory.
+const (
+	BandsGenerator = "bands"
+	ModesGenerator = "modes"
+)
+
+// The predefined selections of bands.
+const (
+	AllBands     = "all"
+	AllHFBands   = "all-hf"
+	ContestBands = "contest"
+)
+
+// DefaultModes are used for generated mode pages without a list of modes.
+var DefaultModes = []client.Mode{client.ModeLSB, client.ModeUSB, client.ModeCW, client.ModeRTTY, client.ModePKTUSB, client.ModeAM, client.ModeFM}
+
+var contestBands = []bandplan.BandName{bandplan.Band160m, bandplan.Band80m, bandplan.Band40m, bandplan.Band20m, bandplan.Band15m, bandplan.Band10m}
+
+const hfUpperLimit = 30000000
+
+// GenerateButtons generates a hamlib.SwitchToBand button for each selected band of the IARU region 1 bandplan,
+// or a hamlib.SetMode button for each selected mode.
+func (f *Factory) GenerateButtons(config map[string]any, _ func()) ([]map[string]any, bool) {
+	kind, _ := hamdeck.ToString(config[hamdeck.ConfigKind])
+	if kind != BandsGenerator && kind != ModesGenerator {
+		return nil, false
+	}
+	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
+	_, err := f.connections.Get(connection)
+	if err != nil {
+		return nil, false
+	}
+
+	var result []map[string]any
+	switch kind {
+	case BandsGenerator:
+		useUpDown, _ := hamdeck.ToBool(config[ConfigUseUpDown])
+		for _, band := range selectBands(config[ConfigBands]) {
+			result = append(result, map[string]any{
+				hamdeck.ConfigType:       SwitchToBandButtonType,
+				hamdeck.ConfigConnection: connection,
+				ConfigBand:               string(band),
+				ConfigLabel:              string(band),
+				ConfigUseUpDown:          useUpDown,
+			})
+		}
+	case ModesGenerator:
+		for _, mode := range selectModes(config[ConfigModes]) {
+			result = append(result, map[string]any{
+				hamdeck.ConfigType:       SetModeButtonType,
+				hamdeck.ConfigConnection: connection,
+				ConfigMode:               string(mode),
+				ConfigLabel:              string(mode),
+			})
+		}
+	}
+	return result, true
+}
+
+// selectBands returns the bands of the given selection ordered by frequency. The selection is either one of the
+// predefined selections or a list of band names, given as array or as comma separated string.
+func selectBands(rawSelection any) []bandplan.BandName {
+	names, ok := hamdeck.ToStringArray(rawSelection)
+	if !ok {
+		selection, _ := hamdeck.ToString(rawSelection)
+		names = strings.Split(selection, ",")
+	}
+
+	var bands []bandplan.Band
+	if len(names) == 1 {
+		switch strings.TrimSpace(names[0]) {
+		case "", AllBands:
+			bands = filterBands(func(bandplan.Band) bool { return true })
+		case AllHFBands:
+			bands = filterBands(func(band bandplan.Band) bool { return band.To <= hfUpperLimit })
+		case ContestBands:
+			names = make([]string, len(contestBands))
+			for i, name := range contestBands {
+				names[i] = string(name)
+			}
+		}
+	}
+	if bands == nil {
+		for _, name := range names {
+			band, ok := bandplan.IARURegion1[bandplan.BandName(strings.TrimSpace(name))]
+			if !ok {
+				logger.Error("unknown band", "band", name)
+				continue
+			}
+			bands = append(bands, band)
+		}
+	}
+
+	sort.Slice(bands, func(i, j int) bool {
+		return bands[i].From < bands[j].From
+	})
+	result := make([]bandplan.BandName, len(bands))
+	for i, band := range bands {
+		result[i] = band.Name
+	}
+	return result
+}
+
+func filterBands(include func(bandplan.Band) bool) []bandplan.Band {
+	var result []bandplan.Band
+	for _, band := range bandplan.IARURegion1 {
+		if include(band) {
+			result = append(result, band)
+		}
+	}
+	return result
+}
+
+// selectModes returns the given list of modes, or the default modes.
+func selectModes(rawSelection any) []client.Mode {
+	names, ok := hamdeck.ToStringArray(rawSelection)
+	if !ok {
+		selection, _ := hamdeck.ToString(rawSelection)
+		if selection == "" {
+			return DefaultModes
+		}
+		names = strings.Split(selection, ",")
+	}
+	result := make([]client.Mode, 0, len(names))
+	for _, name := range names {
+		result = append(result, client.Mode(strings.ToUpper(strings.TrimSpace(name))))
+	}
+	return result
+}
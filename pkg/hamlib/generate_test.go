@@ -0,0 +1,35 @@
+package hamlib
+
+import (
+	"testing"
+
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/rigproxy/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectBands(t *testing.T) {
+	tt := []struct {
+		name      string
+		selection any
+		expected  []bandplan.BandName
+	}{
+		{name: "all-hf", selection: "all-hf", expected: []bandplan.BandName{"160m", "80m", "60m", "40m", "30m", "20m", "17m", "15m", "12m", "10m"}},
+		{name: "all", selection: "all", expected: []bandplan.BandName{"160m", "80m", "60m", "40m", "30m", "20m", "17m", "15m", "12m", "10m", "6m"}},
+		{name: "default", selection: nil, expected: []bandplan.BandName{"160m", "80m", "60m", "40m", "30m", "20m", "17m", "15m", "12m", "10m", "6m"}},
+		{name: "contest", selection: "contest", expected: []bandplan.BandName{"160m", "80m", "40m", "20m", "15m", "10m"}},
+		{name: "list", selection: []any{"20m", "80m", "2m", "40m"}, expected: []bandplan.BandName{"80m", "40m", "20m"}},
+		{name: "comma separated", selection: "10m, 6m", expected: []bandplan.BandName{"10m", "6m"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, selectBands(tc.selection))
+		})
+	}
+}
+
+func TestSelectModes(t *testing.T) {
+	assert.Equal(t, DefaultModes, selectModes(nil))
+	assert.Equal(t, []client.Mode{client.ModeCW, client.ModePKTUSB}, selectModes([]any{"CW", "pktusb"}))
+	assert.Equal(t, []client.Mode{client.ModeUSB, client.ModeLSB}, selectModes("usb,lsb"))
+}
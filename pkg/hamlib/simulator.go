@@ -0,0 +1,245 @@
+package hamlib
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/rigproxy/pkg/client"
+	"github.com/ftl/rigproxy/pkg/protocol"
+	"github.com/ftl/rigproxy/pkg/proxy"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+// hamlib error code for features that are not implemented
+const simulatorNotImplemented = "-4"
+
+// the bands in the order of the BAND_SELECT values
+var simulatorBands = []bandplan.BandName{
+	bandplan.Band160m,
+	bandplan.Band80m,
+	bandplan.Band60m,
+	bandplan.Band40m,
+	bandplan.Band30m,
+	bandplan.Band20m,
+	bandplan.Band17m,
+	bandplan.Band15m,
+	bandplan.Band12m,
+	bandplan.Band10m,
+}
+
+// NewSimulatedButtonFactory creates a factory for hamlib buttons that are connected to simulated radios instead of
+// rigctld servers. Every command that the buttons send is logged.
+func NewSimulatedButtonFactory(station hamdeck.Station, legacyAddress string) *Factory {
+	result := &Factory{
+		station:    station,
+		simulators: make(map[*HamlibClient]*Simulator),
+	}
+	result.connections = hamdeck.NewConnectionManager(ConnectionType, station, result.createSimulatedClient)
+	result.connections.SetCloser(result.closeSimulatedClient)
+
+	if legacyAddress != "" {
+		client, err := result.createSimulatedClient(hamdeck.LegacyConnectionName, nil)
+		if err != nil {
+			logger.Error("Cannot create the simulated legacy hamlib connection", "error", err)
+		} else {
+			result.connections.SetLegacy(client)
+		}
+	}
+
+	return result
+}
+
+func (f *Factory) createSimulatedClient(name string, _ hamdeck.ConnectionConfig) (*HamlibClient, error) {
+	simulator, err := NewSimulator(name)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(name, simulator.Address())
+	client.Listen(newStatePublisher(name, f.station))
+	client.KeepOpen()
+	f.simulators[client] = simulator
+
+	return client, nil
+}
+
+func (f *Factory) closeSimulatedClient(client *HamlibClient) {
+	client.Close()
+	simulator, ok := f.simulators[client]
+	if !ok {
+		return
+	}
+	delete(f.simulators, client)
+	simulator.Close()
+}
+
+// Simulator is an in-process rigctld server that keeps a plausible state of a radio.
+type Simulator struct {
+	name     string
+	listener net.Listener
+	done     chan struct{}
+
+	lock       *sync.Mutex
+	vfo        string
+	frequency  int
+	mode       string
+	passband   int
+	powerLevel float64
+	ptt        string
+}
+
+// NewSimulator starts a simulated rigctld server on a free local port.
+func NewSimulator(name string) (*Simulator, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("cannot start the hamlib simulator: %w", err)
+	}
+
+	result := &Simulator{
+		name:       name,
+		listener:   listener,
+		done:       make(chan struct{}),
+		lock:       new(sync.Mutex),
+		vfo:        "VFOA",
+		frequency:  14074000,
+		mode:       "PKTUSB",
+		passband:   3000,
+		powerLevel: 0.5,
+		ptt:        "0",
+	}
+	go result.serve()
+
+	return result, nil
+}
+
+func (s *Simulator) Address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Simulator) Close() {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+		s.listener.Close()
+	}
+}
+
+func (s *Simulator) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		proxy.New(conn, s, s.done, false)
+	}
+}
+
+// Send handles a request of a hamlib client. It implements the proxy.Transceiver interface.
+func (s *Simulator) Send(_ context.Context, request protocol.Request) (protocol.Response, error) {
+	if !strings.HasPrefix(request.Long, "get_") {
+		logger.Info("simulated hamlib command", "connection", s.name, "command", request.LongFormat())
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch request.Long {
+	case "get_vfo":
+		return protocol.GetVFOResponse(s.vfo), nil
+	case "set_vfo":
+		s.vfo = s.arg(request, 0, s.vfo)
+	case "get_freq":
+		return protocol.GetFreqResponse(s.frequency), nil
+	case "set_freq":
+		frequency, err := strconv.ParseFloat(s.arg(request, 0, ""), 64)
+		if err != nil {
+			return protocol.ErrorResponse(request.Key(), "-1"), nil
+		}
+		s.frequency = int(frequency)
+	case "get_mode":
+		return protocol.GetModeResponse(s.mode, s.passband), nil
+	case "set_mode":
+		s.mode = s.arg(request, 0, s.mode)
+		passband, err := strconv.Atoi(s.arg(request, 1, "0"))
+		if err == nil && passband > 0 {
+			s.passband = passband
+		}
+	case "get_ptt":
+		return protocol.GetPTTResponse(s.ptt != "0"), nil
+	case "set_ptt":
+		s.ptt = s.arg(request, 0, s.ptt)
+	case "get_level":
+		if s.arg(request, 0, "") != "RFPOWER" {
+			return protocol.ErrorResponse(request.Key(), simulatorNotImplemented), nil
+		}
+		return protocol.Response{
+			Command: request.Key(),
+			Data:    []string{fmt.Sprintf("%f", s.powerLevel)},
+			Keys:    []string{""},
+			Result:  "0",
+		}, nil
+	case "set_level":
+		return s.setLevel(request), nil
+	case "vfo_op":
+		return s.vfoOp(request), nil
+	}
+	return protocol.OKResponse(request.Key()), nil
+}
+
+func (s *Simulator) arg(request protocol.Request, i int, defaultValue string) string {
+	if i >= len(request.Args) {
+		return defaultValue
+	}
+	return request.Args[i]
+}
+
+func (s *Simulator) setLevel(request protocol.Request) protocol.Response {
+	switch s.arg(request, 0, "") {
+	case "RFPOWER":
+		powerLevel, err := strconv.ParseFloat(s.arg(request, 1, ""), 64)
+		if err != nil {
+			return protocol.ErrorResponse(request.Key(), "-1")
+		}
+		s.powerLevel = powerLevel
+	case "BAND_SELECT":
+		index, err := strconv.Atoi(s.arg(request, 1, ""))
+		if err != nil || index < 0 || index >= len(simulatorBands) {
+			return protocol.ErrorResponse(request.Key(), "-1")
+		}
+		s.switchToBand(index)
+	}
+	return protocol.OKResponse(request.Key())
+}
+
+func (s *Simulator) vfoOp(request protocol.Request) protocol.Response {
+	current := s.currentBandIndex()
+	switch s.arg(request, 0, "") {
+	case "BAND_UP":
+		s.switchToBand((current + 1) % len(simulatorBands))
+	case "BAND_DOWN":
+		s.switchToBand((current - 1 + len(simulatorBands)) % len(simulatorBands))
+	}
+	return protocol.OKResponse(request.Key())
+}
+
+func (s *Simulator) currentBandIndex() int {
+	band := bandplan.IARURegion1.ByFrequency(client.Frequency(s.frequency))
+	for i, name := range simulatorBands {
+		if name == band.Name {
+			return i
+		}
+	}
+	return 0
+}
+
+func (s *Simulator) switchToBand(index int) {
+	band := bandplan.IARURegion1[simulatorBands[index]]
+	s.frequency = int(band.Center())
+}
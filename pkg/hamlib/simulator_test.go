@@ -0,0 +1,64 @@
+package hamlib
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/rigproxy/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulator(t *testing.T) {
+	simulator, err := NewSimulator("test")
+	require.NoError(t, err)
+	defer simulator.Close()
+
+	conn, err := client.Open(simulator.Address())
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, conn.SetFrequency(ctx, 7030000))
+	frequency, err := conn.Frequency(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, client.Frequency(7030000), frequency)
+
+	require.NoError(t, conn.SetModeAndPassband(ctx, client.ModeCW, 500))
+	mode, passband, err := conn.ModeAndPassband(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, client.ModeCW, mode)
+	assert.Equal(t, client.Frequency(500), passband)
+
+	require.NoError(t, conn.SetPowerLevel(ctx, 0.25))
+	powerLevel, err := conn.PowerLevel(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, powerLevel)
+
+	require.NoError(t, conn.SetPTT(ctx, client.PTTTx))
+	ptt, err := conn.PTT(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, client.PTTTx, ptt)
+
+	require.NoError(t, conn.SwitchToBand(ctx, bandplan.IARURegion1[bandplan.Band20m]))
+	frequency, err = conn.Frequency(ctx)
+	require.NoError(t, err)
+	assert.True(t, bandplan.IARURegion1[bandplan.Band20m].Contains(frequency), "%v", frequency)
+}
+
+func TestSimulatedButtonFactory_ClosesTheSimulatorWithTheClient(t *testing.T) {
+	simulator, err := NewSimulator("rig")
+	require.NoError(t, err)
+	client := NewClient("rig", simulator.Address())
+	factory := &Factory{simulators: map[*HamlibClient]*Simulator{client: simulator}}
+
+	factory.closeSimulatedClient(client)
+
+	assert.Empty(t, factory.simulators)
+	_, err = net.Dial("tcp", simulator.Address())
+	assert.Error(t, err, "the simulator is still listening")
+}
@@ -0,0 +1,52 @@
+package hamlib
+
+import (
+	"fmt"
+
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/rigproxy/pkg/client"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+// statePublisher publishes the state of a hamlib connection to the HamDeck.
+type statePublisher struct {
+	connection string
+	publisher  hamdeck.StatePublisher
+}
+
+func newStatePublisher(name string, publisher hamdeck.StatePublisher) *statePublisher {
+	result := &statePublisher{
+		connection: hamdeck.StateConnectionName(name, ConnectionType),
+		publisher:  publisher,
+	}
+	result.Enable(false)
+	return result
+}
+
+func (p *statePublisher) Enable(enabled bool) {
+	p.publisher.PublishState(p.connection, hamdeck.StateConnected, hamdeck.FormatBoolState(enabled))
+}
+
+func (p *statePublisher) ConnectionFailed(err error) {
+	p.publisher.PublishState(p.connection, hamdeck.StateError, err.Error())
+}
+
+func (p *statePublisher) SetVFO(vfo client.VFO) {
+	p.publisher.PublishState(p.connection, hamdeck.StateVFO, string(vfo))
+}
+
+func (p *statePublisher) SetFrequency(frequency client.Frequency) {
+	p.publisher.PublishState(p.connection, hamdeck.StateFrequency, fmt.Sprintf("%.0f", frequency))
+
+	band := bandplan.IARURegion1.ByFrequency(frequency)
+	p.publisher.PublishState(p.connection, hamdeck.StateBand, string(band.Name))
+}
+
+func (p *statePublisher) SetMode(mode client.Mode) {
+	p.publisher.PublishState(p.connection, hamdeck.StateMode, string(mode))
+}
+
+func (p *statePublisher) SetPTT(ptt client.PTT) {
+	p.publisher.PublishState(p.connection, hamdeck.StatePTT, hamdeck.FormatBoolState(ptt != client.PTTRx))
+}
@@ -0,0 +1,184 @@
+// Package logging provides leveled, structured loggers for the subsystems of HamDeck. The level of each subsystem
+// can be set from the command line and from the configuration file; the command line takes precedence.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SubsystemKey is the attribute that names the subsystem of a log record.
+const SubsystemKey = "subsystem"
+
+// DefaultLevel is used for subsystems without a configured level.
+const DefaultLevel = slog.LevelInfo
+
+// Format is the output format of the log records.
+type Format string
+
+const (
+	TextFormat Format = "text"
+	JSONFormat Format = "json"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case TextFormat:
+		return TextFormat, nil
+	case JSONFormat:
+		return JSONFormat, nil
+	default:
+		return "", fmt.Errorf("unknown log format %s", s)
+	}
+}
+
+// Levels maps subsystems to their log level. The empty subsystem holds the level for all other subsystems.
+type Levels map[string]slog.Level
+
+// ParseLevels parses a comma separated list of levels, e.g. "warn,mqtt=debug,hamlib=info". An entry without
+// a subsystem sets the level for all other subsystems.
+func ParseLevels(s string) (Levels, error) {
+	result := make(Levels)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		subsystem, rawLevel, found := strings.Cut(entry, "=")
+		if !found {
+			subsystem, rawLevel = "", entry
+		}
+		var level slog.Level
+		err := level.UnmarshalText([]byte(strings.TrimSpace(rawLevel)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %s: %w", entry, err)
+		}
+		result[strings.TrimSpace(subsystem)] = level
+	}
+	return result, nil
+}
+
+// the global logging setup
+var (
+	lock         = new(sync.RWMutex)
+	output       slog.Handler
+	flagLevels   = make(Levels)
+	configLevels = make(Levels)
+)
+
+func init() {
+	output = newTextHandler(os.Stderr)
+}
+
+func newTextHandler(w io.Writer) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+}
+
+func newJSONHandler(w io.Writer) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+}
+
+// SetOutput writes all log records in the given format into the given writer.
+func SetOutput(w io.Writer, format Format) {
+	if format == JSONFormat {
+		SetHandler(newJSONHandler(w))
+	} else {
+		SetHandler(newTextHandler(w))
+	}
+}
+
+// SetHandler passes all log records that are enabled by the subsystem levels to the given handler.
+func SetHandler(handler slog.Handler) {
+	lock.Lock()
+	defer lock.Unlock()
+	output = handler
+}
+
+// SetLevels sets the levels given on the command line. They override the levels from the configuration file.
+func SetLevels(levels Levels) {
+	lock.Lock()
+	defer lock.Unlock()
+	flagLevels = levels
+}
+
+// SetConfigLevels sets the levels from the configuration file.
+func SetConfigLevels(levels Levels) {
+	lock.Lock()
+	defer lock.Unlock()
+	configLevels = levels
+}
+
+// Level returns the effective level of the given subsystem.
+func Level(subsystem string) slog.Level {
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, levels := range []Levels{flagLevels, configLevels} {
+		if level, ok := levels[subsystem]; ok {
+			return level
+		}
+	}
+	for _, levels := range []Levels{flagLevels, configLevels} {
+		if level, ok := levels[""]; ok {
+			return level
+		}
+	}
+	return DefaultLevel
+}
+
+func currentOutput() slog.Handler {
+	lock.RLock()
+	defer lock.RUnlock()
+	return output
+}
+
+// For returns the logger of the given subsystem. The logger follows all later changes of the output and the levels,
+// so it can be created when the package is initialized.
+func For(subsystem string) *slog.Logger {
+	return slog.New(&subsystemHandler{subsystem: subsystem})
+}
+
+// subsystemHandler filters the records by the level of its subsystem and passes them on to the current output.
+// The attributes and groups added to the handler are applied to the output in the order they were added.
+type subsystemHandler struct {
+	subsystem string
+	with      []func(slog.Handler) slog.Handler
+}
+
+func (h *subsystemHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= Level(h.subsystem)
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, record slog.Record) error {
+	handler := currentOutput().WithAttrs([]slog.Attr{slog.String(SubsystemKey, h.subsystem)})
+	for _, with := range h.with {
+		handler = with(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.and(func(handler slog.Handler) slog.Handler {
+		return handler.WithAttrs(attrs)
+	})
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.and(func(handler slog.Handler) slog.Handler {
+		return handler.WithGroup(name)
+	})
+}
+
+func (h *subsystemHandler) and(with func(slog.Handler) slog.Handler) *subsystemHandler {
+	return &subsystemHandler{
+		subsystem: h.subsystem,
+		with:      append(append([]func(slog.Handler) slog.Handler{}, h.with...), with),
+	}
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestOutput(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buffer := new(bytes.Buffer)
+	SetOutput(buffer, JSONFormat)
+	t.Cleanup(func() {
+		SetOutput(os.Stderr, TextFormat)
+		SetLevels(nil)
+		SetConfigLevels(nil)
+	})
+	return buffer
+}
+
+func readRecords(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := make(map[string]any)
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestParseLevels(t *testing.T) {
+	tt := []struct {
+		value    string
+		expected Levels
+		invalid  bool
+	}{
+		{value: "", expected: Levels{}},
+		{value: "debug", expected: Levels{"": slog.LevelDebug}},
+		{value: "warn, mqtt=debug,hamlib=INFO", expected: Levels{"": slog.LevelWarn, "mqtt": slog.LevelDebug, "hamlib": slog.LevelInfo}},
+		{value: "mqtt=verbose", invalid: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := ParseLevels(tc.value)
+			if tc.invalid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestLevel_FlagsOverrideConfig(t *testing.T) {
+	setupTestOutput(t)
+	SetConfigLevels(Levels{"": slog.LevelWarn, "mqtt": slog.LevelDebug, "tci": slog.LevelDebug})
+	SetLevels(Levels{"tci": slog.LevelError})
+
+	assert.Equal(t, slog.LevelWarn, Level("hamlib"))
+	assert.Equal(t, slog.LevelDebug, Level("mqtt"))
+	assert.Equal(t, slog.LevelError, Level("tci"))
+
+	SetLevels(Levels{"": slog.LevelError})
+	assert.Equal(t, slog.LevelError, Level("hamlib"))
+	assert.Equal(t, slog.LevelDebug, Level("mqtt"))
+
+	SetLevels(nil)
+	SetConfigLevels(nil)
+	assert.Equal(t, DefaultLevel, Level("hamlib"))
+}
+
+func TestFor_FiltersBySubsystem(t *testing.T) {
+	buffer := setupTestOutput(t)
+	SetLevels(Levels{"mqtt": slog.LevelDebug})
+	mqttLogger := For("mqtt")
+	hamlibLogger := For("hamlib")
+
+	mqttLogger.Debug("received MQTT message", "topic", "atu/data")
+	hamlibLogger.Debug("polling")
+	hamlibLogger.Error("cannot set mode", "error", "timeout")
+
+	records := readRecords(t, buffer)
+	require.Len(t, records, 2)
+	assert.Equal(t, "mqtt", records[0][SubsystemKey])
+	assert.Equal(t, "DEBUG", records[0][slog.LevelKey])
+	assert.Equal(t, "atu/data", records[0]["topic"])
+	assert.Equal(t, "hamlib", records[1][SubsystemKey])
+	assert.Equal(t, "cannot set mode", records[1][slog.MessageKey])
+	assert.Equal(t, "timeout", records[1]["error"])
+}
+
+func TestFor_FollowsOutputChanges(t *testing.T) {
+	logger := For("hamdeck").With("page", "main").WithGroup("key")
+	buffer := setupTestOutput(t)
+
+	logger.Info("pressed", "index", 3)
+
+	records := readRecords(t, buffer)
+	require.Len(t, records, 1)
+	assert.Equal(t, "hamdeck", records[0][SubsystemKey])
+	assert.Equal(t, "main", records[0]["page"])
+	assert.Equal(t, map[string]any{"index": float64(3)}, records[0]["key"])
+}
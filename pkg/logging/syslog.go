@@ -0,0 +1,93 @@
+//go:build unix
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"log/syslog"
+	"sync"
+)
+
+// NewSyslogHandler creates a handler that sends the log records to the local syslog daemon. The level of a record
+// is mapped to the syslog priority.
+func NewSyslogHandler(tag string, format Format) (slog.Handler, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	result := &syslogHandler{
+		writer: writer,
+		lock:   new(sync.Mutex),
+		buffer: new(bytes.Buffer),
+	}
+	options := &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			// syslog adds its own timestamp
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	}
+	if format == JSONFormat {
+		result.formatter = slog.NewJSONHandler(result.buffer, options)
+	} else {
+		result.formatter = slog.NewTextHandler(result.buffer, options)
+	}
+	return result, nil
+}
+
+type syslogHandler struct {
+	writer    *syslog.Writer
+	lock      *sync.Mutex
+	buffer    *bytes.Buffer
+	formatter slog.Handler
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.buffer.Reset()
+	err := h.formatter.Handle(ctx, record)
+	if err != nil {
+		return err
+	}
+	message := string(bytes.TrimSuffix(h.buffer.Bytes(), []byte("\n")))
+
+	switch {
+	case record.Level >= slog.LevelError:
+		return h.writer.Err(message)
+	case record.Level >= slog.LevelWarn:
+		return h.writer.Warning(message)
+	case record.Level >= slog.LevelInfo:
+		return h.writer.Info(message)
+	default:
+		return h.writer.Debug(message)
+	}
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{
+		writer:    h.writer,
+		lock:      h.lock,
+		buffer:    h.buffer,
+		formatter: h.formatter.WithAttrs(attrs),
+	}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{
+		writer:    h.writer,
+		lock:      h.lock,
+		buffer:    h.buffer,
+		formatter: h.formatter.WithGroup(name),
+	}
+}
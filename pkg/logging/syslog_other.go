@@ -0,0 +1,13 @@
+//go:build !unix
+
+package logging
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// NewSyslogHandler is not supported on this platform.
+func NewSyslogHandler(tag string, format Format) (slog.Handler, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
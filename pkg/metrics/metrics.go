@@ -0,0 +1,131 @@
+// Package metrics provides the Prometheus metrics of HamDeck.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/ftl/hamdeck/pkg/logging"
+)
+
+const namespace = "hamdeck"
+
+var logger = logging.For("metrics")
+
+var (
+	registry = prometheus.NewRegistry()
+
+	keyPresses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "key_presses_total",
+		Help:      "The number of key presses per page and button type.",
+	}, []string{"page", "type"})
+
+	connectionUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "connection_up",
+		Help:      "1 if the connection is established, 0 otherwise.",
+	}, []string{"connection"})
+
+	reconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "connection_reconnects_total",
+		Help:      "The number of times a connection was established again after it was lost.",
+	}, []string{"connection"})
+
+	hamlibRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "hamlib",
+		Name:      "request_duration_seconds",
+		Help:      "The duration of the requests sent to rigctld.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"connection", "command", "result"})
+
+	redraws = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redraws_total",
+		Help:      "The number of redrawn keys.",
+	})
+
+	drawingDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "drawing_duration_seconds",
+		Help:      "The time spent to draw the image of a key.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1},
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		keyPresses,
+		connectionUp,
+		reconnects,
+		hamlibRequestDuration,
+		redraws,
+		drawingDuration,
+	)
+}
+
+// KeyPressed counts a key press on the given page on a button of the given type.
+func KeyPressed(page string, buttonType string) {
+	keyPresses.WithLabelValues(page, buttonType).Inc()
+}
+
+// ConnectionChanged sets the state of the given connection. A reconnect is counted if the connection was established
+// again after it was lost.
+func ConnectionChanged(connection string, connected bool, reconnected bool) {
+	if connected {
+		connectionUp.WithLabelValues(connection).Set(1)
+	} else {
+		connectionUp.WithLabelValues(connection).Set(0)
+	}
+	if reconnected {
+		reconnects.WithLabelValues(connection).Inc()
+	}
+}
+
+// HamlibRequest records the duration of a request to rigctld that was started at the given time.
+func HamlibRequest(connection string, command string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	hamlibRequestDuration.WithLabelValues(connection, command, result).Observe(time.Since(start).Seconds())
+}
+
+// Redraw records that the image of a key was drawn, which took the given time.
+func Redraw(duration time.Duration) {
+	redraws.Inc()
+	drawingDuration.Observe(duration.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Serve provides the metrics on /metrics at the given address in the background.
+func Serve(address string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("cannot serve the metrics", "address", address, "error", err)
+		}
+	}()
+	logger.Info("serving metrics", "address", address)
+	return server
+}
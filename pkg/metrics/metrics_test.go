@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	response, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestHandler_ProvidesMetrics(t *testing.T) {
+	KeyPressed("main", "hamlib.SetMode")
+	ConnectionChanged("hamlib:rig", true, false)
+	ConnectionChanged("mqtt:atu", false, false)
+	ConnectionChanged("mqtt:atu", true, true)
+	HamlibRequest("hamlib:rig", "set_mode", time.Now().Add(-10*time.Millisecond), nil)
+	HamlibRequest("hamlib:rig", "set_freq", time.Now(), errors.New("timeout"))
+	Redraw(2 * time.Millisecond)
+
+	body := scrape(t)
+
+	assert.Contains(t, body, `hamdeck_key_presses_total{page="main",type="hamlib.SetMode"} 1`)
+	assert.Contains(t, body, `hamdeck_connection_up{connection="hamlib:rig"} 1`)
+	assert.Contains(t, body, `hamdeck_connection_up{connection="mqtt:atu"} 1`)
+	assert.Contains(t, body, `hamdeck_connection_reconnects_total{connection="mqtt:atu"} 1`)
+	assert.Contains(t, body, `hamdeck_hamlib_request_duration_seconds_count{command="set_mode",connection="hamlib:rig",result="ok"} 1`)
+	assert.Contains(t, body, `hamdeck_hamlib_request_duration_seconds_count{command="set_freq",connection="hamlib:rig",result="error"} 1`)
+	assert.Contains(t, body, `hamdeck_redraws_total 1`)
+	assert.Contains(t, body, `hamdeck_drawing_duration_seconds_count 1`)
+	assert.Contains(t, body, `go_goroutines`)
+}
@@ -148,13 +148,21 @@ func (b *TuneButton) Pressed() {
 	if !(b.enabled && b.alive) {
 		return
 	}
-	b.client.Tune(b.path)
+	err := b.client.Tune(b.path)
+	b.AuditCommand(fmt.Sprintf("publish %s/cmd 1", b.path), err)
+	if err != nil {
+		logger.Error("cannot start tuning", "error", err)
+	}
 }
 
 func (b *TuneButton) Released() {
 	// ignore
 }
 
+func (b *TuneButton) Close() {
+	b.client.Unnotify(b)
+}
+
 /*
 	SwitchButton
 */
@@ -274,9 +282,88 @@ func (b *SwitchButton) Pressed() {
 		return
 	}
 
-	b.client.Publish(b.outputTopic, payload)
+	err := b.client.Publish(b.outputTopic, payload)
+	b.AuditCommand(fmt.Sprintf("publish %s %s", b.outputTopic, payload), err)
+	if err != nil {
+		logger.Error("cannot publish", "topic", b.outputTopic, "error", err)
+	}
 }
 
 func (b *SwitchButton) Released() {
 	// ignore
 }
+
+func (b *SwitchButton) Close() {
+	b.client.Unsubscribe(b)
+}
+
+/*
+	PublishButton
+*/
+
+func NewPublishButton(client *Client, label string, topic string, payload string) *PublishButton {
+	if label == "" {
+		label = "Pub"
+	}
+
+	result := &PublishButton{
+		client:  client,
+		enabled: client.Connected(),
+		label:   label,
+		topic:   topic,
+		payload: payload,
+	}
+
+	client.Notify(result)
+
+	return result
+}
+
+type PublishButton struct {
+	hamdeck.BaseButton
+	client  *Client
+	image   image.Image
+	enabled bool
+	label   string
+	topic   string
+	payload string
+}
+
+func (b *PublishButton) Enable(enabled bool) {
+	if enabled == b.enabled {
+		return
+	}
+	b.enabled = enabled
+	b.Invalidate(true)
+}
+
+func (b *PublishButton) Image(gc hamdeck.GraphicContext, redrawImages bool) image.Image {
+	if b.image == nil || redrawImages {
+		if b.enabled {
+			gc.SetForeground(hamdeck.White)
+		} else {
+			gc.SetForeground(hamdeck.DisabledGray)
+		}
+		b.image = gc.DrawSingleLineTextButton(b.label)
+	}
+	return b.image
+}
+
+func (b *PublishButton) Pressed() {
+	if !(b.enabled) {
+		return
+	}
+	err := b.client.Publish(b.topic, b.payload)
+	b.AuditCommand(fmt.Sprintf("publish %s %s", b.topic, b.payload), err)
+	if err != nil {
+		logger.Error("cannot publish", "topic", b.topic, "error", err)
+	}
+}
+
+func (b *PublishButton) Released() {
+	// ignore
+}
+
+func (b *PublishButton) Close() {
+	b.client.Unnotify(b)
+}
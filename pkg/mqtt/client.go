@@ -3,8 +3,8 @@ package mqtt
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
+	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -13,15 +13,8 @@ import (
 
 const mqttWaitTimeout = 200 * time.Millisecond
 
-func NewClient(address string, username string, password string) *Client {
-	result := &Client{
-		address:     address,
-		alive:       make(map[string]bool),
-		tx:          make(map[string]bool),
-		tuning:      make(map[string]bool),
-		swr:         make(map[string]float64),
-		subscribers: make(map[string][]Subscriber),
-	}
+func NewClient(name string, address string, username string, password string, station hamdeck.StatePublisher) *Client {
+	result := newClient(name, address, station)
 
 	opts := mqtt.NewClientOptions()
 	opts.AddBroker(fmt.Sprintf("tcp://%s", address))
@@ -39,26 +32,51 @@ func NewClient(address string, username string, password string) *Client {
 	opts.OnConnect = result.connected
 	opts.OnConnectionLost = result.connectionLost
 
-	result.client = mqtt.NewClient(opts)
-	if token := result.client.Connect(); token.WaitTimeout(mqttWaitTimeout) && token.Error() != nil {
-		log.Printf("cannot connect to MQTT broker: %v", token.Error())
-	}
+	result.connect(mqtt.NewClient(opts))
 
 	return result
 }
 
+func newClient(name string, address string, station hamdeck.StatePublisher) *Client {
+	return &Client{
+		stateConnection: hamdeck.StateConnectionName(name, ConnectionType),
+		station:         station,
+		address:         address,
+		alive:           make(map[string]bool),
+		tx:              make(map[string]bool),
+		tuning:          make(map[string]bool),
+		swr:             make(map[string]float64),
+		listeners:       hamdeck.NewListeners(),
+		subscribersLock: new(sync.Mutex),
+		subscribers:     make(map[string][]Subscriber),
+	}
+}
+
+func (c *Client) connect(client mqtt.Client) {
+	c.publishConnected(false)
+	c.client = client
+	if token := c.client.Connect(); token.WaitTimeout(mqttWaitTimeout) && token.Error() != nil {
+		logger.Error("cannot connect to MQTT broker", "error", token.Error())
+		c.station.PublishState(c.stateConnection, hamdeck.StateError, token.Error().Error())
+	}
+}
+
 type Client struct {
+	stateConnection string
+	station         hamdeck.StatePublisher
+
 	address   string
 	client    mqtt.Client
 	paths     []string
-	listeners []interface{}
+	listeners *hamdeck.Listeners
 
 	alive  map[string]bool
 	tx     map[string]bool
 	tuning map[string]bool
 	swr    map[string]float64
 
-	subscribers map[string][]Subscriber
+	subscribersLock *sync.Mutex
+	subscribers     map[string][]Subscriber
 }
 
 type Subscriber interface {
@@ -80,11 +98,16 @@ func (c *Client) Disconnect() {
 }
 
 func (c *Client) connected(mqtt.Client) {
-	log.Printf("connected to MQTT broker %s", c.address)
+	logger.Info("connected to MQTT broker", "address", c.address)
 	for _, path := range c.paths {
 		c.subscribePath(path)
 	}
-	hamdeck.NotifyEnablers(c.listeners, true)
+	c.publishConnected(true)
+	hamdeck.NotifyEnablers(c.listeners.All(), true)
+}
+
+func (c *Client) publishConnected(connected bool) {
+	c.station.PublishState(c.stateConnection, hamdeck.StateConnected, hamdeck.FormatBoolState(connected))
 }
 
 func (c *Client) subscribePath(path string) {
@@ -96,15 +119,21 @@ func (c *Client) subscribePath(path string) {
 }
 
 func (c *Client) connectionLost(_ mqtt.Client, err error) {
-	log.Printf("MQTT connection lost: %v", err)
-	hamdeck.NotifyEnablers(c.listeners, false)
+	logger.Warn("MQTT connection lost", "error", err)
+	c.station.PublishState(c.stateConnection, hamdeck.StateError, err.Error())
+	c.publishConnected(false)
+	hamdeck.NotifyEnablers(c.listeners.All(), false)
 }
 
 func (c *Client) messageReceived(_ mqtt.Client, msg mqtt.Message) {
 	topic := strings.TrimSpace(msg.Topic())
-	// log.Printf("received MQTT message from topic: %s", topic)
+	logger.Debug("received MQTT message", "topic", topic)
+
+	c.station.PublishState(c.stateConnection, topic, strings.TrimSpace(string(msg.Payload())))
 
+	c.subscribersLock.Lock()
 	topicSubscribers := c.subscribers[topic]
+	c.subscribersLock.Unlock()
 	for _, subscriber := range topicSubscribers {
 		subscriber.SetInput(topic, string(msg.Payload()))
 	}
@@ -122,7 +151,7 @@ func (c *Client) messageReceived(_ mqtt.Client, msg mqtt.Message) {
 		var data atu100Data
 		err := json.Unmarshal(msg.Payload(), &data)
 		if err != nil {
-			log.Printf("invalid atu100 payload from %s: %v", topic, err)
+			logger.Error("invalid atu100 payload", "topic", topic, "error", err)
 			return
 		}
 		c.SetTX(path, data.TX)
@@ -142,32 +171,82 @@ func splitTopic(topic string) (string, string, bool) {
 func (c *Client) Subscribe(s Subscriber, topics ...string) {
 	for _, topic := range topics {
 		topic = strings.TrimSpace(topic)
-		topicSubscribers, ok := c.subscribers[topic]
-		topicSubscribers = append(topicSubscribers, s)
-		c.subscribers[topic] = topicSubscribers
+		c.subscribeTopic(topic)
+		c.subscribersLock.Lock()
+		c.subscribers[topic] = append(c.subscribers[topic], s)
+		c.subscribersLock.Unlock()
+	}
+}
 
-		if !ok {
-			log.Printf("subscribing to %s", topic)
-			c.client.Subscribe(topic, 1, nil).WaitTimeout(mqttWaitTimeout)
+// Unsubscribe removes the given subscriber from all topics, e.g. when its button is closed. The topics stay
+// subscribed, their payload is still published as state value.
+func (c *Client) Unsubscribe(s Subscriber) {
+	c.subscribersLock.Lock()
+	defer c.subscribersLock.Unlock()
+	for topic, topicSubscribers := range c.subscribers {
+		remaining := make([]Subscriber, 0, len(topicSubscribers))
+		for _, subscriber := range topicSubscribers {
+			if subscriber != s {
+				remaining = append(remaining, subscriber)
+			}
 		}
+		c.subscribers[topic] = remaining
 	}
 }
 
-func (c *Client) Publish(topic string, payload string) {
-	c.client.Publish(topic, 0, false, payload)
+// Watch subscribes the given topic only to publish its payload as state value.
+func (c *Client) Watch(topic string) {
+	c.subscribeTopic(strings.TrimSpace(topic))
+}
+
+func (c *Client) subscribeTopic(topic string) {
+	c.subscribersLock.Lock()
+	_, ok := c.subscribers[topic]
+	if !ok {
+		c.subscribers[topic] = nil
+	}
+	c.subscribersLock.Unlock()
+	if ok {
+		return
+	}
+
+	logger.Debug("subscribing", "topic", topic)
+	c.client.Subscribe(topic, 1, nil).WaitTimeout(mqttWaitTimeout)
+}
+
+func (c *Client) Publish(topic string, payload string) error {
+	return c.publish(topic, payload)
+}
+
+func (c *Client) publish(topic string, payload string) error {
+	token := c.client.Publish(topic, 0, false, payload)
+	if token.WaitTimeout(mqttWaitTimeout) {
+		return token.Error()
+	}
+	return nil
 }
 
 func (c *Client) AddPath(path string) {
+	for _, p := range c.paths {
+		if p == path {
+			return
+		}
+	}
 	c.paths = append(c.paths, path)
 	c.subscribePath(path)
 }
 
-func (c *Client) Tune(path string) {
-	c.client.Publish(fmt.Sprintf("%s/cmd", path), 0, false, "1")
+func (c *Client) Tune(path string) error {
+	return c.publish(fmt.Sprintf("%s/cmd", path), "1")
 }
 
 func (c *Client) Notify(listener interface{}) {
-	c.listeners = append(c.listeners, listener)
+	c.listeners.Add(listener)
+}
+
+// Unnotify removes the given listener, e.g. when its button is closed.
+func (c *Client) Unnotify(listener interface{}) {
+	c.listeners.Remove(listener)
 }
 
 func (c *Client) SetAlive(path string, alive bool) {
@@ -183,7 +262,7 @@ type AliveListener interface {
 }
 
 func (c *Client) emitAlive(path string, alive bool) {
-	for _, l := range c.listeners {
+	for _, l := range c.listeners.All() {
 		if listener, ok := l.(AliveListener); ok {
 			listener.SetAlive(path, alive)
 		}
@@ -203,7 +282,7 @@ type TXListener interface {
 }
 
 func (c *Client) emitTX(path string, tx bool) {
-	for _, l := range c.listeners {
+	for _, l := range c.listeners.All() {
 		if listener, ok := l.(TXListener); ok {
 			listener.SetTX(path, tx)
 		}
@@ -223,7 +302,7 @@ type TuneListener interface {
 }
 
 func (c *Client) emitTune(path string, tuning bool) {
-	for _, l := range c.listeners {
+	for _, l := range c.listeners.All() {
 		if listener, ok := l.(TuneListener); ok {
 			listener.SetTune(path, tuning)
 		}
@@ -235,6 +314,7 @@ func (c *Client) SetSWR(path string, swr float64) {
 		return
 	}
 	c.swr[path] = swr
+	c.station.PublishState(c.stateConnection, path+"/"+SWRStateSuffix, fmt.Sprintf("%.2f", swr))
 	c.emitSWR(path, swr)
 }
 
@@ -243,7 +323,7 @@ type SWRListener interface {
 }
 
 func (c *Client) emitSWR(path string, swr float64) {
-	for _, l := range c.listeners {
+	for _, l := range c.listeners.All() {
 		if listener, ok := l.(SWRListener); ok {
 			listener.SetSWR(path, swr)
 		}
@@ -2,12 +2,14 @@ package mqtt
 
 import (
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
 )
 
+var logger = logging.For("mqtt")
+
 const (
 	ConfigAddress     = "address"
 	ConfigUsername    = "username"
@@ -19,26 +21,36 @@ const (
 	ConfigOnPayload   = "onPayload"
 	ConfigOffPayload  = "offPayload"
 	ConfigMode        = "mode"
+	ConfigTopic       = "topic"
+	ConfigPayload     = "payload"
 )
 
+// SWRStateSuffix is appended to the path of an ATU-100 to publish its SWR as state value.
+const SWRStateSuffix = "swr"
+
 const (
-	ConnectionType   = "mqtt"
-	TuneButtonType   = "mqtt.AT100Tune"
-	SwitchButtonType = "mqtt.Switch"
+	ConnectionType    = "mqtt"
+	TuneButtonType    = "mqtt.AT100Tune"
+	SwitchButtonType  = "mqtt.Switch"
+	PublishButtonType = "mqtt.Publish"
 )
 
-func NewButtonFactory(provider hamdeck.ConnectionConfigProvider, legacyAddress string, username string, password string) *Factory {
-	result := &Factory{}
-	result.connections = hamdeck.NewConnectionManager(ConnectionType, provider, result.createMQTTClient)
+func NewButtonFactory(station hamdeck.Station, legacyAddress string, username string, password string) *Factory {
+	result := &Factory{
+		station: station,
+	}
+	result.connections = hamdeck.NewConnectionManager(ConnectionType, station, result.createMQTTClient)
+	result.connections.SetCloser((*Client).Disconnect)
 
 	if legacyAddress != "" {
-		result.connections.SetLegacy(NewClient(legacyAddress, username, password))
+		result.connections.SetLegacy(NewClient(hamdeck.LegacyConnectionName, legacyAddress, username, password, station))
 	}
 
 	return result
 }
 
 type Factory struct {
+	station     hamdeck.Station
 	connections *hamdeck.ConnectionManager[*Client]
 }
 
@@ -50,7 +62,7 @@ func (f *Factory) createMQTTClient(name string, config hamdeck.ConnectionConfig)
 	username, _ := hamdeck.ToString(config[ConfigUsername])
 	password, _ := hamdeck.ToString(config[ConfigPassword])
 
-	client := NewClient(address, username, password)
+	client := NewClient(name, address, username, password, f.station)
 
 	return client, nil
 }
@@ -61,12 +73,32 @@ func (f *Factory) Close() {
 	})
 }
 
+// RequestState provides the payload of any MQTT topic as state value of the connection.
+// The SWR measured by an ATU-100 is provided as state <path>/swr.
+func (f *Factory) RequestState(connection string, name string) bool {
+	client, err := f.connections.GetByStateName(connection)
+	if err != nil {
+		return false
+	}
+	path, suffix, ok := splitTopic(name)
+	switch {
+	case name == hamdeck.StateConnected:
+	case ok && suffix == SWRStateSuffix:
+		client.AddPath(path)
+	default:
+		client.Watch(name)
+	}
+	return true
+}
+
 func (f *Factory) CreateButton(config map[string]interface{}) hamdeck.Button {
 	switch config[hamdeck.ConfigType] {
 	case TuneButtonType:
 		return f.createTuneButton(config)
 	case SwitchButtonType:
 		return f.createSwitchButton(config)
+	case PublishButtonType:
+		return f.createPublishButton(config)
 	default:
 		return nil
 	}
@@ -77,14 +109,14 @@ func (f *Factory) createTuneButton(config map[string]interface{}) hamdeck.Button
 	path, havePath := hamdeck.ToString(config[ConfigPath])
 
 	if !(haveLabel && havePath) {
-		log.Print("A mqtt.ATU100Tune button must have label and path fields.")
+		logger.Error("A mqtt.ATU100Tune button must have label and path fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	mqttClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create mqtt.ATU100Tune button: %v", err)
+		logger.Error("Cannot create mqtt.ATU100Tune button", "error", err)
 		return nil
 	}
 
@@ -100,16 +132,36 @@ func (f *Factory) createSwitchButton(config map[string]interface{}) hamdeck.Butt
 	mode, haveMode := hamdeck.ToString(config[ConfigMode])
 
 	if !(haveLabel && haveInputTopic && haveOutputTopic && haveOnPayload && haveOffPayload && haveMode) {
-		log.Print("A mqtt.Switch button must have label, inputTopic, outputTopic, onPayload, offPayload, and mode fields.")
+		logger.Error("A mqtt.Switch button must have label, inputTopic, outputTopic, onPayload, offPayload, and mode fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	mqttClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create mqtt.Switch button: %v", err)
+		logger.Error("Cannot create mqtt.Switch button", "error", err)
 		return nil
 	}
 
 	return NewSwitchButton(mqttClient, label, inputTopic, outputTopic, onPayload, offPayload, SwitchMode(strings.TrimSpace(strings.ToUpper(mode))))
 }
+
+func (f *Factory) createPublishButton(config map[string]interface{}) hamdeck.Button {
+	label, _ := hamdeck.ToString(config[ConfigLabel])
+	topic, haveTopic := hamdeck.ToString(config[ConfigTopic])
+	payload, havePayload := hamdeck.ToString(config[ConfigPayload])
+
+	if !(haveTopic && havePayload) {
+		logger.Error("A mqtt.Publish button must have topic and payload fields")
+		return nil
+	}
+
+	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
+	mqttClient, err := f.connections.Get(connection)
+	if err != nil {
+		logger.Error("Cannot create mqtt.Publish button", "error", err)
+		return nil
+	}
+
+	return NewPublishButton(mqttClient, label, topic, payload)
+}
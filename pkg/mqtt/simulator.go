@@ -0,0 +1,275 @@
+package mqtt
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+const simulatedTuneDuration = 2 * time.Second
+
+// NewSimulatedButtonFactory creates a factory for MQTT buttons that are connected to simulated brokers instead of
+// real ones. Every message that the buttons publish is logged.
+func NewSimulatedButtonFactory(station hamdeck.Station, legacyAddress string) *Factory {
+	result := &Factory{
+		station: station,
+	}
+	result.connections = hamdeck.NewConnectionManager(ConnectionType, station, result.createSimulatedClient)
+	result.connections.SetCloser((*Client).Disconnect)
+
+	if legacyAddress != "" {
+		result.connections.SetLegacy(NewSimulatedClient(hamdeck.LegacyConnectionName, station))
+	}
+
+	return result
+}
+
+func (f *Factory) createSimulatedClient(name string, _ hamdeck.ConnectionConfig) (*Client, error) {
+	return NewSimulatedClient(name, f.station), nil
+}
+
+// NewSimulatedClient creates a client that is connected to an in-process broker. The broker delivers
+// published messages to the subscribers of their topic and simulates ATU-100 devices.
+func NewSimulatedClient(name string, station hamdeck.StatePublisher) *Client {
+	result := newClient(name, "simulator", station)
+	result.connect(NewSimulator(name, result.messageReceived, result.connected))
+	return result
+}
+
+// Simulator is an in-process MQTT broker with exactly one client. It implements the mqtt.Client interface.
+type Simulator struct {
+	name      string
+	onMessage mqtt.MessageHandler
+	onConnect mqtt.OnConnectHandler
+	messages  chan *simulatedMessage
+	done      chan struct{}
+
+	lock          *sync.Mutex
+	connected     bool
+	closed        bool
+	subscriptions []string
+	retained      map[string][]byte
+}
+
+func NewSimulator(name string, onMessage mqtt.MessageHandler, onConnect mqtt.OnConnectHandler) *Simulator {
+	result := &Simulator{
+		name:      name,
+		onMessage: onMessage,
+		onConnect: onConnect,
+		messages:  make(chan *simulatedMessage, 100),
+		done:      make(chan struct{}),
+		lock:      new(sync.Mutex),
+		retained:  make(map[string][]byte),
+	}
+	go result.deliverMessages()
+	return result
+}
+
+func (s *Simulator) deliverMessages() {
+	for {
+		select {
+		case message := <-s.messages:
+			s.onMessage(s, message)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// deliver queues the given message for the client, unless the simulator is closed.
+func (s *Simulator) deliver(message *simulatedMessage) {
+	select {
+	case s.messages <- message:
+	case <-s.done:
+	}
+}
+
+func (s *Simulator) IsConnected() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.connected
+}
+
+func (s *Simulator) IsConnectionOpen() bool {
+	return s.IsConnected()
+}
+
+func (s *Simulator) Connect() mqtt.Token {
+	s.lock.Lock()
+	s.connected = true
+	s.lock.Unlock()
+
+	logger.Info("simulated mqtt connected", "connection", s.name)
+	if s.onConnect != nil {
+		s.onConnect(s)
+	}
+	return simulatedToken{}
+}
+
+// Disconnect closes the simulator, no more messages are delivered afterwards.
+func (s *Simulator) Disconnect(uint) {
+	s.lock.Lock()
+	s.connected = false
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	s.lock.Unlock()
+	logger.Info("simulated mqtt disconnected", "connection", s.name)
+}
+
+func (s *Simulator) Publish(topic string, _ byte, _ bool, payload any) mqtt.Token {
+	var data []byte
+	switch p := payload.(type) {
+	case []byte:
+		data = p
+	case string:
+		data = []byte(p)
+	default:
+		data = []byte(fmt.Sprint(p))
+	}
+	logger.Info("simulated mqtt publish", "connection", s.name, "topic", topic, "payload", string(data))
+
+	s.publish(topic, data)
+
+	path, suffix, ok := splitTopic(topic)
+	if ok && suffix == "cmd" && string(data) == "1" {
+		go s.simulateTune(path)
+	}
+	return simulatedToken{}
+}
+
+func (s *Simulator) publish(topic string, data []byte) {
+	s.lock.Lock()
+	s.retained[topic] = data
+	subscribed := false
+	for _, filter := range s.subscriptions {
+		if topicMatches(filter, topic) {
+			subscribed = true
+			break
+		}
+	}
+	s.lock.Unlock()
+
+	if subscribed {
+		s.deliver(&simulatedMessage{topic: topic, payload: data})
+	}
+}
+
+// simulateTune lets the ATU-100 with the given path tune for a while and end with a good SWR.
+func (s *Simulator) simulateTune(path string) {
+	s.publish(path+"/data", []byte(`{"txing":true,"tuning":true,"swr":2.8}`))
+	time.Sleep(simulatedTuneDuration)
+	s.publish(path+"/data", []byte(`{"txing":false,"tuning":false,"swr":1.1}`))
+}
+
+func (s *Simulator) Subscribe(topic string, _ byte, _ mqtt.MessageHandler) mqtt.Token {
+	logger.Debug("simulated mqtt subscribe", "connection", s.name, "topic", topic)
+
+	s.lock.Lock()
+	s.subscriptions = append(s.subscriptions, topic)
+	// ATU-100 devices are always alive and tuned
+	path, suffix, ok := splitTopic(topic)
+	if ok && (suffix == "alive" || suffix == "data") {
+		s.retainDefault(path+"/alive", "true")
+		s.retainDefault(path+"/data", `{"txing":false,"tuning":false,"swr":1.1}`)
+	}
+	var messages []*simulatedMessage
+	for retainedTopic, payload := range s.retained {
+		if topicMatches(topic, retainedTopic) {
+			messages = append(messages, &simulatedMessage{topic: retainedTopic, payload: payload})
+		}
+	}
+	s.lock.Unlock()
+
+	for _, message := range messages {
+		s.deliver(message)
+	}
+	return simulatedToken{}
+}
+
+func (s *Simulator) retainDefault(topic string, payload string) {
+	if _, ok := s.retained[topic]; ok {
+		return
+	}
+	s.retained[topic] = []byte(payload)
+}
+
+func (s *Simulator) SubscribeMultiple(filters map[string]byte, callback mqtt.MessageHandler) mqtt.Token {
+	for topic, qos := range filters {
+		s.Subscribe(topic, qos, callback)
+	}
+	return simulatedToken{}
+}
+
+func (s *Simulator) Unsubscribe(topics ...string) mqtt.Token {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, topic := range topics {
+		for i, filter := range s.subscriptions {
+			if filter == topic {
+				s.subscriptions = append(s.subscriptions[:i], s.subscriptions[i+1:]...)
+				break
+			}
+		}
+	}
+	return simulatedToken{}
+}
+
+func (s *Simulator) AddRoute(string, mqtt.MessageHandler) {
+	// all messages are delivered to the default handler
+}
+
+func (s *Simulator) OptionsReader() mqtt.ClientOptionsReader {
+	return mqtt.ClientOptionsReader{}
+}
+
+// topicMatches checks if the given topic matches the given topic filter with + and # wildcards.
+func topicMatches(filter string, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+type simulatedMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *simulatedMessage) Duplicate() bool   { return false }
+func (m *simulatedMessage) Qos() byte         { return 0 }
+func (m *simulatedMessage) Retained() bool    { return false }
+func (m *simulatedMessage) Topic() string     { return m.topic }
+func (m *simulatedMessage) MessageID() uint16 { return 0 }
+func (m *simulatedMessage) Payload() []byte   { return m.payload }
+func (m *simulatedMessage) Ack()              {}
+
+// simulatedToken is the token of an operation that completed immediately.
+type simulatedToken struct{}
+
+var simulatedTokenDone = func() chan struct{} {
+	result := make(chan struct{})
+	close(result)
+	return result
+}()
+
+func (t simulatedToken) Wait() bool                     { return true }
+func (t simulatedToken) WaitTimeout(time.Duration) bool { return true }
+func (t simulatedToken) Done() <-chan struct{}          { return simulatedTokenDone }
+func (t simulatedToken) Error() error                   { return nil }
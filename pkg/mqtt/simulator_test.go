@@ -0,0 +1,76 @@
+package mqtt
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatePublisher struct {
+	lock   *sync.Mutex
+	values map[string]string
+}
+
+func (p *testStatePublisher) PublishState(_ string, name string, value string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.values[name] = value
+}
+
+func (p *testStatePublisher) Get(name string) string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.values[name]
+}
+
+func TestSimulator(t *testing.T) {
+	publisher := &testStatePublisher{lock: new(sync.Mutex), values: make(map[string]string)}
+	client := NewSimulatedClient("test", publisher)
+	defer client.Disconnect()
+	assert.True(t, client.Connected())
+	assert.Equal(t, "true", publisher.Get("connected"))
+
+	client.Watch("shack/+/state")
+	client.Publish("shack/light/state", "on")
+	assert.Eventually(t, func() bool {
+		return publisher.Get("shack/light/state") == "on"
+	}, time.Second, 10*time.Millisecond)
+
+	client.AddPath("atu")
+	assert.Eventually(t, func() bool {
+		return publisher.Get("atu/alive") == "true" && publisher.Get("atu/swr") == "1.10"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSimulator_Disconnect(t *testing.T) {
+	simulator := NewSimulator("test", func(mqtt.Client, mqtt.Message) {}, nil)
+	simulator.Connect()
+	simulator.Subscribe("a/b", 0, nil)
+
+	simulator.Disconnect(0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			simulator.Publish("a/b", 0, false, "x")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "publishing to a disconnected simulator blocks")
+	}
+	assert.False(t, simulator.IsConnected())
+}
+
+func TestTopicMatches(t *testing.T) {
+	assert.True(t, topicMatches("a/b", "a/b"))
+	assert.True(t, topicMatches("a/+", "a/b"))
+	assert.True(t, topicMatches("a/#", "a/b/c"))
+	assert.False(t, topicMatches("a/+", "a/b/c"))
+	assert.False(t, topicMatches("a/b/c", "a/b"))
+}
@@ -0,0 +1,146 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+/*
+	Button
+*/
+
+// Button is a button that is implemented by a plugin. It forwards the key events to the plugin
+// and shows what the plugin tells it to show.
+func NewButton(client *Client, id int, label string) *Button {
+	return &Button{
+		client:     client,
+		id:         id,
+		lock:       new(sync.Mutex),
+		enabled:    true,
+		label:      label,
+		foreground: hamdeck.White,
+		background: hamdeck.Black,
+	}
+}
+
+type Button struct {
+	hamdeck.BaseButton
+	client     *Client
+	id         int
+	lock       *sync.Mutex
+	image      image.Image
+	enabled    bool
+	label      string
+	foreground color.Color
+	background color.Color
+	icon       image.Image
+}
+
+func (b *Button) Enable(enabled bool) {
+	b.lock.Lock()
+	changed := (b.enabled != enabled)
+	b.enabled = enabled
+	b.lock.Unlock()
+
+	if changed {
+		b.Invalidate(true)
+	}
+}
+
+func (b *Button) Update(params updateParams) error {
+	b.lock.Lock()
+	defer func() {
+		b.lock.Unlock()
+		b.Invalidate(true)
+	}()
+
+	if params.Label != nil {
+		b.label = *params.Label
+	}
+	if params.Foreground != nil {
+		foreground, ok := hamdeck.ToColor(*params.Foreground)
+		if !ok {
+			return fmt.Errorf("invalid foreground color %s", *params.Foreground)
+		}
+		b.foreground = foreground
+	}
+	if params.Background != nil {
+		background, ok := hamdeck.ToColor(*params.Background)
+		if !ok {
+			return fmt.Errorf("invalid background color %s", *params.Background)
+		}
+		b.background = background
+	}
+	if params.Image != nil {
+		if *params.Image == "" {
+			b.icon = nil
+			return nil
+		}
+		data, err := base64.StdEncoding.DecodeString(*params.Image)
+		if err != nil {
+			return fmt.Errorf("invalid image: %w", err)
+		}
+		icon, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("invalid image: %w", err)
+		}
+		b.icon = icon
+	}
+	return nil
+}
+
+func (b *Button) Image(gc hamdeck.GraphicContext, redrawImages bool) image.Image {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.image == nil || redrawImages {
+		b.redrawImage(gc)
+	}
+	return b.image
+}
+
+func (b *Button) redrawImage(gc hamdeck.GraphicContext) {
+	if b.enabled {
+		gc.SetForeground(b.foreground)
+	} else {
+		gc.SetForeground(hamdeck.DisabledGray)
+	}
+	gc.SetBackground(b.background)
+
+	switch {
+	case b.icon != nil && b.label == "":
+		b.image = gc.DrawIconButton(b.icon)
+	case b.icon != nil:
+		b.image = gc.DrawIconLabelButton(b.icon, b.label)
+	default:
+		b.image = gc.DrawSingleLineTextButton(b.label)
+	}
+}
+
+func (b *Button) Pressed() {
+	b.client.notify(MethodPressed, buttonParams{Button: b.id})
+}
+
+func (b *Button) Released() {
+	b.client.notify(MethodReleased, buttonParams{Button: b.id})
+}
+
+func (b *Button) Attached(ctx hamdeck.ButtonContext) {
+	b.BaseButton.Attached(ctx)
+	b.client.notify(MethodAttached, buttonParams{Button: b.id})
+}
+
+func (b *Button) Detached() {
+	b.client.notify(MethodDetached, buttonParams{Button: b.id})
+	b.BaseButton.Detached()
+}
+
+func (b *Button) Close() {
+	b.client.destroyButton(b.id)
+}
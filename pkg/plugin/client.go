@@ -0,0 +1,360 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+const requestTimeout = 5 * time.Second
+
+// outgoingQueueSize is the number of messages that may wait until the plugin reads its stdin.
+const outgoingQueueSize = 100
+
+var errClosed = errors.New("plugin closed")
+
+// NewClient launches the given plugin executable and initializes it.
+func NewClient(name string, command string, args []string, env map[string]string, station hamdeck.StatePublisher) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Client{
+		name:        name,
+		station:     station,
+		cmd:         cmd,
+		stdin:       stdin,
+		outgoing:    make(chan []byte, outgoingQueueSize),
+		lock:        new(sync.Mutex),
+		pending:     make(map[int64]chan *message),
+		buttons:     make(map[int]*Button),
+		buttonTypes: make(map[string]bool),
+		done:        make(chan struct{}),
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("cannot start plugin %s: %w", name, err)
+	}
+	logger.Info("plugin started", "plugin", name)
+
+	go result.writeLoop()
+	stderrDone := make(chan struct{})
+	go result.logStderr(stderr, stderrDone)
+	go result.readLoop(stdout, stderrDone)
+
+	var initResult initializeResult
+	err = result.call(MethodInitialize, initializeParams{Version: ProtocolVersion}, &initResult)
+	if err != nil {
+		result.Close()
+		return nil, fmt.Errorf("cannot initialize plugin %s: %w", name, err)
+	}
+	for _, buttonType := range initResult.ButtonTypes {
+		result.buttonTypes[buttonType] = true
+	}
+	station.PublishState(name, hamdeck.StateConnected, hamdeck.FormatBoolState(true))
+
+	return result, nil
+}
+
+type Client struct {
+	name     string
+	station  hamdeck.StatePublisher
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	outgoing chan []byte
+
+	lock         *sync.Mutex
+	nextID       int64
+	pending      map[int64]chan *message
+	buttons      map[int]*Button
+	nextButtonID int
+	buttonTypes  map[string]bool
+	closed       bool
+	done         chan struct{}
+}
+
+func (c *Client) Supports(buttonType string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.buttonTypes[buttonType]
+}
+
+func (c *Client) CreateButton(buttonType string, label string, config map[string]any) (*Button, error) {
+	c.lock.Lock()
+	id := c.nextButtonID
+	c.nextButtonID++
+	button := NewButton(c, id, label)
+	c.buttons[id] = button
+	c.lock.Unlock()
+
+	err := c.call(MethodCreate, createParams{Button: id, Type: buttonType, Config: config}, nil)
+	if err != nil {
+		c.lock.Lock()
+		delete(c.buttons, id)
+		c.lock.Unlock()
+		return nil, err
+	}
+	return button, nil
+}
+
+// destroyButton forgets the button with the given ID and tells the plugin that the button is gone.
+func (c *Client) destroyButton(id int) {
+	c.lock.Lock()
+	_, ok := c.buttons[id]
+	delete(c.buttons, id)
+	c.lock.Unlock()
+
+	if ok {
+		c.notify(MethodDestroy, buttonParams{Button: id})
+	}
+}
+
+func (c *Client) Close() {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return
+	}
+	c.closeOutgoing()
+	c.lock.Unlock()
+
+	select {
+	case <-c.done:
+	case <-time.After(requestTimeout):
+		logger.Warn("plugin did not stop, killing it", "plugin", c.name)
+		c.cmd.Process.Kill()
+		<-c.done
+	}
+}
+
+func (c *Client) call(method string, params any, result any) error {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return errClosed
+	}
+	id := c.nextID
+	c.nextID++
+	response := make(chan *message, 1)
+	c.pending[id] = response
+	c.lock.Unlock()
+
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+	}()
+
+	err := c.send(&id, method, params)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case msg, ok := <-response:
+		if !ok {
+			return errClosed
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	case <-time.After(requestTimeout):
+		return fmt.Errorf("%s request to plugin %s timed out", method, c.name)
+	}
+}
+
+func (c *Client) notify(method string, params any) {
+	err := c.send(nil, method, params)
+	if err != nil && err != errClosed {
+		logger.Error("cannot send to plugin", "plugin", c.name, "method", method, "error", err)
+	}
+}
+
+func (c *Client) send(id *int64, method string, params any) error {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&message{JSONRPC: "2.0", ID: id, Method: method, Params: rawParams})
+}
+
+// write puts the given message into the queue of outgoing messages. It does not wait until the plugin reads the
+// message, if the queue is full, the message is dropped.
+func (c *Client) write(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return errClosed
+	}
+	select {
+	case c.outgoing <- data:
+		return nil
+	default:
+		return fmt.Errorf("plugin %s does not read its input", c.name)
+	}
+}
+
+// closeOutgoing closes the queue of outgoing messages, the plugin's stdin is closed when all messages are written.
+// The caller must hold the lock.
+func (c *Client) closeOutgoing() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.outgoing)
+}
+
+func (c *Client) writeLoop() {
+	defer c.stdin.Close()
+	var err error
+	for data := range c.outgoing {
+		if err != nil {
+			continue
+		}
+		_, err = c.stdin.Write(data)
+		if err != nil {
+			logger.Error("cannot write to plugin", "plugin", c.name, "error", err)
+		}
+	}
+}
+
+func (c *Client) readLoop(stdout io.Reader, stderrDone <-chan struct{}) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var msg message
+		err := json.Unmarshal(scanner.Bytes(), &msg)
+		if err != nil {
+			logger.Error("invalid message from plugin", "plugin", c.name, "error", err)
+			continue
+		}
+		c.handle(&msg)
+	}
+
+	<-stderrDone
+	err := c.cmd.Wait()
+	if err != nil {
+		logger.Warn("plugin exited", "plugin", c.name, "error", err)
+		c.station.PublishState(c.name, hamdeck.StateError, err.Error())
+	} else {
+		logger.Info("plugin exited", "plugin", c.name)
+	}
+	c.station.PublishState(c.name, hamdeck.StateConnected, hamdeck.FormatBoolState(false))
+
+	c.lock.Lock()
+	c.closeOutgoing()
+	for id, response := range c.pending {
+		close(response)
+		delete(c.pending, id)
+	}
+	buttons := make([]*Button, 0, len(c.buttons))
+	for _, button := range c.buttons {
+		buttons = append(buttons, button)
+	}
+	c.lock.Unlock()
+
+	for _, button := range buttons {
+		button.Enable(false)
+	}
+	close(c.done)
+}
+
+func (c *Client) logStderr(stderr io.Reader, done chan<- struct{}) {
+	defer close(done)
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		logger.Info("plugin output", "plugin", c.name, "line", scanner.Text())
+	}
+}
+
+func (c *Client) handle(msg *message) {
+	if msg.Method == "" {
+		if msg.ID == nil {
+			return
+		}
+		c.lock.Lock()
+		response, ok := c.pending[*msg.ID]
+		c.lock.Unlock()
+		if ok {
+			response <- msg
+		}
+		return
+	}
+
+	var err *rpcError
+	switch msg.Method {
+	case MethodUpdate:
+		err = c.update(msg.Params)
+	default:
+		err = &rpcError{Code: errorMethodNotFound, Message: fmt.Sprintf("unknown method %s", msg.Method)}
+	}
+
+	if msg.ID == nil {
+		if err != nil {
+			logger.Error("plugin error", "plugin", c.name, "error", err)
+		}
+		return
+	}
+	response := &message{JSONRPC: "2.0", ID: msg.ID, Error: err}
+	if err == nil {
+		response.Result = json.RawMessage("null")
+	}
+	writeErr := c.write(response)
+	if writeErr != nil {
+		logger.Error("cannot respond to plugin", "plugin", c.name, "error", writeErr)
+	}
+}
+
+func (c *Client) update(rawParams json.RawMessage) *rpcError {
+	var params updateParams
+	err := json.Unmarshal(rawParams, &params)
+	if err != nil {
+		return &rpcError{Code: errorInvalidParams, Message: err.Error()}
+	}
+
+	c.lock.Lock()
+	button, ok := c.buttons[params.Button]
+	c.lock.Unlock()
+	if !ok {
+		return &rpcError{Code: errorInvalidParams, Message: fmt.Sprintf("unknown button %d", params.Button)}
+	}
+
+	err = button.Update(params)
+	if err != nil {
+		return &rpcError{Code: errorInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+type testStation struct {
+	*hamdeck.State
+}
+
+func (s testStation) PublishState(connection string, name string, value string) {
+	s.Publish(connection, name, value)
+}
+
+func TestClient(t *testing.T) {
+	station := testStation{hamdeck.NewState()}
+	client, err := NewClient("test", os.Args[0], []string{"-test.run=TestHelperPlugin"}, map[string]string{"HAMDECK_TEST_PLUGIN": "1"}, station)
+	require.NoError(t, err)
+	defer func() {
+		client.Close()
+		connected, _ := station.Get("test", hamdeck.StateConnected)
+		assert.Equal(t, "false", connected)
+	}()
+
+	connected, _ := station.Get("test", hamdeck.StateConnected)
+	assert.Equal(t, "true", connected)
+
+	assert.True(t, client.Supports("Echo"))
+	assert.False(t, client.Supports("Other"))
+
+	button, err := client.CreateButton("Echo", "Echo", map[string]any{"type": "test.Echo"})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return button.currentLabel() == "created" }, time.Second, 10*time.Millisecond)
+
+	button.Pressed()
+	assert.Eventually(t, func() bool { return button.currentLabel() == "pressed 0" }, time.Second, 10*time.Millisecond)
+
+	_, err = client.CreateButton("Other", "Other", map[string]any{"type": "test.Other"})
+	assert.Error(t, err)
+
+	other, err := client.CreateButton("Echo", "Echo", map[string]any{"type": "test.Echo"})
+	require.NoError(t, err)
+	other.Close()
+	assert.Eventually(t, func() bool { return button.currentLabel() == "destroyed 2" }, time.Second, 10*time.Millisecond)
+	client.lock.Lock()
+	assert.NotContains(t, client.buttons, other.id)
+	client.lock.Unlock()
+}
+
+func TestClient_WriteDoesNotBlock(t *testing.T) {
+	// no writeLoop is running, the plugin does not read anything
+	client := &Client{
+		name:     "test",
+		outgoing: make(chan []byte, outgoingQueueSize),
+		lock:     new(sync.Mutex),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < outgoingQueueSize; i++ {
+			assert.NoError(t, client.send(nil, MethodPressed, buttonParams{Button: 0}))
+		}
+		client.notify(MethodPressed, buttonParams{Button: 0})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "writing to a plugin that does not read blocks")
+	}
+
+	err := client.send(nil, MethodPressed, buttonParams{Button: 0})
+	assert.Error(t, err, "the queue is full")
+}
+
+func (b *Button) currentLabel() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.label
+}
+
+// TestHelperPlugin is not a real test, it is the plugin process that is launched by TestClient.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("HAMDECK_TEST_PLUGIN") != "1" {
+		return
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	respond := func(id *int64, result any, err *rpcError) {
+		rawResult, _ := json.Marshal(result)
+		out.Encode(message{JSONRPC: "2.0", ID: id, Result: rawResult, Error: err})
+	}
+	update := func(button int, label string) {
+		rawParams, _ := json.Marshal(updateParams{Button: button, Label: &label})
+		out.Encode(message{JSONRPC: "2.0", Method: MethodUpdate, Params: rawParams})
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid message: %v\n", err)
+			continue
+		}
+		switch msg.Method {
+		case MethodInitialize:
+			respond(msg.ID, initializeResult{ButtonTypes: []string{"Echo"}}, nil)
+		case MethodCreate:
+			var params createParams
+			json.Unmarshal(msg.Params, &params)
+			if params.Type != "Echo" {
+				respond(msg.ID, nil, &rpcError{Code: errorInvalidParams, Message: "unknown type"})
+				continue
+			}
+			respond(msg.ID, nil, nil)
+			update(params.Button, "created")
+		case MethodPressed:
+			var params buttonParams
+			json.Unmarshal(msg.Params, &params)
+			update(params.Button, fmt.Sprintf("pressed %d", params.Button))
+		case MethodDestroy:
+			var params buttonParams
+			json.Unmarshal(msg.Params, &params)
+			update(0, fmt.Sprintf("destroyed %d", params.Button))
+		}
+	}
+	os.Exit(0)
+}
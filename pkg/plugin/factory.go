@@ -0,0 +1,102 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
+)
+
+var logger = logging.For("plugin")
+
+const (
+	ConfigCommand = "command"
+	ConfigArgs    = "args"
+	ConfigEnv     = "env"
+	ConfigLabel   = "label"
+)
+
+const (
+	ConnectionType = "plugin"
+)
+
+// NewButtonFactory creates a factory for the buttons of plugin connections.
+// The type of a plugin button is the name of the connection and the button type declared by the plugin,
+// separated by a dot, e.g. weather.Temperature.
+func NewButtonFactory(station hamdeck.Station) *Factory {
+	result := &Factory{
+		station: station,
+	}
+	result.connections = hamdeck.NewConnectionManager(ConnectionType, station, result.createPluginClient)
+	result.connections.SetCloser((*Client).Close)
+	return result
+}
+
+type Factory struct {
+	station     hamdeck.Station
+	connections *hamdeck.ConnectionManager[*Client]
+}
+
+func (f *Factory) createPluginClient(name string, config hamdeck.ConnectionConfig) (*Client, error) {
+	command, ok := hamdeck.ToString(config[ConfigCommand])
+	if !ok {
+		return nil, fmt.Errorf("no command defined for plugin connection %s", name)
+	}
+	args, _ := hamdeck.ToStringArray(config[ConfigArgs])
+	env := make(map[string]string)
+	rawEnv, _ := config[ConfigEnv].(map[string]any)
+	for key, rawValue := range rawEnv {
+		value, ok := hamdeck.ToString(rawValue)
+		if !ok {
+			return nil, fmt.Errorf("the value of the environment variable %s of plugin connection %s must be a string", key, name)
+		}
+		env[key] = value
+	}
+
+	client, err := NewClient(name, command, args, env, f.station)
+	if err != nil {
+		f.station.PublishState(name, hamdeck.StateConnected, hamdeck.FormatBoolState(false))
+		f.station.PublishState(name, hamdeck.StateError, err.Error())
+		return nil, err
+	}
+	return client, nil
+}
+
+func (f *Factory) Close() {
+	f.connections.ForEach(func(client *Client) {
+		client.Close()
+	})
+}
+
+func (f *Factory) CreateButton(config map[string]interface{}) hamdeck.Button {
+	fullType, ok := hamdeck.ToString(config[hamdeck.ConfigType])
+	if !ok {
+		return nil
+	}
+	connection, buttonType, ok := strings.Cut(fullType, ".")
+	if !ok {
+		return nil
+	}
+	if _, ok := f.station.GetConnection(connection, ConnectionType); !ok {
+		return nil
+	}
+
+	client, err := f.connections.Get(connection)
+	if err != nil {
+		logger.Error("Cannot create button", "type", fullType, "error", err)
+		return nil
+	}
+	if !client.Supports(buttonType) {
+		logger.Error("Plugin does not provide buttons of this type", "plugin", connection, "type", buttonType)
+		return nil
+	}
+
+	label, _ := hamdeck.ToString(config[ConfigLabel])
+	button, err := client.CreateButton(buttonType, label, config)
+	if err != nil {
+		logger.Error("Cannot create button", "type", fullType, "error", err)
+		return nil
+	}
+	return button
+}
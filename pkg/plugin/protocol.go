@@ -0,0 +1,74 @@
+package plugin
+
+import "encoding/json"
+
+// ProtocolVersion is sent to the plugin with the initialize request.
+const ProtocolVersion = 1
+
+// Methods that hamdeck calls on the plugin.
+const (
+	MethodInitialize = "initialize"
+	MethodCreate     = "create"
+	MethodPressed    = "pressed"
+	MethodReleased   = "released"
+	MethodAttached   = "attached"
+	MethodDetached   = "detached"
+	MethodDestroy    = "destroy"
+)
+
+// Methods that the plugin calls on hamdeck.
+const (
+	MethodUpdate = "update"
+)
+
+const (
+	errorMethodNotFound = -32601
+	errorInvalidParams  = -32602
+)
+
+// message is a JSON-RPC 2.0 request, notification, or response. Messages are separated by newlines.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+type initializeParams struct {
+	Version int `json:"version"`
+}
+
+type initializeResult struct {
+	ButtonTypes []string `json:"buttonTypes"`
+}
+
+type createParams struct {
+	Button int            `json:"button"`
+	Type   string         `json:"type"`
+	Config map[string]any `json:"config"`
+}
+
+type buttonParams struct {
+	Button int `json:"button"`
+}
+
+// updateParams changes the appearance of a button. Only the given fields are changed.
+// Colors are names or #rrggbb, the image is a base64 encoded PNG, an empty string removes the image.
+type updateParams struct {
+	Button     int     `json:"button"`
+	Label      *string `json:"label,omitempty"`
+	Foreground *string `json:"foreground,omitempty"`
+	Background *string `json:"background,omitempty"`
+	Image      *string `json:"image,omitempty"`
+}
@@ -2,12 +2,11 @@ package pulse
 
 import (
 	"image"
-	"log"
 
 	"github.com/ftl/hamdeck/pkg/hamdeck"
 )
 
-func NewToggleMuteButton(client *PulseClient, sinkID, sourceID, sinkInputName, sourceOutputName string, label string) *ToggleMuteButton {
+func NewToggleMuteButton(client Mixer, sinkID, sourceID, sinkInputName, sourceOutputName string, label string) *ToggleMuteButton {
 	result := &ToggleMuteButton{
 		client:           client,
 		sinkID:           sinkID,
@@ -26,7 +25,7 @@ func NewToggleMuteButton(client *PulseClient, sinkID, sourceID, sinkInputName, s
 
 type ToggleMuteButton struct {
 	hamdeck.BaseButton
-	client           *PulseClient
+	client           Mixer
 	sinkID           string
 	sourceID         string
 	sinkInputName    string
@@ -67,7 +66,7 @@ func (b *ToggleMuteButton) updateSelection() {
 		muted, err = b.client.IsSourceOutputMuted(b.sourceOutputName)
 	}
 	if err != nil {
-		log.Print(err)
+		logger.Error("cannot read the mute state", "error", err)
 		return
 	}
 
@@ -107,21 +106,33 @@ func (b *ToggleMuteButton) Pressed() {
 		return
 	}
 
+	var command string
 	var err error
 	if b.sinkID != "" {
+		command = "toggle_mute sink " + b.sinkID
 		_, err = b.client.ToggleMuteSink(b.sinkID)
 	} else if b.sourceID != "" {
+		command = "toggle_mute source " + b.sourceID
 		_, err = b.client.ToggleMuteSource(b.sourceID)
 	} else if b.sinkInputName != "" {
+		command = "toggle_mute sink_input " + b.sinkInputName
 		_, err = b.client.ToggleMuteSinkInput(b.sinkInputName)
 	} else if b.sourceOutputName != "" {
+		command = "toggle_mute source_output " + b.sourceOutputName
 		_, err = b.client.ToggleMuteSourceOutput(b.sourceOutputName)
+	} else {
+		return
 	}
+	b.AuditCommand(command, err)
 	if err != nil {
-		log.Printf("cannot toggle mute state: %v", err)
+		logger.Error("cannot toggle mute state", "error", err)
 	}
 }
 
 func (b *ToggleMuteButton) Released() {
 	// ignore
 }
+
+func (b *ToggleMuteButton) Close() {
+	b.client.Unlisten(b)
+}
@@ -2,7 +2,6 @@ package pulse
 
 import (
 	"fmt"
-	"log"
 	"net"
 	"os"
 	"path"
@@ -57,6 +56,23 @@ func (f MuteListenerFunc) SetMute(id string, mute bool) {
 	f(id, mute)
 }
 
+// SinksListener is notified when sinks are added or removed.
+type SinksListener interface {
+	SinksChanged()
+}
+
+type SinksListenerFunc func()
+
+func (f SinksListenerFunc) SinksChanged() {
+	f()
+}
+
+// Sink is an audio output device.
+type Sink struct {
+	Name        string
+	Description string
+}
+
 func NewClient() *PulseClient {
 	result := &PulseClient{
 		props: proto.PropList{
@@ -70,6 +86,7 @@ func NewClient() *PulseClient {
 		subscribeEvents: make(chan *proto.SubscribeEvent, 100),
 		retryInterval:   5 * time.Second,
 		done:            make(chan struct{}),
+		listeners:       hamdeck.NewListeners(),
 	}
 
 	go result.handleSubscribeEvents()
@@ -88,7 +105,7 @@ type PulseClient struct {
 	onPulseConnectionClosed func(interface{})
 	done                    chan struct{}
 
-	listeners []interface{}
+	listeners *hamdeck.Listeners
 }
 
 func (c *PulseClient) KeepOpen() {
@@ -101,20 +118,22 @@ func (c *PulseClient) KeepOpen() {
 			if err == nil {
 				select {
 				case <-disconnected:
-					log.Print("Connection lost to pulseaudio, waiting for retry.")
+					logger.Warn("Connection lost to pulseaudio, waiting for retry")
+					hamdeck.NotifyErrorListeners(c.listeners.All(), hamdeck.ErrConnectionLost)
 				case <-c.done:
-					log.Print("Connection to pulseaudio closed.")
+					logger.Info("Connection to pulseaudio closed")
 					return
 				}
 			} else {
-				log.Printf("Cannot connect to pulseaudio, waiting for retry: %v", err)
+				logger.Warn("Cannot connect to pulseaudio, waiting for retry", "error", err)
+				hamdeck.NotifyErrorListeners(c.listeners.All(), err)
 			}
 
 			select {
 			case <-time.After(c.retryInterval):
-				log.Print("Retrying to connect to pulseaudio")
+				logger.Info("Retrying to connect to pulseaudio")
 			case <-c.done:
-				log.Print("Connection to pulseaudio closed.")
+				logger.Info("Connection to pulseaudio closed")
 				return
 			}
 		}
@@ -146,17 +165,9 @@ func (c *PulseClient) connect(whenClosed func()) error {
 	}
 
 	c.connected = true
-	hamdeck.NotifyEnablers(c.listeners, true)
-	log.Print("Connected to pulseaudio.")
-
-	c.client.Callback = func(msg interface{}) {
-		switch msg := msg.(type) {
-		case *proto.SubscribeEvent:
-			c.subscribeEvents <- msg
-		default:
-			log.Print("unknown message type ", msg)
-		}
-	}
+	hamdeck.NotifyEnablers(c.listeners.All(), true)
+	c.notifySinksListeners()
+	logger.Info("Connected to pulseaudio")
 
 	c.onPulseConnectionClosed = func(event interface{}) {
 		if _, isConnectionClosed := event.(*proto.ConnectionClosed); !isConnectionClosed {
@@ -164,13 +175,22 @@ func (c *PulseClient) connect(whenClosed func()) error {
 		}
 
 		c.connected = false
-		hamdeck.NotifyEnablers(c.listeners, false)
+		hamdeck.NotifyEnablers(c.listeners.All(), false)
 
 		if whenClosed != nil {
 			whenClosed()
 		}
 	}
-	c.client.Callback = c.onPulseConnectionClosed
+	c.client.Callback = func(msg interface{}) {
+		switch msg := msg.(type) {
+		case *proto.SubscribeEvent:
+			c.subscribeEvents <- msg
+		case *proto.ConnectionClosed:
+			c.onPulseConnectionClosed(msg)
+		default:
+			logger.Debug("unknown message type", "message", msg)
+		}
+	}
 	return nil
 }
 
@@ -191,7 +211,12 @@ func (c *PulseClient) Connected() bool {
 */
 
 func (c *PulseClient) Listen(listener interface{}) {
-	c.listeners = append(c.listeners, listener)
+	c.listeners.Add(listener)
+}
+
+// Unlisten removes the given listener, e.g. when its button is closed.
+func (c *PulseClient) Unlisten(listener interface{}) {
+	c.listeners.Remove(listener)
 }
 
 func (c *PulseClient) handleSubscribeEvents() {
@@ -200,6 +225,9 @@ func (c *PulseClient) handleSubscribeEvents() {
 		facility := msg.Event & paSubscriptionEventFacilityMask
 		index := int(msg.Index)
 
+		if facility == paSubscriptionEventSink && eventType != paSubscriptionEventChange {
+			c.notifySinksListeners()
+		}
 		if eventType == paSubscriptionEventRemove {
 			continue
 		}
@@ -214,7 +242,7 @@ func (c *PulseClient) handleSubscribeEvents() {
 		case paSubscriptionEventSourceOutput:
 			c.handleSourceOutputChange(index)
 		default:
-			log.Printf("unknown event facility: %d", facility)
+			logger.Debug("unknown event facility", "facility", facility)
 		}
 	}
 }
@@ -226,7 +254,7 @@ func (c *PulseClient) handleSinkChange(index int) {
 	infoReply := proto.GetSinkInfoReply{}
 	err := c.client.Request(&infoRequest, &infoReply)
 	if err != nil {
-		log.Printf("cannot get sink info: %v", err)
+		logger.Error("cannot get sink info", "error", err)
 		return
 	}
 
@@ -240,7 +268,7 @@ func (c *PulseClient) handleSourceChange(index int) {
 	infoReply := proto.GetSourceInfoReply{}
 	err := c.client.Request(&infoRequest, &infoReply)
 	if err != nil {
-		log.Printf("cannot get source info: %v", err)
+		logger.Error("cannot get source info", "error", err)
 		return
 	}
 
@@ -254,7 +282,7 @@ func (c *PulseClient) handleSinkInputChange(index int) {
 	infoReply := proto.GetSinkInputInfoReply{}
 	err := c.client.Request(&infoRequest, &infoReply)
 	if err != nil {
-		log.Printf("cannot get sink input info: %v", err)
+		logger.Error("cannot get sink input info", "error", err)
 		return
 	}
 
@@ -268,7 +296,7 @@ func (c *PulseClient) handleSourceOutputChange(index int) {
 	infoReply := proto.GetSourceOutputInfoReply{}
 	err := c.client.Request(&infoRequest, &infoReply)
 	if err != nil {
-		log.Printf("cannot get source output info: %v", err)
+		logger.Error("cannot get source output info", "error", err)
 		return
 	}
 
@@ -276,7 +304,7 @@ func (c *PulseClient) handleSourceOutputChange(index int) {
 }
 
 func (c *PulseClient) notifyMuteListeners(id string, mute bool) {
-	for _, listener := range c.listeners {
+	for _, listener := range c.listeners.All() {
 		muteListener, ok := listener.(MuteListener)
 		if ok {
 			muteListener.SetMute(id, mute)
@@ -284,10 +312,41 @@ func (c *PulseClient) notifyMuteListeners(id string, mute bool) {
 	}
 }
 
+func (c *PulseClient) notifySinksListeners() {
+	for _, listener := range c.listeners.All() {
+		sinksListener, ok := listener.(SinksListener)
+		if ok {
+			sinksListener.SinksChanged()
+		}
+	}
+}
+
 /*
 	Sink
 */
 
+// Sinks returns all sinks of the pulseaudio server.
+func (c *PulseClient) Sinks() ([]Sink, error) {
+	if !c.connected {
+		return nil, fmt.Errorf("not connected to pulseaudio")
+	}
+	reply := proto.GetSinkInfoListReply{}
+	err := c.client.Request(&proto.GetSinkInfoList{}, &reply)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the list of sinks: %w", err)
+	}
+
+	result := make([]Sink, 0, len(reply))
+	for _, info := range reply {
+		description := info.SinkName
+		if entry, ok := info.Properties["device.description"]; ok {
+			description = entry.String()
+		}
+		result = append(result, Sink{Name: info.SinkName, Description: description})
+	}
+	return result, nil
+}
+
 func (c *PulseClient) ToggleMuteSink(id string) (bool, error) {
 	infoRequest := proto.GetSinkInfo{
 		SinkIndex: proto.Undefined,
@@ -1,6 +1,13 @@
 package pulse
 
-import "github.com/ftl/hamdeck/pkg/hamdeck"
+import (
+	"sync"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
+)
+
+var logger = logging.For("pulse")
 
 const (
 	ConfigSinkID           = "sink"
@@ -11,26 +18,62 @@ const (
 )
 
 const (
+	ConnectionType       = "pulse"
 	ToggleMuteButtonType = "pulse.ToggleMute"
+	SinksGenerator       = "pulse-sinks"
 )
 
-func NewButtonFactory() *Factory {
+func NewButtonFactory(station hamdeck.StatePublisher) *Factory {
 	client := NewClient()
+	client.Listen(newStatePublisher(station))
 	client.KeepOpen()
 
-	return &Factory{
-		client: client,
+	return newFactory(client)
+}
+
+func newFactory(client Mixer) *Factory {
+	result := &Factory{
+		client:      client,
+		changedLock: new(sync.Mutex),
 	}
+	client.Listen(SinksListenerFunc(result.sinksChanged))
+	return result
+}
+
+// Mixer controls the mute state of sinks, sources, sink inputs, and source outputs.
+type Mixer interface {
+	Connected() bool
+	Listen(listener interface{})
+	Unlisten(listener interface{})
+	Close()
+
+	IsSinkMuted(id string) (bool, error)
+	ToggleMuteSink(id string) (bool, error)
+	IsSourceMuted(id string) (bool, error)
+	ToggleMuteSource(id string) (bool, error)
+	IsSinkInputMuted(mediaName string) (bool, error)
+	ToggleMuteSinkInput(mediaName string) (bool, error)
+	IsSourceOutputMuted(mediaName string) (bool, error)
+	ToggleMuteSourceOutput(mediaName string) (bool, error)
+
+	Sinks() ([]Sink, error)
 }
 
 type Factory struct {
-	client *PulseClient
+	client Mixer
+
+	changedLock *sync.Mutex
+	changed     []func()
 }
 
 func (f *Factory) Close() {
 	f.client.Close()
 }
 
+func (f *Factory) RequestState(connection string, _ string) bool {
+	return connection == ConnectionType
+}
+
 func (f *Factory) CreateButton(config map[string]interface{}) hamdeck.Button {
 	switch config[hamdeck.ConfigType] {
 	case ToggleMuteButtonType:
@@ -52,3 +95,49 @@ func (f *Factory) createToggleMuteButton(config map[string]interface{}) hamdeck.
 
 	return NewToggleMuteButton(f.client, sinkID, sourceID, sinkInputName, sourceOutputName, label)
 }
+
+// GenerateButtons generates a pulse.ToggleMute button for each sink of the pulseaudio server. The page is
+// regenerated when sinks are added or removed.
+func (f *Factory) GenerateButtons(config map[string]interface{}, changed func()) ([]map[string]interface{}, bool) {
+	kind, _ := hamdeck.ToString(config[hamdeck.ConfigKind])
+	if kind != SinksGenerator {
+		return nil, false
+	}
+	if changed != nil {
+		f.changedLock.Lock()
+		f.changed = append(f.changed, changed)
+		f.changedLock.Unlock()
+	}
+
+	sinks, err := f.client.Sinks()
+	if err != nil {
+		logger.Warn("cannot generate the sink buttons", "error", err)
+		return []map[string]interface{}{}, true
+	}
+	result := make([]map[string]interface{}, 0, len(sinks))
+	for _, sink := range sinks {
+		result = append(result, map[string]interface{}{
+			hamdeck.ConfigType: ToggleMuteButtonType,
+			ConfigSinkID:       sink.Name,
+			ConfigLabel:        sink.Description,
+		})
+	}
+	return result, true
+}
+
+// ClearChanged drops the changed functions of the pages that were generated for the previous configuration.
+func (f *Factory) ClearChanged() {
+	f.changedLock.Lock()
+	defer f.changedLock.Unlock()
+	f.changed = nil
+}
+
+func (f *Factory) sinksChanged() {
+	f.changedLock.Lock()
+	changed := f.changed
+	f.changedLock.Unlock()
+
+	for _, notify := range changed {
+		notify()
+	}
+}
@@ -0,0 +1,48 @@
+package pulse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+func TestGenerateButtons_Sinks(t *testing.T) {
+	simulator := NewSimulator()
+	factory := newFactory(simulator)
+	changes := 0
+
+	buttons, ok := factory.GenerateButtons(map[string]interface{}{hamdeck.ConfigKind: SinksGenerator}, func() { changes++ })
+
+	require.True(t, ok)
+	assert.Equal(t, []map[string]interface{}{
+		{hamdeck.ConfigType: ToggleMuteButtonType, ConfigSinkID: "simulated.speakers", ConfigLabel: "Speakers"},
+		{hamdeck.ConfigType: ToggleMuteButtonType, ConfigSinkID: "simulated.radio", ConfigLabel: "Radio"},
+	}, buttons)
+
+	simulator.SetSinks([]Sink{{Name: "usb", Description: "USB Audio CODEC"}})
+	assert.Equal(t, 1, changes)
+	buttons, _ = factory.GenerateButtons(map[string]interface{}{hamdeck.ConfigKind: SinksGenerator}, nil)
+	assert.Equal(t, "usb", buttons[0][ConfigSinkID])
+
+	_, ok = factory.GenerateButtons(map[string]interface{}{hamdeck.ConfigKind: "bands"}, nil)
+	assert.False(t, ok)
+}
+
+func TestGenerateButtons_ClearChanged(t *testing.T) {
+	simulator := NewSimulator()
+	factory := newFactory(simulator)
+	staleChanges := 0
+	changes := 0
+
+	factory.GenerateButtons(map[string]interface{}{hamdeck.ConfigKind: SinksGenerator}, func() { staleChanges++ })
+	factory.ClearChanged()
+	factory.GenerateButtons(map[string]interface{}{hamdeck.ConfigKind: SinksGenerator}, func() { changes++ })
+	simulator.SetSinks([]Sink{{Name: "usb", Description: "USB Audio CODEC"}})
+
+	assert.Equal(t, 0, staleChanges)
+	assert.Equal(t, 1, changes)
+	assert.Len(t, simulator.listeners.All(), 1, "the factory listens only once")
+}
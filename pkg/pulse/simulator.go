@@ -0,0 +1,150 @@
+package pulse
+
+import (
+	"sync"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+// NewSimulatedButtonFactory creates a factory for pulseaudio buttons that control a simulated mixer instead of
+// the pulseaudio server. Every change of the mute state is logged.
+func NewSimulatedButtonFactory(station hamdeck.StatePublisher) *Factory {
+	mixer := NewSimulator()
+	mixer.Listen(newStatePublisher(station))
+	mixer.KeepOpen()
+
+	return newFactory(mixer)
+}
+
+// DefaultSimulatedSinks are the sinks of a new simulator.
+var DefaultSimulatedSinks = []Sink{
+	{Name: "simulated.speakers", Description: "Speakers"},
+	{Name: "simulated.radio", Description: "Radio"},
+}
+
+// Simulator is an in-process mixer that keeps the mute state of any sink, source, sink input, or source output.
+type Simulator struct {
+	lock      *sync.Mutex
+	connected bool
+	muted     map[string]bool
+	sinks     []Sink
+	listeners *hamdeck.Listeners
+}
+
+func NewSimulator() *Simulator {
+	return &Simulator{
+		lock:      new(sync.Mutex),
+		muted:     make(map[string]bool),
+		sinks:     DefaultSimulatedSinks,
+		listeners: hamdeck.NewListeners(),
+	}
+}
+
+func (s *Simulator) KeepOpen() {
+	s.lock.Lock()
+	s.connected = true
+	listeners := s.listeners.All()
+	s.lock.Unlock()
+
+	hamdeck.NotifyEnablers(listeners, true)
+}
+
+func (s *Simulator) Close() {
+	s.lock.Lock()
+	s.connected = false
+	listeners := s.listeners.All()
+	s.lock.Unlock()
+
+	hamdeck.NotifyEnablers(listeners, false)
+}
+
+func (s *Simulator) Connected() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.connected
+}
+
+func (s *Simulator) Listen(listener interface{}) {
+	s.listeners.Add(listener)
+}
+
+func (s *Simulator) Unlisten(listener interface{}) {
+	s.listeners.Remove(listener)
+}
+
+func (s *Simulator) isMuted(kind string, id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.muted[kind+"/"+id], nil
+}
+
+func (s *Simulator) toggleMute(kind string, id string) (bool, error) {
+	s.lock.Lock()
+	key := kind + "/" + id
+	muted := !s.muted[key]
+	s.muted[key] = muted
+	listeners := s.listeners.All()
+	s.lock.Unlock()
+
+	logger.Info("simulated pulse mute", "kind", kind, "id", id, "muted", muted)
+	for _, listener := range listeners {
+		if muteListener, ok := listener.(MuteListener); ok {
+			muteListener.SetMute(id, muted)
+		}
+	}
+	return muted, nil
+}
+
+func (s *Simulator) IsSinkMuted(id string) (bool, error) {
+	return s.isMuted("sink", id)
+}
+
+func (s *Simulator) ToggleMuteSink(id string) (bool, error) {
+	return s.toggleMute("sink", id)
+}
+
+func (s *Simulator) IsSourceMuted(id string) (bool, error) {
+	return s.isMuted("source", id)
+}
+
+func (s *Simulator) ToggleMuteSource(id string) (bool, error) {
+	return s.toggleMute("source", id)
+}
+
+func (s *Simulator) IsSinkInputMuted(mediaName string) (bool, error) {
+	return s.isMuted("sink input", mediaName)
+}
+
+func (s *Simulator) ToggleMuteSinkInput(mediaName string) (bool, error) {
+	return s.toggleMute("sink input", mediaName)
+}
+
+func (s *Simulator) IsSourceOutputMuted(mediaName string) (bool, error) {
+	return s.isMuted("source output", mediaName)
+}
+
+func (s *Simulator) ToggleMuteSourceOutput(mediaName string) (bool, error) {
+	return s.toggleMute("source output", mediaName)
+}
+
+func (s *Simulator) Sinks() ([]Sink, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make([]Sink, len(s.sinks))
+	copy(result, s.sinks)
+	return result, nil
+}
+
+// SetSinks replaces the simulated sinks and notifies the SinksListeners.
+func (s *Simulator) SetSinks(sinks []Sink) {
+	s.lock.Lock()
+	s.sinks = sinks
+	listeners := s.listeners.All()
+	s.lock.Unlock()
+
+	for _, listener := range listeners {
+		if sinksListener, ok := listener.(SinksListener); ok {
+			sinksListener.SinksChanged()
+		}
+	}
+}
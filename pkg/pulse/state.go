@@ -0,0 +1,24 @@
+package pulse
+
+import "github.com/ftl/hamdeck/pkg/hamdeck"
+
+// statePublisher publishes the state of the pulseaudio connection to the HamDeck.
+type statePublisher struct {
+	publisher hamdeck.StatePublisher
+}
+
+func newStatePublisher(publisher hamdeck.StatePublisher) *statePublisher {
+	result := &statePublisher{
+		publisher: publisher,
+	}
+	result.Enable(false)
+	return result
+}
+
+func (p *statePublisher) Enable(enabled bool) {
+	p.publisher.PublishState(ConnectionType, hamdeck.StateConnected, hamdeck.FormatBoolState(enabled))
+}
+
+func (p *statePublisher) ConnectionFailed(err error) {
+	p.publisher.PublishState(ConnectionType, hamdeck.StateError, err.Error())
+}
@@ -3,13 +3,15 @@ package streamdeck
 import (
 	"fmt"
 	"image"
-	"log"
 
 	"github.com/muesli/streamdeck"
 
 	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
 )
 
+var logger = logging.For("streamdeck")
+
 func Open(serial string) (*Device, error) {
 	devices, err := streamdeck.Devices()
 	if err != nil {
@@ -18,7 +20,7 @@ func Open(serial string) (*Device, error) {
 	if len(devices) == 0 {
 		return nil, fmt.Errorf("no Stream Deck devices found")
 	}
-	log.Printf("Found %d Stream Deck devices", len(devices))
+	logger.Info("Found Stream Deck devices", "count", len(devices))
 
 	device := devices[0]
 	if serial != "" {
@@ -46,7 +48,7 @@ func Open(serial string) (*Device, error) {
 
 	firmwareVersion, err := device.FirmwareVersion()
 	if err != nil {
-		log.Printf("Cannot read firmware version from Stream Deck with serial %v: %v", device.Serial, err)
+		logger.Error("Cannot read firmware version from Stream Deck", "serial", device.Serial, "error", err)
 		firmwareVersion = "n/a"
 	}
 
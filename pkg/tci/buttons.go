@@ -3,7 +3,7 @@ package tci
 import (
 	"fmt"
 	"image"
-	"log"
+	"sync"
 	"time"
 
 	"github.com/ftl/hamradio"
@@ -145,8 +145,9 @@ func (b *SetModeButton) Pressed() {
 		return
 	}
 	err := b.client.SetMode(b.currentTRX, b.mode)
+	b.AuditCommand(fmt.Sprintf("modulation:%d,%s", b.currentTRX, b.mode), err)
 	if err != nil {
-		log.Printf("cannot set mode: %v", err)
+		logger.Error("cannot set mode", "error", err)
 	}
 }
 
@@ -154,18 +155,24 @@ func (b *SetModeButton) Released() {
 	b.longpress.Released()
 }
 
+func (b *SetModeButton) Close() {
+	b.client.Unnotify(b)
+}
+
 func (b *SetModeButton) OnLongpress() {
 	if !b.enabled {
 		return
 	}
 	frequency := findModePortionCenter(b.currentFrequency[b.currentTRX], b.bandplanMode)
 	err := b.client.SetDDS(b.currentTRX, frequency)
+	b.AuditCommand(fmt.Sprintf("dds:%d,%v", b.currentTRX, frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the center of the %s band portion: %v", b.bandplanMode, err)
+		logger.Error("cannot jump to the center of the band portion", "mode", b.bandplanMode, "error", err)
 	}
 	err = b.client.SetVFOFrequency(b.currentTRX, client.VFOA, frequency)
+	b.AuditCommand(fmt.Sprintf("vfo:%d,%d,%v", b.currentTRX, client.VFOA, frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the center of the %s band portion: %v", b.bandplanMode, err)
+		logger.Error("cannot jump to the center of the band portion", "mode", b.bandplanMode, "error", err)
 	}
 }
 
@@ -370,8 +377,9 @@ func (b *ToggleModeButton) Pressed() {
 		modeIndex = (modeIndex + 1) % len(b.modes)
 	}
 	err := b.client.SetMode(b.currentTRX, b.modes[modeIndex])
+	b.AuditCommand(fmt.Sprintf("modulation:%d,%s", b.currentTRX, b.modes[modeIndex]), err)
 	if err != nil {
-		log.Printf("cannot set mode: %v", err)
+		logger.Error("cannot set mode", "error", err)
 	}
 }
 
@@ -379,18 +387,24 @@ func (b *ToggleModeButton) Released() {
 	b.longpress.Released()
 }
 
+func (b *ToggleModeButton) Close() {
+	b.client.Unnotify(b)
+}
+
 func (b *ToggleModeButton) OnLongpress() {
 	if !b.enabled {
 		return
 	}
 	frequency := findModePortionCenter(b.currentFrequency[b.currentTRX], b.bandplanModes[b.selectedModeIndex])
 	err := b.client.SetDDS(b.currentTRX, frequency)
+	b.AuditCommand(fmt.Sprintf("dds:%d,%v", b.currentTRX, frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the beginning of the %s band portion: %v", b.bandplanModes[b.selectedModeIndex], err)
+		logger.Error("cannot jump to the beginning of the band portion", "mode", b.bandplanModes[b.selectedModeIndex], "error", err)
 	}
 	err = b.client.SetVFOFrequency(b.currentTRX, client.VFOA, frequency)
+	b.AuditCommand(fmt.Sprintf("vfo:%d,%d,%v", b.currentTRX, client.VFOA, frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the beginning of the %s band portion: %v", b.bandplanModes[b.selectedModeIndex], err)
+		logger.Error("cannot jump to the beginning of the band portion", "mode", b.bandplanModes[b.selectedModeIndex], "error", err)
 	}
 }
 
@@ -561,8 +575,9 @@ func (b *SetFilterButton) Pressed() {
 
 	if b.mode != "" {
 		err := b.client.SetMode(b.currentTRX, b.mode)
+		b.AuditCommand(fmt.Sprintf("modulation:%d,%s", b.currentTRX, b.mode), err)
 		if err != nil {
-			log.Printf("cannot set mode: %v", err)
+			logger.Error("cannot set mode", "error", err)
 		}
 	}
 
@@ -571,8 +586,9 @@ func (b *SetFilterButton) Pressed() {
 	time.Sleep(200 * time.Millisecond)
 
 	err := b.client.SetRXFilterBand(b.currentTRX, b.bottomFrequency, b.topFrequency)
+	b.AuditCommand(fmt.Sprintf("rx_filter_band:%d,%v,%v", b.currentTRX, b.bottomFrequency, b.topFrequency), err)
 	if err != nil {
-		log.Printf("cannot set rx filter band: %v", err)
+		logger.Error("cannot set rx filter band", "error", err)
 	}
 }
 
@@ -580,6 +596,10 @@ func (b *SetFilterButton) Released() {
 	b.longpress.Released()
 }
 
+func (b *SetFilterButton) Close() {
+	b.client.Unnotify(b)
+}
+
 func (b *SetFilterButton) OnLongpress() {
 	if !b.enabled {
 		return
@@ -590,12 +610,14 @@ func (b *SetFilterButton) OnLongpress() {
 
 	frequency := findModePortionCenter(b.currentFrequency[b.currentTRX], b.bandplanMode)
 	err := b.client.SetDDS(b.currentTRX, frequency)
+	b.AuditCommand(fmt.Sprintf("dds:%d,%v", b.currentTRX, frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the center of the %s band portion: %v", b.bandplanMode, err)
+		logger.Error("cannot jump to the center of the band portion", "mode", b.bandplanMode, "error", err)
 	}
 	err = b.client.SetVFOFrequency(b.currentTRX, client.VFOA, frequency)
+	b.AuditCommand(fmt.Sprintf("vfo:%d,%d,%v", b.currentTRX, client.VFOA, frequency), err)
 	if err != nil {
-		log.Printf("cannot jump to the center of the %s band portion: %v", b.bandplanMode, err)
+		logger.Error("cannot jump to the center of the band portion", "mode", b.bandplanMode, "error", err)
 	}
 }
 
@@ -697,8 +719,9 @@ func (b *MOXButton) Pressed() {
 	}
 	value := !b.selected
 	err := b.client.SetTX(b.currentTRX, value, client.SignalSourceDefault)
+	b.AuditCommand(fmt.Sprintf("trx:%d,%t", b.currentTRX, value), err)
 	if err != nil {
-		log.Printf("cannot set PTT to %t: %v", value, err)
+		logger.Error("cannot set PTT", "value", value, "error", err)
 	}
 }
 
@@ -706,6 +729,14 @@ func (b *MOXButton) Released() {
 	// ignore
 }
 
+func (b *MOXButton) Close() {
+	b.client.Unnotify(b)
+}
+
+func (b *MOXButton) KeysTransmitter() bool {
+	return !b.selected
+}
+
 /*
 	TuneButton
 */
@@ -804,8 +835,9 @@ func (b *TuneButton) Pressed() {
 	}
 	value := !b.selected
 	err := b.client.SetTune(b.currentTRX, value)
+	b.AuditCommand(fmt.Sprintf("tune:%d,%t", b.currentTRX, value), err)
 	if err != nil {
-		log.Printf("cannot set Tune to %t: %v", value, err)
+		logger.Error("cannot set Tune", "value", value, "error", err)
 	}
 }
 
@@ -813,6 +845,14 @@ func (b *TuneButton) Released() {
 	// ignore
 }
 
+func (b *TuneButton) Close() {
+	b.client.Unnotify(b)
+}
+
+func (b *TuneButton) KeysTransmitter() bool {
+	return !b.selected
+}
+
 /*
 	MuteButton
 */
@@ -894,8 +934,9 @@ func (b *MuteButton) Pressed() {
 	}
 	value := !b.selected
 	err := b.client.SetMute(value)
+	b.AuditCommand(fmt.Sprintf("mute:%t", value), err)
 	if err != nil {
-		log.Printf("cannot set Mute to %t: %v", value, err)
+		logger.Error("cannot set Mute", "value", value, "error", err)
 	}
 }
 
@@ -903,12 +944,17 @@ func (b *MuteButton) Released() {
 	// ignore
 }
 
+func (b *MuteButton) Close() {
+	b.client.Unnotify(b)
+}
+
 /*
 	SetDriveButton
 */
 
 func NewSetDriveButton(tciClient *Client, label string, value int) *SetDriveButton {
 	result := &SetDriveButton{
+		lock:    new(sync.Mutex),
 		client:  tciClient,
 		enabled: tciClient.Connected(),
 		label:   label,
@@ -929,6 +975,14 @@ type SetDriveButton struct {
 	selected      bool
 	label         string
 	value         int
+	lock          *sync.Mutex
+	currentValue  int
+}
+
+func (b *SetDriveButton) current() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.currentValue
 }
 
 func (b *SetDriveButton) Enable(enabled bool) {
@@ -940,6 +994,9 @@ func (b *SetDriveButton) Enable(enabled bool) {
 }
 
 func (b *SetDriveButton) SetDrive(percent int) {
+	b.lock.Lock()
+	b.currentValue = percent
+	b.lock.Unlock()
 	wasSelected := b.selected
 	b.selected = (percent == b.value)
 	if b.selected == wasSelected {
@@ -974,8 +1031,9 @@ func (b *SetDriveButton) Pressed() {
 		return
 	}
 	err := b.client.SetDrive(b.value)
+	b.AuditCommand(fmt.Sprintf("drive:%v", b.value), err)
 	if err != nil {
-		log.Printf("cannot set drive to %d: %v", b.value, err)
+		logger.Error("cannot set drive", "value", b.value, "error", err)
 	}
 }
 
@@ -983,12 +1041,21 @@ func (b *SetDriveButton) Released() {
 	// ignore
 }
 
+func (b *SetDriveButton) Close() {
+	b.client.Unnotify(b)
+}
+
+func (b *SetDriveButton) KeysTransmitter() bool {
+	return b.value > b.current()
+}
+
 /*
 	IncrementDriveButton
 */
 
 func NewIncrementDriveButton(tciClient *Client, label string, increment int) *IncrementDriveButton {
 	result := &IncrementDriveButton{
+		lock:      new(sync.Mutex),
 		client:    tciClient,
 		enabled:   tciClient.Connected(),
 		label:     label,
@@ -1010,10 +1077,17 @@ type IncrementDriveButton struct {
 	selected      bool
 	label         string
 	increment     int
+	lock          *sync.Mutex
 	currentValue  int
 	longpress     *hamdeck.LongpressHandler
 }
 
+func (b *IncrementDriveButton) current() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.currentValue
+}
+
 func (b *IncrementDriveButton) Enable(enabled bool) {
 	if enabled == b.enabled {
 		return
@@ -1023,7 +1097,9 @@ func (b *IncrementDriveButton) Enable(enabled bool) {
 }
 
 func (b *IncrementDriveButton) SetDrive(percent int) {
+	b.lock.Lock()
 	b.currentValue = percent
+	b.lock.Unlock()
 	wasSelected := b.selected
 	if b.increment > 0 {
 		b.selected = (percent == 100)
@@ -1054,7 +1130,7 @@ func (b *IncrementDriveButton) redrawImages(gc hamdeck.GraphicContext) {
 	}
 	text := b.label
 	if b.selected {
-		text = fmt.Sprintf("%d%%", b.currentValue)
+		text = fmt.Sprintf("%d%%", b.current())
 	}
 	b.image = gc.DrawSingleLineTextButton(text)
 	gc.SwapColors()
@@ -1066,10 +1142,11 @@ func (b *IncrementDriveButton) Pressed() {
 	if !b.enabled {
 		return
 	}
-	value := b.currentValue + b.increment
+	value := b.current() + b.increment
 	err := b.client.SetDrive(value)
+	b.AuditCommand(fmt.Sprintf("drive:%v", value), err)
 	if err != nil {
-		log.Printf("cannot increment drive to %d: %v", value, err)
+		logger.Error("cannot increment drive", "value", value, "error", err)
 	}
 }
 
@@ -1077,6 +1154,14 @@ func (b *IncrementDriveButton) Released() {
 	b.longpress.Released()
 }
 
+func (b *IncrementDriveButton) Close() {
+	b.client.Unnotify(b)
+}
+
+func (b *IncrementDriveButton) KeysTransmitter() bool {
+	return b.increment > 0
+}
+
 func (b *IncrementDriveButton) OnLongpress() {
 	if !b.enabled {
 		return
@@ -1088,8 +1173,9 @@ func (b *IncrementDriveButton) OnLongpress() {
 		value = 0
 	}
 	err := b.client.SetDrive(value)
+	b.AuditCommand(fmt.Sprintf("drive:%v", value), err)
 	if err != nil {
-		log.Printf("cannot increment drive to %d: %v", value, err)
+		logger.Error("cannot increment drive", "value", value, "error", err)
 	}
 }
 
@@ -1099,6 +1185,7 @@ func (b *IncrementDriveButton) OnLongpress() {
 
 func NewIncrementVolumeButton(tciClient *Client, label string, increment int) *IncrementVolumeButton {
 	result := &IncrementVolumeButton{
+		lock:      new(sync.Mutex),
 		client:    tciClient,
 		enabled:   tciClient.Connected(),
 		label:     label,
@@ -1119,9 +1206,16 @@ type IncrementVolumeButton struct {
 	selected      bool
 	label         string
 	increment     int
+	lock          *sync.Mutex
 	currentValue  int
 }
 
+func (b *IncrementVolumeButton) current() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.currentValue
+}
+
 func (b *IncrementVolumeButton) Enable(enabled bool) {
 	if enabled == b.enabled {
 		return
@@ -1131,7 +1225,9 @@ func (b *IncrementVolumeButton) Enable(enabled bool) {
 }
 
 func (b *IncrementVolumeButton) SetVolume(dB int) {
+	b.lock.Lock()
 	b.currentValue = dB
+	b.lock.Unlock()
 	wasSelected := b.selected
 	if b.increment > 0 {
 		b.selected = (dB == 0)
@@ -1162,7 +1258,7 @@ func (b *IncrementVolumeButton) redrawImages(gc hamdeck.GraphicContext) {
 	}
 	text := b.label
 	if b.selected {
-		text = fmt.Sprintf("%ddB", b.currentValue)
+		text = fmt.Sprintf("%ddB", b.current())
 	}
 	var imageName string
 	if b.increment > 0 {
@@ -1180,10 +1276,11 @@ func (b *IncrementVolumeButton) Pressed() {
 	if !b.enabled {
 		return
 	}
-	value := b.currentValue + b.increment
+	value := b.current() + b.increment
 	err := b.client.SetVolume(value)
+	b.AuditCommand(fmt.Sprintf("volume:%v", value), err)
 	if err != nil {
-		log.Printf("cannot increment volume to %d: %v", value, err)
+		logger.Error("cannot increment volume", "value", value, "error", err)
 	}
 }
 
@@ -1191,6 +1288,10 @@ func (b *IncrementVolumeButton) Released() {
 	// ignore
 }
 
+func (b *IncrementVolumeButton) Close() {
+	b.client.Unnotify(b)
+}
+
 /*
 	SwitchToBandButton
 */
@@ -1198,7 +1299,7 @@ func (b *IncrementVolumeButton) Released() {
 func NewSwitchToBandButton(tciClient *Client, label string, bandName string) *SwitchToBandButton {
 	band, ok := bandplan.IARURegion1[bandplan.BandName(bandName)]
 	if !ok {
-		log.Printf("cannot find band %s in IARU Region 1 bandplan", bandName)
+		logger.Error("cannot find band in IARU Region 1 bandplan", "band", bandName)
 		return nil
 	}
 	result := &SwitchToBandButton{
@@ -1208,6 +1309,7 @@ func NewSwitchToBandButton(tciClient *Client, label string, bandName string) *Sw
 		band:             band,
 		currentFrequency: make(map[int]int),
 		currentMode:      make(map[int]client.Mode),
+		memory:           make(map[int]bandMemory),
 	}
 
 	tciClient.Notify(result)
@@ -1227,6 +1329,28 @@ type SwitchToBandButton struct {
 	currentTRX       int
 	currentFrequency map[int]int
 	currentMode      map[int]client.Mode
+	memory           map[int]bandMemory
+	store            hamdeck.ButtonStore
+}
+
+// bandMemory is the band stack memory of a SwitchToBandButton: the frequency and mode that were used last on the band.
+type bandMemory struct {
+	Frequency int         `json:"frequency"`
+	Mode      client.Mode `json:"mode"`
+}
+
+// Restore restores the band stack memories of all TRX that were stored the last time.
+func (b *SwitchToBandButton) Restore(store hamdeck.ButtonStore) {
+	b.store = store
+	store.Get(&b.memory)
+}
+
+func (b *SwitchToBandButton) remember(trx int, memory bandMemory) {
+	if memory == b.memory[trx] {
+		return
+	}
+	b.memory[trx] = memory
+	b.store.Set(b.memory)
 }
 
 func (b *SwitchToBandButton) Enable(enabled bool) {
@@ -1250,6 +1374,9 @@ func (b *SwitchToBandButton) SetTRX(trx int) {
 func (b *SwitchToBandButton) SetVFOFrequency(trx int, vfo client.VFO, frequency int) {
 	if vfo == client.VFOA {
 		b.currentFrequency[trx] = frequency
+		if b.band.Contains(hamradio.Frequency(frequency)) {
+			b.remember(trx, bandMemory{Frequency: frequency, Mode: b.currentMode[trx]})
+		}
 	}
 	if trx != b.currentTRX || vfo != client.VFOA {
 		return
@@ -1265,6 +1392,9 @@ func (b *SwitchToBandButton) SetVFOFrequency(trx int, vfo client.VFO, frequency
 
 func (b *SwitchToBandButton) SetMode(trx int, mode client.Mode) {
 	b.currentMode[trx] = mode
+	if b.band.Contains(hamradio.Frequency(b.currentFrequency[trx])) {
+		b.remember(trx, bandMemory{Frequency: b.currentFrequency[trx], Mode: mode})
+	}
 }
 
 func (b *SwitchToBandButton) Image(gc hamdeck.GraphicContext, redrawImages bool) image.Image {
@@ -1298,16 +1428,28 @@ func (b *SwitchToBandButton) Pressed() {
 	}
 	mode := b.currentMode[b.currentTRX]
 	frequency := findModePortionCenter(int(b.band.Center()), toBandplanMode(mode))
+	if memory, ok := b.memory[b.currentTRX]; ok && memory.Frequency != 0 {
+		frequency = memory.Frequency
+		if memory.Mode != "" {
+			mode = memory.Mode
+		}
+	}
 	err := b.client.SetVFOFrequency(b.currentTRX, client.VFOA, frequency)
+	b.AuditCommand(fmt.Sprintf("vfo:%d,%d,%v", b.currentTRX, client.VFOA, frequency), err)
 	if err != nil {
-		log.Printf("cannot switch to band %s: %v", b.band, err)
+		logger.Error("cannot switch band", "band", b.band, "error", err)
 	}
 	err = b.client.SetMode(b.currentTRX, mode)
+	b.AuditCommand(fmt.Sprintf("modulation:%d,%s", b.currentTRX, mode), err)
 	if err != nil {
-		log.Printf("cannot switch band to mode %s: %v", mode, err)
+		logger.Error("cannot switch band to mode", "mode", mode, "error", err)
 	}
 }
 
 func (b *SwitchToBandButton) Released() {
 	// ignore
 }
+
+func (b *SwitchToBandButton) Close() {
+	b.client.Unnotify(b)
+}
@@ -0,0 +1,30 @@
+package tci
+
+import (
+	"testing"
+
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/tci/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwitchToBandButton_RemembersTheLastFrequencyAndModeOnTheBandOfEachTRX(t *testing.T) {
+	button := &SwitchToBandButton{
+		band:             bandplan.IARURegion1[bandplan.Band40m],
+		currentFrequency: make(map[int]int),
+		currentMode:      make(map[int]client.Mode),
+		memory:           make(map[int]bandMemory),
+	}
+
+	button.SetMode(0, client.ModeCW)
+	button.SetVFOFrequency(0, client.VFOA, 7012000)
+	button.SetVFOFrequency(0, client.VFOB, 7015000)
+	button.SetVFOFrequency(1, client.VFOA, 7150000)
+	button.SetMode(1, client.ModeLSB)
+	assert.Equal(t, bandMemory{Frequency: 7012000, Mode: client.ModeCW}, button.memory[0])
+	assert.Equal(t, bandMemory{Frequency: 7150000, Mode: client.ModeLSB}, button.memory[1])
+
+	button.SetVFOFrequency(0, client.VFOA, 14025000)
+	button.SetMode(0, client.ModeUSB)
+	assert.Equal(t, bandMemory{Frequency: 7012000, Mode: client.ModeCW}, button.memory[0], "other bands do not change the memory")
+}
@@ -11,26 +11,34 @@ import (
 
 func NewClient(host *net.TCPAddr) *Client {
 	result := &Client{
-		Client: client.KeepOpen(host, 10*time.Second, false),
+		Client:    client.KeepOpen(host, 10*time.Second, false),
+		listeners: hamdeck.NewListeners(),
 	}
 	result.Client.Notify(client.ConnectionListenerFunc(func(connected bool) {
-		hamdeck.NotifyEnablers(result.listeners, connected)
+		hamdeck.NotifyEnablers(result.listeners.All(), connected)
 	}))
+	result.Client.Notify(&dispatcher{listeners: result.listeners})
 	return result
 }
 
 type Client struct {
 	*client.Client
 
-	listeners []interface{}
+	listeners *hamdeck.Listeners
 
 	trx int
 	vfo client.VFO
 }
 
+// Notify registers the given listener. The listener is notified about the messages from the TCI server until it
+// is removed with Unnotify.
 func (c *Client) Notify(listener interface{}) {
-	c.listeners = append(c.listeners, listener)
-	c.Client.Notify(listener)
+	c.listeners.Add(listener)
+}
+
+// Unnotify removes the given listener, e.g. when its button is closed.
+func (c *Client) Unnotify(listener interface{}) {
+	c.listeners.Remove(listener)
 }
 
 func (c *Client) SetTRX(trx int) {
@@ -43,7 +51,7 @@ type TRXListener interface {
 }
 
 func (c *Client) emitTRX(trx int) {
-	for _, l := range c.listeners {
+	for _, l := range c.listeners.All() {
 		if listener, ok := l.(TRXListener); ok {
 			listener.SetTRX(trx)
 		}
@@ -68,9 +76,79 @@ type VFOListener interface {
 }
 
 func (c *Client) emitVFO(vfo client.VFO) {
-	for _, l := range c.listeners {
+	for _, l := range c.listeners.All() {
 		if listener, ok := l.(VFOListener); ok {
 			listener.SetVFO(vfo)
 		}
 	}
 }
+
+// dispatcher forwards the messages of the TCI server to the current listeners. It is registered once with the
+// TCI client, which cannot remove its listeners.
+type dispatcher struct {
+	listeners *hamdeck.Listeners
+}
+
+func (d *dispatcher) SetMode(trx int, mode client.Mode) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.ModeListener); ok {
+			listener.SetMode(trx, mode)
+		}
+	}
+}
+
+func (d *dispatcher) SetVFOFrequency(trx int, vfo client.VFO, frequency int) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.VFOFrequencyListener); ok {
+			listener.SetVFOFrequency(trx, vfo, frequency)
+		}
+	}
+}
+
+func (d *dispatcher) SetRXFilterBand(trx int, min, max int) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.RXFilterBandListener); ok {
+			listener.SetRXFilterBand(trx, min, max)
+		}
+	}
+}
+
+func (d *dispatcher) SetTX(trx int, enabled bool) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.TXListener); ok {
+			listener.SetTX(trx, enabled)
+		}
+	}
+}
+
+func (d *dispatcher) SetTune(trx int, enabled bool) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.TuneListener); ok {
+			listener.SetTune(trx, enabled)
+		}
+	}
+}
+
+func (d *dispatcher) SetDrive(percent int) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.DriveListener); ok {
+			listener.SetDrive(percent)
+		}
+	}
+}
+
+func (d *dispatcher) SetVolume(dB int) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.VolumeListener); ok {
+			listener.SetVolume(dB)
+		}
+	}
+}
+
+func (d *dispatcher) SetMute(muted bool) {
+	for _, l := range d.listeners.All() {
+		if listener, ok := l.(client.MuteListener); ok {
+			listener.SetMute(muted)
+		}
+	}
+}
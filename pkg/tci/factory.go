@@ -1,17 +1,20 @@
 package tci
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net"
 	"strconv"
 	"strings"
 
 	"github.com/ftl/hamdeck/pkg/hamdeck"
+	"github.com/ftl/hamdeck/pkg/logging"
 	"github.com/ftl/hamradio/bandplan"
 	"github.com/ftl/tci/client"
 )
 
+var logger = logging.For("tci")
+
 const (
 	ConfigAddress         = "address"
 	ConfigCommand         = "command"
@@ -44,14 +47,19 @@ const (
 	SwitchToBandButtonType    = "tci.SwitchToBand"
 )
 
-func NewButtonFactory(provider hamdeck.ConnectionConfigProvider, legacyAddress string) *Factory {
-	result := &Factory{}
-	result.connections = hamdeck.NewConnectionManager(ConnectionType, provider, result.createTCIClient)
+func NewButtonFactory(station hamdeck.Station, legacyAddress string) *Factory {
+	result := &Factory{
+		station: station,
+	}
+	result.connections = hamdeck.NewConnectionManager(ConnectionType, station, result.createTCIClient)
+	result.connections.SetCloser((*Client).Disconnect)
 
 	if legacyAddress != "" {
 		host, err := parseTCPAddr(legacyAddress)
 		if err == nil {
-			result.connections.SetLegacy(NewClient(host))
+			client := NewClient(host)
+			client.Notify(newStatePublisher(hamdeck.LegacyConnectionName, station))
+			result.connections.SetLegacy(client)
 		}
 	}
 
@@ -59,7 +67,9 @@ func NewButtonFactory(provider hamdeck.ConnectionConfigProvider, legacyAddress s
 }
 
 type Factory struct {
+	station     hamdeck.Station
 	connections *hamdeck.ConnectionManager[*Client]
+	simulators  map[*Client]*Simulator
 }
 
 func (f *Factory) createTCIClient(name string, config hamdeck.ConnectionConfig) (*Client, error) {
@@ -73,6 +83,7 @@ func (f *Factory) createTCIClient(name string, config hamdeck.ConnectionConfig)
 		return nil, err
 	}
 	client := NewClient(host)
+	client.Notify(newStatePublisher(name, f.station))
 
 	return client, nil
 }
@@ -81,6 +92,37 @@ func (f *Factory) Close() {
 	f.connections.ForEach(func(client *Client) {
 		client.Disconnect()
 	})
+	for _, simulator := range f.simulators {
+		simulator.Close()
+	}
+}
+
+func (f *Factory) RequestState(connection string, _ string) bool {
+	_, err := f.connections.GetByStateName(connection)
+	return err == nil
+}
+
+// StopTX unkeys the current TRX of the given connection.
+func (f *Factory) StopTX(connection string) (func() error, bool) {
+	tciClient, err := f.connections.GetByStateName(connection)
+	if err != nil {
+		return nil, false
+	}
+	return func() error {
+		if !tciClient.Connected() {
+			return fmt.Errorf("tci connection %s is not connected", connection)
+		}
+		trx := tciClient.TRX()
+		txErr := tciClient.SetTX(trx, false, client.SignalSourceDefault)
+		if txErr != nil {
+			txErr = fmt.Errorf("cannot stop TX: %w", txErr)
+		}
+		tuneErr := tciClient.SetTune(trx, false)
+		if tuneErr != nil {
+			tuneErr = fmt.Errorf("cannot stop tune: %w", tuneErr)
+		}
+		return errors.Join(txErr, tuneErr)
+	}, true
 }
 
 func (f *Factory) CreateButton(config map[string]interface{}) hamdeck.Button {
@@ -117,14 +159,14 @@ func (f *Factory) createSetModeButton(config map[string]interface{}) hamdeck.But
 	mode = strings.ToLower(strings.TrimSpace(mode))
 
 	if !haveMode {
-		log.Print("A tci.SetMode button must have a mode field.")
+		logger.Error("A tci.SetMode button must have a mode field")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.SetMode button: %v", err)
+		logger.Error("Cannot create tci.SetMode button", "error", err)
 		return nil
 	}
 
@@ -141,14 +183,14 @@ func (f *Factory) createToggleModeButton(config map[string]interface{}) hamdeck.
 	mode2 = strings.ToLower(strings.TrimSpace(mode2))
 
 	if !(haveMode1 && haveMode2) {
-		log.Print("A tci.ToggleMode button must have mode1 and mode2 fields.")
+		logger.Error("A tci.ToggleMode button must have mode1 and mode2 fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.ToggleMode button: %v", err)
+		logger.Error("Cannot create tci.ToggleMode button", "error", err)
 		return nil
 	}
 
@@ -163,15 +205,15 @@ func (f *Factory) createSetFilterButton(config map[string]interface{}) hamdeck.B
 	icon, haveIcon := hamdeck.ToString(config[ConfigIcon])
 	mode = strings.ToLower(strings.TrimSpace(mode))
 	if !haveBottomFrequency {
-		log.Print("A tci.SetFilter button must have a bottom_frequency field.")
+		logger.Error("A tci.SetFilter button must have a bottom_frequency field")
 		return nil
 	}
 	if !haveTopFrequency {
-		log.Print("A tci.SetFilter button must have a top_frequency field.")
+		logger.Error("A tci.SetFilter button must have a top_frequency field")
 		return nil
 	}
 	if !haveLabel {
-		log.Print("A tci.SetFilter button must have a label field.")
+		logger.Error("A tci.SetFilter button must have a label field")
 		return nil
 	}
 	if !haveIcon {
@@ -181,7 +223,7 @@ func (f *Factory) createSetFilterButton(config map[string]interface{}) hamdeck.B
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.SetFilter button: %v", err)
+		logger.Error("Cannot create tci.SetFilter button", "error", err)
 		return nil
 	}
 
@@ -194,7 +236,7 @@ func (f *Factory) createMOXButton(config map[string]interface{}) hamdeck.Button
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.MOX button: %v", err)
+		logger.Error("Cannot create tci.MOX button", "error", err)
 		return nil
 	}
 
@@ -207,7 +249,7 @@ func (f *Factory) createTuneButton(config map[string]interface{}) hamdeck.Button
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.Tune button: %v", err)
+		logger.Error("Cannot create tci.Tune button", "error", err)
 		return nil
 	}
 
@@ -220,7 +262,7 @@ func (f *Factory) createMuteButton(config map[string]interface{}) hamdeck.Button
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.Mute button: %v", err)
+		logger.Error("Cannot create tci.Mute button", "error", err)
 		return nil
 	}
 
@@ -231,14 +273,14 @@ func (f *Factory) createSetDriveButton(config map[string]interface{}) hamdeck.Bu
 	value, haveValue := hamdeck.ToInt(config[ConfigValue])
 	label, haveLabel := hamdeck.ToString(config[ConfigLabel])
 	if !(haveValue && haveLabel) {
-		log.Print("A tci.SetDrive button must have value and label fields.")
+		logger.Error("A tci.SetDrive button must have value and label fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.SetDrive button: %v", err)
+		logger.Error("Cannot create tci.SetDrive button", "error", err)
 		return nil
 	}
 
@@ -249,14 +291,14 @@ func (f *Factory) createIncrementDriveButton(config map[string]interface{}) hamd
 	increment, haveIncrement := hamdeck.ToInt(config[ConfigIncrement])
 	label, haveLabel := hamdeck.ToString(config[ConfigLabel])
 	if !(haveIncrement && haveLabel) {
-		log.Print("A tci.IncrementDrive button must have increment and label fields.")
+		logger.Error("A tci.IncrementDrive button must have increment and label fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.IncrementDrive button: %v", err)
+		logger.Error("Cannot create tci.IncrementDrive button", "error", err)
 		return nil
 	}
 
@@ -267,14 +309,14 @@ func (f *Factory) createIncrementVolumeButton(config map[string]interface{}) ham
 	increment, haveIncrement := hamdeck.ToInt(config[ConfigIncrement])
 	label, haveLabel := hamdeck.ToString(config[ConfigLabel])
 	if !(haveIncrement && haveLabel) {
-		log.Print("A tci.IncrementVolume button must have increment and label fields.")
+		logger.Error("A tci.IncrementVolume button must have increment and label fields")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.IncrementVolume button: %v", err)
+		logger.Error("Cannot create tci.IncrementVolume button", "error", err)
 		return nil
 	}
 
@@ -285,14 +327,14 @@ func (f *Factory) createSwitchToBandButton(config map[string]interface{}) hamdec
 	band, haveBand := hamdeck.ToString(config[ConfigBand])
 	label, _ := hamdeck.ToString(config[ConfigLabel])
 	if !(haveBand) {
-		log.Print("A tci.SwitchToBand button must have a band field.")
+		logger.Error("A tci.SwitchToBand button must have a band field")
 		return nil
 	}
 
 	connection, _ := hamdeck.ToString(config[hamdeck.ConfigConnection])
 	tciClient, err := f.connections.Get(connection)
 	if err != nil {
-		log.Printf("Cannot create tci.SwitchToBand button: %v", err)
+		logger.Error("Cannot create tci.SwitchToBand button", "error", err)
 		return nil
 	}
 
@@ -0,0 +1,205 @@
+package tci
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ftl/tci/client"
+	"github.com/gorilla/websocket"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+// the number of leading arguments that address the value of a simulated TCI command, e.g. the TRX
+var simulatorAddressArgs = map[string]int{
+	"dds":            1,
+	"if":             2,
+	"modulation":     1,
+	"rx_filter_band": 1,
+	"trx":            1,
+	"tune":           1,
+	"vfo":            2,
+	"rx_mute":        1,
+}
+
+var simulatorInitialState = []string{
+	"dds:0,14074000;",
+	"vfo:0,0,14074000;",
+	"vfo:0,1,14076000;",
+	"modulation:0,digu;",
+	"rx_filter_band:0,0,3000;",
+	"trx:0,false;",
+	"tune:0,false;",
+	"drive:50;",
+	"mute:false;",
+	"volume:-20;",
+}
+
+// NewSimulatedButtonFactory creates a factory for TCI buttons that are connected to simulated TCI servers instead of
+// real ones. Every command that the buttons send is logged.
+func NewSimulatedButtonFactory(station hamdeck.Station, legacyAddress string) *Factory {
+	result := &Factory{
+		station:    station,
+		simulators: make(map[*Client]*Simulator),
+	}
+	result.connections = hamdeck.NewConnectionManager(ConnectionType, station, result.createSimulatedClient)
+	result.connections.SetCloser(result.closeSimulatedClient)
+
+	if legacyAddress != "" {
+		client, err := result.createSimulatedClient(hamdeck.LegacyConnectionName, nil)
+		if err != nil {
+			logger.Error("Cannot create the simulated legacy tci connection", "error", err)
+		} else {
+			result.connections.SetLegacy(client)
+		}
+	}
+
+	return result
+}
+
+func (f *Factory) createSimulatedClient(name string, _ hamdeck.ConnectionConfig) (*Client, error) {
+	simulator, err := NewSimulator(name)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(simulator.Address())
+	client.Notify(newStatePublisher(name, f.station))
+	f.simulators[client] = simulator
+
+	return client, nil
+}
+
+func (f *Factory) closeSimulatedClient(client *Client) {
+	client.Disconnect()
+	simulator, ok := f.simulators[client]
+	if !ok {
+		return
+	}
+	delete(f.simulators, client)
+	simulator.Close()
+}
+
+// Simulator is an in-process TCI server that keeps a plausible state of a radio. It confirms every command
+// by sending it back to the client, like a real TCI server does.
+type Simulator struct {
+	name     string
+	listener net.Listener
+	upgrader websocket.Upgrader
+
+	lock   *sync.Mutex
+	keys   []string
+	values map[string]string
+}
+
+// NewSimulator starts a simulated TCI server on a free local port.
+func NewSimulator(name string) (*Simulator, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("cannot start the tci simulator: %w", err)
+	}
+
+	result := &Simulator{
+		name:     name,
+		listener: listener,
+		lock:     new(sync.Mutex),
+		values:   make(map[string]string),
+	}
+	for _, message := range simulatorInitialState {
+		parsed, err := client.ParseTextMessage(message)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		result.update(parsed)
+	}
+	go http.Serve(listener, http.HandlerFunc(result.serve))
+
+	return result, nil
+}
+
+func (s *Simulator) Address() *net.TCPAddr {
+	return s.listener.Addr().(*net.TCPAddr)
+}
+
+func (s *Simulator) Close() {
+	s.listener.Close()
+}
+
+func (s *Simulator) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error("simulated tci connection failed", "connection", s.name, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	messages := []string{
+		"protocol:hamdeck,1.4;",
+		"device:simulator;",
+		"trx_count:1;",
+		"channels_count:2;",
+	}
+	messages = append(messages, s.state()...)
+	messages = append(messages, "ready;")
+	for _, message := range messages {
+		err := conn.WriteMessage(websocket.TextMessage, []byte(message))
+		if err != nil {
+			return
+		}
+	}
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+		message, err := client.ParseTextMessage(string(data))
+		if err != nil {
+			logger.Error("invalid command for simulated tci", "connection", s.name, "error", err)
+			continue
+		}
+		logger.Info("simulated tci command", "connection", s.name, "command", message.String())
+
+		s.update(message)
+		err = conn.WriteMessage(websocket.TextMessage, []byte(message.String()))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Simulator) update(message client.Message) {
+	key := message.Name()
+	addressArgs := simulatorAddressArgs[key]
+	args := message.Args()
+	if addressArgs > len(args) {
+		addressArgs = len(args)
+	}
+	if addressArgs > 0 {
+		key += ":" + strings.Join(args[:addressArgs], ",")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.values[key]; !ok {
+		s.keys = append(s.keys, key)
+	}
+	s.values[key] = message.String()
+}
+
+func (s *Simulator) state() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		result = append(result, s.values[key])
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package tci
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ftl/tci/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+type testModeListener struct {
+	lock  *sync.Mutex
+	modes map[int]client.Mode
+}
+
+func (l *testModeListener) SetMode(trx int, mode client.Mode) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.modes[trx] = mode
+}
+
+func (l *testModeListener) Mode(trx int) client.Mode {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.modes[trx]
+}
+
+func TestSimulator(t *testing.T) {
+	simulator, err := NewSimulator("test")
+	require.NoError(t, err)
+	defer simulator.Close()
+
+	listener := &testModeListener{lock: new(sync.Mutex), modes: make(map[int]client.Mode)}
+	tciClient, err := client.Open(simulator.Address(), false, listener)
+	require.NoError(t, err)
+	defer tciClient.Disconnect()
+
+	assert.Eventually(t, func() bool {
+		return listener.Mode(0) == client.ModeDIGU
+	}, time.Second, 10*time.Millisecond, "initial state")
+
+	require.NoError(t, tciClient.SetMode(0, client.ModeCW))
+	assert.Eventually(t, func() bool {
+		return listener.Mode(0) == client.ModeCW
+	}, time.Second, 10*time.Millisecond, "confirmed command")
+	assert.Contains(t, simulator.state(), "modulation:0,cw;")
+}
+
+type testStation struct {
+	lock    *sync.Mutex
+	configs map[string]hamdeck.ConnectionConfig
+}
+
+func (s *testStation) GetConnection(name string, _ string) (hamdeck.ConnectionConfig, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	config, ok := s.configs[name]
+	return config, ok
+}
+
+func (s *testStation) PublishState(string, string, string) {}
+
+func TestSimulatedButtonFactory_ClosesReplacedSimulators(t *testing.T) {
+	station := &testStation{lock: new(sync.Mutex), configs: map[string]hamdeck.ConnectionConfig{"sdr": {"type": "tci"}}}
+	factory := NewSimulatedButtonFactory(station, "")
+	defer factory.Close()
+
+	client, err := factory.connections.Get("sdr")
+	require.NoError(t, err)
+	simulator := factory.simulators[client]
+	require.NotNil(t, simulator)
+
+	station.lock.Lock()
+	station.configs["sdr"] = hamdeck.ConnectionConfig{"type": "tci", "label": "changed"}
+	station.lock.Unlock()
+	_, err = factory.connections.Get("sdr")
+	require.NoError(t, err)
+
+	_, ok := factory.simulators[client]
+	assert.False(t, ok)
+	_, err = net.Dial("tcp", simulator.Address().String())
+	assert.Error(t, err, "the replaced simulator is still listening")
+}
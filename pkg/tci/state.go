@@ -0,0 +1,107 @@
+package tci
+
+import (
+	"strconv"
+
+	"github.com/ftl/hamradio"
+	"github.com/ftl/hamradio/bandplan"
+	"github.com/ftl/tci/client"
+
+	"github.com/ftl/hamdeck/pkg/hamdeck"
+)
+
+// statePublisher publishes the state of the current TRX of a TCI connection to the HamDeck.
+type statePublisher struct {
+	connection string
+	publisher  hamdeck.StatePublisher
+
+	connected        bool
+	currentTRX       int
+	currentMode      map[int]client.Mode
+	currentFrequency map[int]int
+	currentTX        map[int]bool
+	currentTune      map[int]bool
+}
+
+func newStatePublisher(name string, publisher hamdeck.StatePublisher) *statePublisher {
+	result := &statePublisher{
+		connection:       hamdeck.StateConnectionName(name, ConnectionType),
+		publisher:        publisher,
+		currentMode:      make(map[int]client.Mode),
+		currentFrequency: make(map[int]int),
+		currentTX:        make(map[int]bool),
+		currentTune:      make(map[int]bool),
+	}
+	result.Enable(false)
+	return result
+}
+
+func (p *statePublisher) Enable(enabled bool) {
+	if p.connected && !enabled {
+		p.publisher.PublishState(p.connection, hamdeck.StateError, hamdeck.ErrConnectionLost.Error())
+	}
+	p.connected = enabled
+	p.publisher.PublishState(p.connection, hamdeck.StateConnected, hamdeck.FormatBoolState(enabled))
+}
+
+func (p *statePublisher) SetTRX(trx int) {
+	p.currentTRX = trx
+	p.publishMode()
+	p.publishFrequency()
+	p.publishPTT()
+}
+
+func (p *statePublisher) SetMode(trx int, mode client.Mode) {
+	p.currentMode[trx] = mode
+	if trx == p.currentTRX {
+		p.publishMode()
+	}
+}
+
+func (p *statePublisher) SetVFOFrequency(trx int, vfo client.VFO, frequency int) {
+	if vfo != client.VFOA {
+		return
+	}
+	p.currentFrequency[trx] = frequency
+	if trx == p.currentTRX {
+		p.publishFrequency()
+	}
+}
+
+func (p *statePublisher) SetTX(trx int, enabled bool) {
+	p.currentTX[trx] = enabled
+	if trx == p.currentTRX {
+		p.publishPTT()
+	}
+}
+
+func (p *statePublisher) SetTune(trx int, enabled bool) {
+	p.currentTune[trx] = enabled
+	if trx == p.currentTRX {
+		p.publishPTT()
+	}
+}
+
+func (p *statePublisher) publishMode() {
+	mode, ok := p.currentMode[p.currentTRX]
+	if !ok {
+		return
+	}
+	p.publisher.PublishState(p.connection, hamdeck.StateMode, string(mode))
+}
+
+func (p *statePublisher) publishFrequency() {
+	frequency, ok := p.currentFrequency[p.currentTRX]
+	if !ok {
+		return
+	}
+	p.publisher.PublishState(p.connection, hamdeck.StateFrequency, strconv.Itoa(frequency))
+
+	band := bandplan.IARURegion1.ByFrequency(hamradio.Frequency(frequency))
+	p.publisher.PublishState(p.connection, hamdeck.StateBand, string(band.Name))
+}
+
+func (p *statePublisher) publishPTT() {
+	ptt := p.currentTX[p.currentTRX] || p.currentTune[p.currentTRX]
+	p.publisher.PublishState(p.connection, hamdeck.StatePTT, hamdeck.FormatBoolState(ptt))
+}